@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 var _ Package = &Provider{}
@@ -195,3 +196,38 @@ func (p *ConfigurationRevision) GetRevision() int64 {
 func (p *ConfigurationRevision) SetRevision(r int64) {
 	p.Spec.Revision = r
 }
+
+var _ PackageRevisionList = &ProviderRevisionList{}
+var _ PackageRevisionList = &ConfigurationRevisionList{}
+
+// PackageRevisionList is the interface satisfied by package revision list
+// types.
+// +k8s:deepcopy-gen=false
+type PackageRevisionList interface {
+	runtime.Object
+
+	// GetRevisions gets the list of PackageRevisions in a PackageRevisionList.
+	// This is a costly operation, but allows for treating different revision
+	// list types as a single interface.
+	GetRevisions() []PackageRevision
+}
+
+// GetRevisions of this ProviderRevisionList.
+func (p *ProviderRevisionList) GetRevisions() []PackageRevision {
+	prs := make([]PackageRevision, len(p.Items))
+	for i, r := range p.Items {
+		r := r // Pin range variable so we can take its address.
+		prs[i] = &r
+	}
+	return prs
+}
+
+// GetRevisions of this ConfigurationRevisionList.
+func (p *ConfigurationRevisionList) GetRevisions() []PackageRevision {
+	prs := make([]PackageRevision, len(p.Items))
+	for i, r := range p.Items {
+		r := r // Pin range variable so we can take its address.
+		prs[i] = &r
+	}
+	return prs
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configurations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/cmd/cli/kube"
+	"github.com/hasheddan/crank/pkg/install"
+	"github.com/hasheddan/crank/pkg/prompt"
+)
+
+var (
+	installName        string
+	installPullSecrets []string
+	installPullPolicy  string
+	installWait        bool
+	installWaitTimeout time.Duration
+)
+
+// installer will install a Crossplane Configuration.
+var installer = &cobra.Command{
+	Use:   "install <package>",
+	Short: "Install a Crossplane Configuration",
+	Long:  ``,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := install.ValidatePullPolicy(installPullPolicy); err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		c, err := kube.Default.Client()
+		if err != nil {
+			return err
+		}
+		p := install.NewConfiguration(install.Options{
+			Name:        installName,
+			Package:     args[0],
+			PullSecrets: installPullSecrets,
+			PullPolicy:  corev1.PullPolicy(installPullPolicy),
+		})
+		ctx, cancel := kube.Default.Context()
+		defer cancel()
+		if err := c.Create(ctx, p); err != nil {
+			return errors.Wrapf(err, "cannot install configuration %s", p.GetName())
+		}
+		fmt.Println(prompt.FmtInfo(fmt.Sprintf("Configuration %s installed from %s.", p.GetName(), p.Spec.Package)))
+		if !installWait {
+			return nil
+		}
+		wctx, wcancel := context.WithTimeout(context.Background(), installWaitTimeout)
+		defer wcancel()
+		if err := install.Wait(wctx, c, os.Stdout, p, &v1alpha1.ConfigurationRevision{}, install.DefaultInterval); err != nil {
+			return err
+		}
+		fmt.Println(prompt.FmtInfo(fmt.Sprintf("✔️  Configuration %s is ready.", p.GetName())))
+		return nil
+	},
+}
+
+func init() {
+	installer.Flags().StringVar(&installName, "name", "", "Name of the Configuration. Derived from the package if not set.")
+	installer.Flags().StringArrayVar(&installPullSecrets, "pull-secret", nil, "Name of a secret used to pull the package. May be repeated.")
+	installer.Flags().StringVar(&installPullPolicy, "pull-policy", "", "Pull policy of the package. One of Always, IfNotPresent, Never.")
+	installer.Flags().BoolVar(&installWait, "wait", false, "Wait for the Configuration to become ready, reporting its progress.")
+	installer.Flags().DurationVar(&installWaitTimeout, "wait-timeout", 5*time.Minute, "How long to wait for the Configuration to become ready.")
+}
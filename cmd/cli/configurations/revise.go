@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configurations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/cmd/cli/kube"
+	"github.com/hasheddan/crank/pkg/install"
+	"github.com/hasheddan/crank/pkg/prompt"
+)
+
+var reviseWaitTimeout time.Duration
+
+func newRevisionList() v1alpha1.PackageRevisionList { return &v1alpha1.ConfigurationRevisionList{} }
+
+// upgrader will upgrade a Crossplane Configuration to a new package.
+var upgrader = &cobra.Command{
+	Use:   "upgrade <name> <package>",
+	Short: "Upgrade a Crossplane Configuration to a new package",
+	Long:  `Upgrade a Crossplane Configuration to a new package, activating its revision once it has been created.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return revise(args[0], func(ctx context.Context, c client.Client, p *v1alpha1.Configuration, _ []v1alpha1.PackageRevision) (v1alpha1.PackageRevision, error) {
+			if p.Spec.Package != args[1] {
+				p.Spec.Package = args[1]
+				if err := c.Update(ctx, p); err != nil {
+					return nil, errors.Wrapf(err, "cannot upgrade configuration %s", p.GetName())
+				}
+			}
+			fmt.Println(prompt.FmtInfo(fmt.Sprintf("Waiting for a revision of %s from %s...", p.GetName(), args[1])))
+			return install.WaitForRevision(ctx, c, p, newRevisionList, args[1], install.DefaultInterval)
+		})
+	},
+}
+
+// activator will activate a revision of a Crossplane Configuration.
+var activator = &cobra.Command{
+	Use:   "activate <name> <revision>",
+	Short: "Activate a revision of a Crossplane Configuration",
+	Long:  `Activate a revision of a Crossplane Configuration. The revision may be identified by its name or number.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return revise(args[0], func(_ context.Context, _ client.Client, _ *v1alpha1.Configuration, revs []v1alpha1.PackageRevision) (v1alpha1.PackageRevision, error) {
+			return install.FindRevision(revs, args[1])
+		})
+	},
+}
+
+// rollbacker will roll a Crossplane Configuration back to its previous revision.
+var rollbacker = &cobra.Command{
+	Use:   "rollback <name>",
+	Short: "Roll a Crossplane Configuration back to its previous revision",
+	Long:  ``,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return revise(args[0], func(_ context.Context, _ client.Client, _ *v1alpha1.Configuration, revs []v1alpha1.PackageRevision) (v1alpha1.PackageRevision, error) {
+			return install.Previous(revs)
+		})
+	},
+}
+
+// revise activates the revision of the named Configuration returned by the supplied
+// function, then waits for it to control its CustomResourceDefinitions.
+func revise(name string, target func(ctx context.Context, c client.Client, p *v1alpha1.Configuration, revs []v1alpha1.PackageRevision) (v1alpha1.PackageRevision, error)) error {
+	c, err := kube.Default.Client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), reviseWaitTimeout)
+	defer cancel()
+	p := &v1alpha1.Configuration{}
+	if err := c.Get(ctx, types.NamespacedName{Name: name}, p); err != nil {
+		return errors.Wrapf(err, "cannot get configuration %s", name)
+	}
+	revs, err := install.Revisions(ctx, c, p, newRevisionList())
+	if err != nil {
+		return err
+	}
+	t, err := target(ctx, c, p, revs)
+	if err != nil {
+		return err
+	}
+	// The target may be a revision that was created after revs were listed.
+	if revs, err = install.Revisions(ctx, c, p, newRevisionList()); err != nil {
+		return err
+	}
+	if t, err = install.FindRevision(revs, t.GetName()); err != nil {
+		return err
+	}
+	if err := install.Activate(ctx, c, p, revs, t); err != nil {
+		return err
+	}
+	fmt.Println(prompt.FmtInfo(fmt.Sprintf("Activated revision %d (%s) of configuration %s.", t.GetRevision(), t.GetName(), p.GetName())))
+	if err := install.WaitForOwnership(ctx, c, os.Stdout, revs, t, install.DefaultInterval); err != nil {
+		return err
+	}
+	fmt.Println(prompt.FmtInfo(fmt.Sprintf("✔️  Configuration %s is running %s.", p.GetName(), t.GetSource())))
+	return nil
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{upgrader, activator, rollbacker} {
+		cmd.Flags().DurationVar(&reviseWaitTimeout, "wait-timeout", 5*time.Minute, "How long to wait for the revision to become active.")
+	}
+}
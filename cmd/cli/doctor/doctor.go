@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package doctor checks that the packages installed in a cluster are
+// consistent.
+package doctor
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hasheddan/crank/cmd/cli/kube"
+	"github.com/hasheddan/crank/pkg/doctor"
+	"github.com/hasheddan/crank/pkg/prompt"
+)
+
+var fix bool
+
+// Root checks that packages, their revisions, and the PackageLock are
+// consistent.
+var Root = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that installed packages are consistent",
+	Long: `Check that installed packages, their revisions, and the PackageLock are
+consistent, explaining each problem found. Problems are repaired if --fix is
+set.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		c, err := kube.Default.Client()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := kube.Default.Context()
+		defer cancel()
+
+		// Problems are repaired as they are found, because later checks
+		// depend on the repairs of earlier ones.
+		unresolved := 0
+		for _, check := range doctor.Checks {
+			problems, err := check.Run(ctx, c)
+			if err != nil {
+				return errors.Wrapf(err, "cannot check %s", check.Name)
+			}
+			if len(problems) == 0 {
+				fmt.Println(prompt.FmtInfo("✔ " + check.Name))
+				continue
+			}
+			fmt.Println(prompt.FmtWarning("✖ " + check.Name))
+			for _, p := range problems {
+				fmt.Printf("  %s %s.\n", p.Object, p.Message)
+				switch {
+				case p.Fix == "":
+					fmt.Println(prompt.FmtNotice("    Cannot be fixed automatically."))
+					unresolved++
+				case !fix:
+					fmt.Println(prompt.FmtNotice("    Fix: " + p.Fix + "."))
+					unresolved++
+				default:
+					if err := p.Repair(ctx, c); err != nil {
+						fmt.Println(prompt.FmtError("    Cannot fix: " + err.Error()))
+						unresolved++
+						continue
+					}
+					fmt.Println(prompt.FmtInfo("    Fixed: " + p.Fix + "."))
+				}
+			}
+		}
+		switch {
+		case unresolved == 0:
+			return nil
+		case fix:
+			return errors.Errorf("%d problem(s) could not be fixed", unresolved)
+		default:
+			return errors.Errorf("%d problem(s) found; run crank doctor --fix to repair them", unresolved)
+		}
+	},
+}
+
+func init() {
+	Root.Flags().BoolVar(&fix, "fix", false, "Repair the problems that are found.")
+}
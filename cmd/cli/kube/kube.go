@@ -0,0 +1,104 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kube builds clients of the Kubernetes API server for CLI commands.
+package kube
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/hasheddan/crank/apis"
+)
+
+// DefaultTimeout is the default timeout of requests to the API server.
+const DefaultTimeout = 30 * time.Second
+
+// Flags configure how clients connect to the API server.
+type Flags struct {
+	// Kubeconfig is the path of a kubeconfig file. The default loading rules
+	// are used if it is empty.
+	Kubeconfig string
+
+	// KubeContext is the kubeconfig context to use. The current context is used
+	// if it is empty.
+	KubeContext string
+
+	// Timeout of requests to the API server. Zero means no timeout.
+	Timeout time.Duration
+}
+
+// Default flags, shared by every command.
+var Default = &Flags{Timeout: DefaultTimeout}
+
+// AddFlags adds persistent flags that configure f to the supplied command.
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	fs := cmd.PersistentFlags()
+	fs.StringVar(&f.Kubeconfig, "kubeconfig", f.Kubeconfig, "Path to the kubeconfig file to use.")
+	fs.StringVar(&f.KubeContext, "context", f.KubeContext, "The kubeconfig context to use.")
+	fs.DurationVar(&f.Timeout, "timeout", f.Timeout, "Timeout of requests to the API server. Zero means no timeout.")
+}
+
+// Config returns the REST config described by f.
+func (f *Flags) Config() (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = f.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: f.KubeContext}
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot load kubeconfig")
+	}
+	cfg.Timeout = f.Timeout
+	return cfg, nil
+}
+
+// Client returns a client of the API server described by f, whose scheme
+// includes the crank, core Kubernetes, and CustomResourceDefinition API types.
+func (f *Flags) Client() (client.Client, error) {
+	cfg, err := f.Config()
+	if err != nil {
+		return nil, err
+	}
+	s := runtime.NewScheme()
+	if err := apis.AddToScheme(s); err != nil {
+		return nil, errors.Wrap(err, "cannot build scheme")
+	}
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		return nil, errors.Wrap(err, "cannot build scheme")
+	}
+	if err := apiextensions.AddToScheme(s); err != nil {
+		return nil, errors.Wrap(err, "cannot build scheme")
+	}
+	c, err := client.New(cfg, client.Options{Scheme: s})
+	return c, errors.Wrapf(err, "cannot connect to API server %s", cfg.Host)
+}
+
+// Context returns a context that is cancelled after the timeout of f.
+func (f *Flags) Context() (context.Context, context.CancelFunc) {
+	if f.Timeout == 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), f.Timeout)
+}
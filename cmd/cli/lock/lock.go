@@ -0,0 +1,69 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lock interacts with PackageLock files.
+package lock
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hasheddan/crank/pkg/lock"
+	"github.com/hasheddan/crank/pkg/prompt"
+)
+
+// Root will interact with PackageLock files.
+var Root = &cobra.Command{
+	Use:   "lock",
+	Short: "Interact with PackageLock files",
+}
+
+// validator will validate a PackageLock file.
+var validator = &cobra.Command{
+	Use:   "validate <file>",
+	Short: "Validate a PackageLock file",
+	Long: `Validates that the package manager can build a dependency graph from a
+PackageLock file. Missing dependencies are reported with the path from every
+package that requires them, and cycles with the path around the cycle.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		b, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			return errors.Wrap(err, "cannot read PackageLock")
+		}
+		l, err := lock.Read(b)
+		if err != nil {
+			return err
+		}
+		problems := lock.Validate(l.Spec.Packages)
+		if len(problems) == 0 {
+			fmt.Println(prompt.FmtInfo(fmt.Sprintf("PackageLock %s is valid: %d packages.", args[0], len(l.Spec.Packages))))
+			return nil
+		}
+		for _, p := range problems {
+			fmt.Println(prompt.FmtWarning(p.String()))
+		}
+		return errors.Errorf("PackageLock %s has %d problem(s)", args[0], len(problems))
+	},
+}
+
+func init() {
+	Root.AddCommand(validator)
+}
@@ -17,9 +17,18 @@ limitations under the License.
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/hasheddan/crank/cmd/cli/configurations"
+	"github.com/hasheddan/crank/cmd/cli/doctor"
+	"github.com/hasheddan/crank/cmd/cli/kube"
+	"github.com/hasheddan/crank/cmd/cli/lock"
 	"github.com/hasheddan/crank/cmd/cli/packages"
+	"github.com/hasheddan/crank/cmd/cli/plan"
 	"github.com/hasheddan/crank/cmd/cli/providers"
+	"github.com/hasheddan/crank/cmd/cli/state"
+	"github.com/hasheddan/crank/pkg/prompt"
 	"github.com/spf13/cobra"
 )
 
@@ -28,14 +37,25 @@ var Root = &cobra.Command{
 	Use:   "crank",
 	Short: "The next generation package manager for Crossplane",
 	Long:  `The next generation package manager for Crossplane`,
+	// Errors are printed by main.
+	SilenceErrors: true,
 }
 
 func init() {
+	kube.Default.AddFlags(Root)
 	Root.AddCommand(configurations.Root)
+	Root.AddCommand(doctor.Root)
+	Root.AddCommand(lock.Root)
 	Root.AddCommand(packages.Root)
+	Root.AddCommand(plan.Root)
 	Root.AddCommand(providers.Root)
+	Root.AddCommand(state.Exporter)
+	Root.AddCommand(state.Applier)
 }
 
 func main() {
-	Root.Execute()
+	if err := Root.Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, prompt.FmtError(err.Error()))
+		os.Exit(1)
+	}
 }
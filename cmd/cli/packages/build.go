@@ -0,0 +1,211 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/hasheddan/crank/pkg/build"
+	"github.com/hasheddan/crank/pkg/parser"
+	"github.com/hasheddan/crank/pkg/prompt"
+	"github.com/hasheddan/crank/pkg/unpack"
+)
+
+// Formats to which a package may be built.
+const (
+	buildFormatTarball = "tarball"
+	buildFormatOCI     = "oci"
+)
+
+var (
+	buildFormat   string
+	buildOutput   string
+	buildTag      string
+	buildSkipLint bool
+	buildSkipDeps bool
+	buildCacheDir string
+)
+
+// builder will build a Crossplane package into an image.
+var builder = &cobra.Command{
+	Use:   "build [path]",
+	Short: "Build a Crossplane package into an image",
+	Long: `Builds a Crossplane package into an image without a container runtime. The
+image has a single layer that contains the package in a .registry directory.
+If the package has a .registry directory it is built instead of the package.
+Dockerfile, .dockerignore, .git and the paths ignored by crossplane.yaml are
+not built.
+
+The package is linted before it is built, and is not built if any finding has
+error severity. Dependencies are pulled to lint the package as 'crank package
+lint' does. The dependencies of the package are recorded in the annotations of
+the image, at the digests locked by its crank.lock if it has one.
+
+The image is written as a tarball that may be loaded with 'docker load', or as
+an OCI image layout with --format oci. Every timestamp of the image is set to
+the Unix epoch, or to SOURCE_DATE_EPOCH if it is set, so that the same package
+is always built to the same digest.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if buildFormat != buildFormatTarball && buildFormat != buildFormatOCI {
+			return errors.Errorf("unknown format %q: must be one of %s, %s", buildFormat, buildFormatTarball, buildFormatOCI)
+		}
+		cmd.SilenceUsage = true
+		o := buildOptions{format: buildFormat, output: buildOutput, tag: buildTag, lint: !buildSkipLint}
+		if o.lint && !buildSkipDeps {
+			var err error
+			if o.fetcher, err = cachingFetcher(buildCacheDir); err != nil {
+				return err
+			}
+		}
+		t, err := sourceDateEpoch()
+		if err != nil {
+			return err
+		}
+		o.time = t
+		return buildPackage(os.Stdout, afero.NewOsFs(), pathArg(args), o)
+	},
+}
+
+func init() {
+	builder.Flags().StringVar(&buildFormat, "format", buildFormatTarball, "Format of the built image. One of: tarball, oci.")
+	builder.Flags().StringVarP(&buildOutput, "output", "o", "", "Path to which the image is written. Defaults to the name of the package with a .tar or .oci extension.")
+	builder.Flags().StringVarP(&buildTag, "tag", "t", "", "Reference with which the image is tagged. Defaults to the name of the package tagged latest.")
+	builder.Flags().BoolVar(&buildSkipLint, "skip-lint", false, "Build without linting the package.")
+	builder.Flags().BoolVar(&buildSkipDeps, "skip-dependencies", false, "Lint without pulling package dependencies.")
+	builder.Flags().StringVar(&buildCacheDir, "cache-dir", defaultCacheDir(), "Directory in which pulled packages are cached.")
+}
+
+// buildOptions configure how a package is built.
+type buildOptions struct {
+	// format and output path of the image, and the reference with which it
+	// is tagged. The output and tag are derived from the name of the
+	// package if they are empty.
+	format string
+	output string
+	tag    string
+
+	// lint the package before it is built, fetching its dependencies with
+	// fetcher unless it is nil.
+	lint    bool
+	fetcher *unpack.Fetcher
+
+	// time to which every timestamp of the image is set.
+	time time.Time
+}
+
+// buildPackage builds the package at path into an image and writes it to the
+// output path.
+func buildPackage(w io.Writer, fs afero.Fs, path string, o buildOptions) error {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	root := dir
+	if ok, _ := afero.DirExists(fs, filepath.Join(dir, unpack.RegistryDir)); ok {
+		root = filepath.Join(dir, unpack.RegistryDir)
+	}
+	if o.lint {
+		code, err := lintPackage(w, fs, root, lintOptions{format: "text", fetcher: o.fetcher})
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return errors.New("cannot build a package that has lint errors")
+		}
+	}
+	pkg, err := parser.NewParser(fs).ParsePackage(root)
+	if err != nil {
+		return err
+	}
+	deps, err := pinnedDependencies(fs, root, pkg)
+	if err != nil {
+		return err
+	}
+
+	n := pkg.Name
+	if n == "" {
+		n = filepath.Base(dir)
+	}
+	if o.tag == "" {
+		o.tag = n + ":latest"
+	}
+	ref, err := name.ParseReference(o.tag)
+	if err != nil {
+		return errors.Wrapf(err, "cannot parse tag %s", o.tag)
+	}
+	if o.output == "" {
+		o.output = n + ".tar"
+		if o.format == buildFormatOCI {
+			o.output = n + ".oci"
+		}
+	}
+	out, err := filepath.Abs(o.output)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return errors.Wrapf(err, "cannot create directory for %s", o.output)
+	}
+	img, err := build.Image(fs, pkg, build.WithTime(o.time), build.WithDependencies(deps...), build.WithExclude(out))
+	if err != nil {
+		return err
+	}
+	switch o.format {
+	case buildFormatOCI:
+		a := build.Annotations(pkg, o.time, deps...)
+		a[build.AnnotationRefName] = ref.Identifier()
+		err = build.WriteLayout(out, img, a)
+	default:
+		err = errors.Wrapf(tarball.WriteToFile(out, ref, img), "cannot write image to %s", o.output)
+	}
+	if err != nil {
+		return err
+	}
+	d, err := img.Digest()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, prompt.FmtNotice(fmt.Sprintf("Built %s (%s) to %s.", ref, d, o.output)))
+	return err
+}
+
+// sourceDateEpoch returns the time set by SOURCE_DATE_EPOCH, or the Unix epoch
+// if it is not set.
+func sourceDateEpoch() (time.Time, error) {
+	s, ok := os.LookupEnv("SOURCE_DATE_EPOCH")
+	if !ok || s == "" {
+		return build.Epoch, nil
+	}
+	i, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "cannot parse SOURCE_DATE_EPOCH %q", s)
+	}
+	return time.Unix(i, 0).UTC(), nil
+}
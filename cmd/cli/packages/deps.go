@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/hasheddan/crank/pkg/parser"
+	"github.com/hasheddan/crank/pkg/prompt"
+	"github.com/hasheddan/crank/pkg/suggest"
+)
+
+var (
+	depsTable string
+	depsIndex string
+)
+
+// depsRoot will interact with the dependencies of a Crossplane package.
+var depsRoot = &cobra.Command{
+	Use:   "deps",
+	Short: "Interact with the dependencies of a Crossplane package",
+}
+
+// suggester will suggest the dependencies of a Crossplane package.
+var suggester = &cobra.Command{
+	Use:   "suggest [path]",
+	Short: "Suggests dependencies from the resources a package composes",
+	Long: `Suggests the packages a Crossplane package depends on from the API groups of
+the resources its Compositions compose.
+
+Groups are mapped to packages using a built-in table of well known providers,
+which may be extended with --dependency-table or --dependency-index. Both
+supply YAML of the form:
+
+  groups:
+    gcp.crossplane.io: crossplane/provider-gcp:v0.11.0`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		fs := afero.NewOsFs()
+		root, err := filepath.Abs(pathArg(args))
+		if err != nil {
+			return err
+		}
+		pkg, err := parser.NewParser(fs).ParsePackage(root)
+		if err != nil {
+			return err
+		}
+		t, err := dependencyTable(fs)
+		if err != nil {
+			return err
+		}
+		printSuggestions(os.Stdout, suggest.Suggest(pkg, t))
+		return nil
+	},
+}
+
+func init() {
+	depsRoot.AddCommand(suggester)
+	addDependencyTableFlags(suggester)
+}
+
+// addDependencyTableFlags adds the flags that configure dependency suggestion
+// to the supplied command.
+func addDependencyTableFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&depsTable, "dependency-table", "", "YAML file mapping API groups to the packages that provide them.")
+	cmd.Flags().StringVar(&depsIndex, "dependency-index", "", "URL of a registry index mapping API groups to the packages that provide them.")
+}
+
+// dependencyTable returns the built-in dependency table, extended by the
+// table and index supplied by flags.
+func dependencyTable(fs afero.Fs) (suggest.Table, error) {
+	t := suggest.DefaultTable
+	if depsTable != "" {
+		b, err := afero.ReadFile(fs, depsTable)
+		if err != nil {
+			return suggest.Table{}, errors.Wrap(err, "cannot read dependency table")
+		}
+		ft, err := suggest.ParseTable(b)
+		if err != nil {
+			return suggest.Table{}, err
+		}
+		t = t.Merge(ft)
+	}
+	if depsIndex != "" {
+		it, err := suggest.FetchIndex(&http.Client{Timeout: 30 * time.Second}, depsIndex)
+		if err != nil {
+			return suggest.Table{}, err
+		}
+		t = t.Merge(it)
+	}
+	return t, nil
+}
+
+// printSuggestions writes the suggested dependencies to w.
+func printSuggestions(w io.Writer, s []suggest.Suggestion) {
+	if len(s) == 0 {
+		fmt.Fprintln(w, prompt.FmtInfo("No dependencies suggested.")) // nolint:errcheck
+		return
+	}
+	fmt.Fprintln(w, prompt.FmtNotice(fmt.Sprintf("Suggested %d dependencies:", len(s)))) // nolint:errcheck
+	for _, d := range s {
+		groups := strings.Join(d.Groups, ", ")
+		switch {
+		case d.Package == "":
+			fmt.Fprintln(w, prompt.FmtWarning(fmt.Sprintf("-- no known package provides %s", groups))) // nolint:errcheck
+		case d.Version == "":
+			fmt.Fprintln(w, prompt.FmtInfo(fmt.Sprintf("-- %s (%s)", d.Package, groups))) // nolint:errcheck
+		default:
+			fmt.Fprintln(w, prompt.FmtInfo(fmt.Sprintf("-- %s:%s (%s)", d.Package, d.Version, groups))) // nolint:errcheck
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/hasheddan/crank/pkg/compat"
+	"github.com/hasheddan/crank/pkg/parser"
+	"github.com/hasheddan/crank/pkg/prompt"
+	"github.com/hasheddan/crank/pkg/unpack"
+)
+
+// Exit codes returned by diff.
+const (
+	diffExitBreaking = 1
+	diffExitError    = 2
+)
+
+var diffOutput string
+
+// differ will compare two versions of a Crossplane package.
+var differ = &cobra.Command{
+	Use:   "diff <old> <new>",
+	Short: "Detects breaking changes between two versions of a Crossplane package",
+	Long: `Compares the CustomResourceDefinitions and InfrastructureDefinitions of two
+versions of a Crossplane package. Either version may be a directory or an image
+reference.
+
+Every change is classified as breaking or non-breaking. Diff exits 1 if any
+change is breaking and 2 if the packages could not be compared.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		code, err := diffPackages(os.Stdout, afero.NewOsFs(), unpack.NewFetcher(), args[0], args[1], diffOutput)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, prompt.FmtError(err.Error()))
+		}
+		os.Exit(code)
+	},
+}
+
+func init() {
+	differ.Flags().StringVarP(&diffOutput, "output", "o", "text", "Output format. One of: text, json.")
+}
+
+// diffPackages compares the old and new packages and writes the changes to w.
+// It returns the exit code for the command.
+func diffPackages(w io.Writer, fs afero.Fs, f *unpack.Fetcher, oldRef, newRef, format string) (int, error) {
+	if format != "text" && format != "json" {
+		return diffExitError, errors.Errorf("unknown output format %q: must be one of json, text", format)
+	}
+	prev, err := loadPackage(fs, f, oldRef)
+	if err != nil {
+		return diffExitError, err
+	}
+	next, err := loadPackage(fs, f, newRef)
+	if err != nil {
+		return diffExitError, err
+	}
+	changes, err := compat.Compare(prev, next)
+	if err != nil {
+		return diffExitError, err
+	}
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(struct {
+			Changes []compat.Change `json:"changes"`
+		}{Changes: changes})
+	} else {
+		err = writeChanges(w, changes)
+	}
+	if err != nil {
+		return diffExitError, err
+	}
+	if compat.Breaking(changes) {
+		return diffExitBreaking, nil
+	}
+	return 0, nil
+}
+
+// loadPackage parses the package in the directory ref, or fetches it if ref
+// is not a directory.
+func loadPackage(fs afero.Fs, f *unpack.Fetcher, ref string) (*parser.Package, error) {
+	if ok, _ := afero.DirExists(fs, ref); ok {
+		root, err := filepath.Abs(ref)
+		if err != nil {
+			return nil, err
+		}
+		return parser.NewParser(fs).ParsePackage(root)
+	}
+	return f.Package(ref)
+}
+
+func writeChanges(w io.Writer, changes []compat.Change) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, prompt.FmtInfo("No changes found."))
+		return err
+	}
+	breaking := 0
+	for _, c := range changes {
+		location := []string{c.Object}
+		if c.Version != "" {
+			location = append(location, c.Version)
+		}
+		if c.Field != "" {
+			location = append(location, c.Field)
+		}
+		line := fmt.Sprintf("%s: %s", strings.Join(location, " "), c.Message)
+		if c.Breaking {
+			breaking++
+			line = prompt.FmtError("BREAKING " + line)
+		} else {
+			line = prompt.FmtInfo("         " + line)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, prompt.FmtWarning(fmt.Sprintf("Found %d changes, %d breaking.", len(changes), breaking)))
+	return err
+}
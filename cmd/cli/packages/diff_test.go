@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/pkg/unpack"
+)
+
+const clusterNetworkCRD = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: networks.example.org
+spec:
+  group: example.org
+  names:
+    kind: ClusterNetwork
+    listKind: ClusterNetworkList
+    plural: networks
+    singular: network
+  scope: Cluster
+  version: v1alpha1
+`
+
+func TestDiffPackages(t *testing.T) {
+	cases := map[string]struct {
+		next   string
+		format string
+		want   int
+
+		// output is contained in the written changes.
+		output string
+	}{
+		"NoChanges": {
+			next:   networkCRD,
+			format: "text",
+			want:   0,
+			output: "No changes found.",
+		},
+		"Breaking": {
+			next:   clusterNetworkCRD,
+			format: "text",
+			want:   diffExitBreaking,
+			output: "BREAKING CustomResourceDefinition networks.example.org: kind renamed from Network to ClusterNetwork",
+		},
+		"JSON": {
+			next:   clusterNetworkCRD,
+			format: "json",
+			want:   diffExitBreaking,
+			output: `"breaking": true`,
+		},
+		"UnknownFormat": {
+			next:   networkCRD,
+			format: "yaml",
+			want:   diffExitError,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for path, content := range map[string]string{
+				"/old/crossplane.yaml": configurationMeta,
+				"/old/network.yaml":    networkCRD,
+				"/new/crossplane.yaml": configurationMeta,
+				"/new/network.yaml":    tc.next,
+			} {
+				if err := afero.WriteFile(fs, path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			w := &bytes.Buffer{}
+			got, err := diffPackages(w, fs, unpack.NewFetcher(), "/old", "/new", tc.format)
+			if (err != nil) != (tc.want == diffExitError) {
+				t.Errorf("diffPackages(...): unexpected error %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("diffPackages(...): want exit code %d, got %d", tc.want, got)
+			}
+			if !strings.Contains(w.String(), tc.output) {
+				t.Errorf("diffPackages(...): want output containing %q, got:\n%s", tc.output, w.String())
+			}
+		})
+	}
+}
@@ -17,21 +17,22 @@ limitations under the License.
 package packages
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"strings"
 	"text/template"
 
+	"github.com/ghodss/yaml"
 	"github.com/hasheddan/crank/pkg/parser"
 	"github.com/hasheddan/crank/pkg/prompt"
+	"github.com/hasheddan/crank/pkg/suggest"
+	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 )
 
-var (
-	pkg *parser.Package
-)
-
 // CrossplaneYAML is the crossplane.yaml template.
 var CrossplaneYAML = template.Must(template.New("crossplane").Parse(
 	`apiVersion: pkg.crossplane.io/v1alpha1
@@ -41,8 +42,8 @@ metadata:
   annotations:
 spec:
   dependsOn:{{ range $i, $dep := .Dependencies }}
-  - package: {{ $dep.Package }}
-    version: {{ $dep.Version }}{{ end }}
+  - package: {{ $dep.Package }}{{ if $dep.Version }}
+    version: {{ $dep.Version }}{{ end }}{{ end }}
   ignore:
   - path: examples/
 `))
@@ -56,128 +57,359 @@ const (
 	Provider      PackageType = "Provider"
 )
 
-// Steps in prompter execution.
-const (
-	First prompt.Step = iota
-	Second
-	Third
-	Fourth
-)
-
 type initer struct {
-	PackageType  PackageType
-	Name         string
-	Dependencies []Dependency
+	PackageType  PackageType  `json:"type,omitempty"`
+	Name         string       `json:"name,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+
+	// Starter includes a starter InfrastructureDefinition and Composition in
+	// the package.
+	Starter bool `json:"starter,omitempty"`
+
+	// suggested dependencies are offered as the default answers when
+	// dependencies are prompted for.
+	suggested []Dependency
 }
 
 // A Dependency is a dependency of a package.
 type Dependency struct {
-	Package string
-	Version string
+	Package string `json:"package"`
+	Version string `json:"version,omitempty"`
 }
 
-var i = &initer{}
-
-// Init Prompter
-var p = prompt.NewPrompter("What type of Package would you like to init?", bufio.NewScanner(os.Stdin), map[prompt.Step]prompt.Fn{
-	First:  firstStep,
-	Second: secondStep,
-	Third:  prompt.BinaryFn(thirdStep),
-	Fourth: prompt.BareFn(fourthStep),
-}, First)
-
-var depP = prompt.NewPrompter("What is the package dependency name?", bufio.NewScanner(os.Stdin), map[prompt.Step]prompt.Fn{
-	First:  depStepOne,
-	Second: depStepTwo,
-}, First)
+// String returns the dependency in the form package@version, or the package
+// if it has no version.
+func (d Dependency) String() string {
+	if d.Version == "" {
+		return d.Package
+	}
+	return d.Package + "@" + d.Version
+}
 
-func firstStep(p prompt.Prompter, s string) (string, bool) {
-	if s != "Configuration" && s != "Provider" {
-		return "Package must be of type Configuration or Provider.", false
+// parseDependency parses a dependency of the form package@version.
+func parseDependency(s string) (Dependency, error) {
+	i := strings.LastIndex(s, "@")
+	if i <= 0 || i == len(s)-1 {
+		return Dependency{}, errors.Errorf("invalid dependency %q: must be of the form package@version", s)
 	}
-	i.PackageType = PackageType(s)
-	p.SetStep(Second)
-	return fmt.Sprintf("What would you like to name your %s?", s), false
+	return Dependency{Package: s[:i], Version: s[i+1:]}, nil
 }
 
-func secondStep(p prompt.Prompter, s string) (string, bool) {
-	i.Name = s
-	p.SetStep(Third)
-	return fmt.Sprintf("Does %s have any dependencies? (Y/N)", s), false
+// complete returns true if every value required to init a package has been
+// supplied.
+func (i *initer) complete() bool {
+	return i.PackageType != "" && i.Name != ""
 }
 
-func thirdStep(b bool, p prompt.Prompter, s string) (string, bool) {
-	if !b {
-		p.SetStep(Fourth)
-		return fmt.Sprintf("Package of type %s will be initialized with name %s. Ok? (Y/N)", i.PackageType, i.Name), false
-	}
-	fmt.Println(prompt.FmtInfo("Enter 'done' when finished adding dependencies."))
-	if err := depP.Prompt(); err != nil {
-		panic(err)
-	}
-	p.SetStep(Fourth)
-	return fmt.Sprintf("Package of type %s will be initialized with name %s. Ok? (Y/N)", i.PackageType, i.Name), false
+// initFlags are the values supplied to init by flags.
+type initFlags struct {
+	packageType  string
+	name         string
+	dependencies []string
+	answers      string
+	starter      bool
 }
 
-func depStepOne(p prompt.Prompter, s string) (string, bool) {
-	if s == "done" {
-		return prompt.FmtInfo(fmt.Sprintf("Added %d dependencies.", len(i.Dependencies))), true
+var (
+	initF     initFlags
+	initForce bool
+)
+
+// steps returns the steps that prompt for the values that were not supplied
+// to the initer.
+func (i *initer) steps(p *prompt.Prompter) []prompt.Step {
+	typeSupplied, nameSupplied, depsSupplied, starterSupplied := i.PackageType != "", i.Name != "", len(i.Dependencies) > 0, i.Starter
+	return []prompt.Step{
+		{
+			Question: func() prompt.Question {
+				return prompt.Question{
+					Message: "What type of Package would you like to init?",
+					Options: []string{string(Configuration), string(Provider)},
+				}
+			},
+			Skip:   func() bool { return typeSupplied },
+			Answer: func(a []string) error { i.PackageType = PackageType(a[0]); return nil },
+		},
+		{
+			Question: func() prompt.Question {
+				return prompt.Question{Message: fmt.Sprintf("What would you like to name your %s?", i.PackageType)}
+			},
+			Skip:   func() bool { return nameSupplied },
+			Answer: func(a []string) error { i.Name = a[0]; return nil },
+		},
+		{
+			Question: func() prompt.Question {
+				return prompt.Confirm(fmt.Sprintf("Does %s have any dependencies?", i.Name), len(i.suggested) > 0)
+			},
+			Skip: func() bool { return depsSupplied },
+			Answer: func(a []string) error {
+				i.Dependencies = nil
+				if prompt.Yes(a) {
+					return i.promptDependencies(p)
+				}
+				return nil
+			},
+		},
+		{
+			Question: func() prompt.Question {
+				return prompt.Confirm("Include a starter InfrastructureDefinition and Composition?", false)
+			},
+			Skip:   func() bool { return starterSupplied || i.PackageType != Configuration },
+			Answer: func(a []string) error { i.Starter = prompt.Yes(a); return nil },
+		},
+		{
+			Question: func() prompt.Question {
+				return prompt.Confirm(fmt.Sprintf("Package of type %s will be initialized with name %s. Ok?", i.PackageType, i.Name), true)
+			},
+			Answer: func(a []string) error {
+				if !prompt.Yes(a) {
+					return prompt.ErrAborted
+				}
+				return nil
+			},
+		},
 	}
-	i.Dependencies = append(i.Dependencies, Dependency{Package: s})
-	p.SetStep(Second)
-	return fmt.Sprintf("What version of package %s is required?", s), false
 }
 
-func depStepTwo(p prompt.Prompter, s string) (string, bool) {
-	i.Dependencies[len(i.Dependencies)-1].Version = s
-	p.SetStep(First)
-	return "What is the package dependency name?", false
+// skipDependency is the answer that omits a suggested dependency.
+const skipDependency = "skip"
+
+// promptDependencies prompts for dependencies until the user enters done. The
+// suggested dependencies are offered as defaults first. A suggestion without a
+// version may be accepted as it is.
+func (i *initer) promptDependencies(p *prompt.Prompter) error {
+	p.Info("Enter 'done' when finished adding dependencies.")
+	for n := 0; ; n++ {
+		q := prompt.Question{
+			Message:  "What is the package dependency? (package@version)",
+			Default:  "done",
+			Validate: func(a []string) error { return validDependency(a[0]) },
+		}
+		var suggested *Dependency
+		if n < len(i.suggested) {
+			suggested = &i.suggested[n]
+			q.Message = fmt.Sprintf("What is the package dependency? (package@version, or %s to omit the suggestion)", skipDependency)
+			q.Default = suggested.String()
+			q.Validate = func(a []string) error {
+				if a[0] == suggested.String() {
+					return nil
+				}
+				return validDependency(a[0])
+			}
+		}
+		a, err := p.Ask(q)
+		if err != nil {
+			return err
+		}
+		switch a[0] {
+		case "done":
+			p.Info(fmt.Sprintf("Added %d dependencies.", len(i.Dependencies)))
+			return nil
+		case skipDependency:
+			continue
+		}
+		if suggested != nil && a[0] == suggested.String() {
+			i.Dependencies = append(i.Dependencies, *suggested)
+			continue
+		}
+		d, _ := parseDependency(a[0])
+		i.Dependencies = append(i.Dependencies, d)
+	}
 }
 
-func fourthStep() (string, bool) {
-	fmt.Printf(prompt.FmtInfo("📦 Building package...\n"))
-	if err := CrossplaneYAML.Execute(os.Stdout, i); err != nil {
-		return "Failed to initialize package.", true
+func validDependency(s string) error {
+	if s == "done" || s == skipDependency {
+		return nil
 	}
-	return prompt.FmtInfo("✔️  Package initialized."), true
+	_, err := parseDependency(s)
+	return err
 }
 
 // initialize will init a Crossplane package.
 var initialize = &cobra.Command{
 	Use:   "init",
 	Short: "Initializes a Crossplane package",
-	Long:  `Initializes a Crossplane package.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	Long: `Initializes a Crossplane package.
+
+Values may be supplied with flags or read from an answers file, in which case
+they are not prompted for. Flags take precedence over the answers file. An
+answers file is YAML of the form:
+
+  type: Configuration
+  name: my-configuration
+  dependencies:
+  - package: crossplane/provider-gcp
+    version: v0.11.0
+  starter: true
+
+Init does not prompt if both the type and name of the package are supplied.
+
+If no dependencies are supplied, those suggested by the API groups of the
+resources composed by Compositions in the working directory are offered as the
+default answers when dependencies are prompted for. A suggestion without a
+version may be accepted as it is, or completed with a version. If init does not
+prompt, the suggestions that have a version are added to the dependencies. See
+'crank package deps suggest' for details.
+
+Init writes crossplane.yaml, a Dockerfile that builds the package into the
+.registry layer of an image, and an examples directory to the working
+directory. A starter InfrastructureDefinition and Composition are also written
+if --starter is supplied, and crossplane/provider-gcp, which provides the
+resource the starter Composition composes, is added to the dependencies.
+Existing files are not overwritten unless --force is supplied.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		fs := afero.NewOsFs()
-		parse := parser.NewParser(fs)
+		in := &initer{}
+		if err := supply(fs, in, initF); err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
 		root, err := os.Getwd()
 		if err != nil {
-			panic(err)
+			return err
 		}
-		pkg, err = parse.ParsePackage(root)
-		if err != nil {
-			panic(err)
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		p := prompt.NewPrompter(prompt.WithInterrupt(interrupt))
+		switch err := runInit(os.Stdout, fs, root, in, p, initForce); err {
+		case nil:
+			return nil
+		case prompt.ErrAborted, prompt.ErrInterrupted, prompt.ErrEOF:
+			return errors.Wrap(err, "package was not initialized")
+		default:
+			return errors.Wrap(err, "cannot initialize package")
+		}
+	},
+}
+
+func init() {
+	initialize.Flags().StringVar(&initF.packageType, "type", "", "Type of package. One of: Configuration, Provider.")
+	initialize.Flags().StringVar(&initF.name, "name", "", "Name of the package.")
+	initialize.Flags().StringArrayVar(&initF.dependencies, "dependency", nil, "Dependency of the package of the form package@version. May be repeated.")
+	initialize.Flags().StringVar(&initF.answers, "answers", "", "YAML file of answers to init prompts.")
+	initialize.Flags().BoolVar(&initF.starter, "starter", false, "Include a starter InfrastructureDefinition and Composition.")
+	initialize.Flags().BoolVar(&initForce, "force", false, "Overwrite existing files.")
+	addDependencyTableFlags(initialize)
+}
+
+// runInit initializes a package in root, prompting with p for the values that
+// were not supplied to the initer. It does not prompt if the type and name of
+// the package were supplied.
+func runInit(w io.Writer, fs afero.Fs, root string, in *initer, p *prompt.Prompter, force bool) error {
+	pkg, err := parser.NewParser(fs).ParsePackage(root)
+	if err != nil {
+		return err
+	}
+	if len(pkg.InfrastructureDefinitions) > 0 {
+		fmt.Fprintln(w, prompt.FmtNotice(fmt.Sprintf("Found %d InfrastructureDefinitions:", len(pkg.InfrastructureDefinitions)))) // nolint:errcheck
+	}
+	for _, id := range pkg.InfrastructureDefinitions {
+		fmt.Fprintln(w, prompt.FmtInfo(fmt.Sprintf("-- %s", id.Name))) // nolint:errcheck
+	}
+	if len(pkg.InfrastructurePublications) > 0 {
+		fmt.Fprintln(w, prompt.FmtNotice(fmt.Sprintf("Found %d InfrastructurePublications:", len(pkg.InfrastructurePublications)))) // nolint:errcheck
+	}
+	for _, ip := range pkg.InfrastructurePublications {
+		fmt.Fprintln(w, prompt.FmtInfo(fmt.Sprintf("-- %s", ip.Name))) // nolint:errcheck
+	}
+	if len(pkg.Compositions) > 0 {
+		fmt.Fprintln(w, prompt.FmtNotice(fmt.Sprintf("Found %d Compositions:", len(pkg.Compositions)))) // nolint:errcheck
+	}
+	for _, c := range pkg.Compositions {
+		fmt.Fprintln(w, prompt.FmtInfo(fmt.Sprintf("-- %s", c.Name))) // nolint:errcheck
+	}
+	if len(in.Dependencies) == 0 && len(pkg.Compositions) > 0 {
+		if err := suggestDependencies(w, fs, pkg, in); err != nil {
+			fmt.Fprintln(w, prompt.FmtWarning(fmt.Sprintf("Cannot suggest dependencies: %s", err))) // nolint:errcheck
 		}
-		if len(pkg.InfrastructureDefinitions) > 0 {
-			fmt.Println(prompt.FmtNotice(fmt.Sprintf("Found %d InfrastructureDefinitions:", len(pkg.InfrastructureDefinitions))))
+		if in.complete() {
+			addSuggestions(w, in)
 		}
-		for _, id := range pkg.InfrastructureDefinitions {
-			fmt.Println(prompt.FmtInfo(fmt.Sprintf("-- %s", id.Name)))
+	}
+	if !in.complete() {
+		if err := p.Run(in.steps(p)...); err != nil {
+			return err
 		}
-		if len(pkg.InfrastructurePublications) > 0 {
-			fmt.Println(prompt.FmtNotice(fmt.Sprintf("Found %d InfrastructurePublications:", len(pkg.InfrastructurePublications))))
+	}
+
+	fmt.Fprintln(w, prompt.FmtInfo("📦 Building package...")) // nolint:errcheck
+	files, err := scaffold(in, in.Starter)
+	if err != nil {
+		return err
+	}
+	if err := writeScaffold(fs, root, files, force); err != nil {
+		return err
+	}
+	for _, f := range files {
+		fmt.Fprintln(w, prompt.FmtInfo(fmt.Sprintf("-- created %s", f.path))) // nolint:errcheck
+	}
+	_, err = fmt.Fprintln(w, prompt.FmtInfo(fmt.Sprintf("✔️  %s %s initialized in %s.", in.PackageType, in.Name, root)))
+	return err
+}
+
+// suggestDependencies suggests the dependencies of the initer from the
+// resources the package composes.
+func suggestDependencies(w io.Writer, fs afero.Fs, pkg *parser.Package, in *initer) error {
+	t, err := dependencyTable(fs)
+	if err != nil {
+		return err
+	}
+	s := suggest.Suggest(pkg, t)
+	printSuggestions(w, s)
+	for _, d := range s {
+		if d.Package != "" {
+			in.suggested = append(in.suggested, Dependency{Package: d.Package, Version: d.Version})
 		}
-		for _, ip := range pkg.InfrastructurePublications {
-			fmt.Println(prompt.FmtInfo(fmt.Sprintf("-- %s", ip.Name)))
+	}
+	return nil
+}
+
+// addSuggestions adds the suggested dependencies that have a version to the
+// dependencies of the initer. Suggestions without a version are not added,
+// because init does not prompt for their version.
+func addSuggestions(w io.Writer, in *initer) {
+	for _, d := range in.suggested {
+		if d.Version == "" {
+			fmt.Fprintln(w, prompt.FmtWarning(fmt.Sprintf("Suggested dependency %s has no version and was not added. Supply it with --dependency package@version.", d.Package))) // nolint:errcheck
+			continue
 		}
-		if len(pkg.Compositions) > 0 {
-			fmt.Println(prompt.FmtNotice(fmt.Sprintf("Found %d Compositions:", len(pkg.Compositions))))
+		in.Dependencies = append(in.Dependencies, d)
+	}
+}
+
+// supply supplies the values from the answers file and flags to the initer.
+func supply(fs afero.Fs, in *initer, f initFlags) error {
+	if f.answers != "" {
+		b, err := afero.ReadFile(fs, f.answers)
+		if err != nil {
+			return errors.Wrap(err, "cannot read answers file")
 		}
-		for _, c := range pkg.Compositions {
-			fmt.Println(prompt.FmtInfo(fmt.Sprintf("-- %s", c.Name)))
+		if err := yaml.Unmarshal(b, in); err != nil {
+			return errors.Wrap(err, "cannot parse answers file")
 		}
-		if err := p.Prompt(); err != nil {
-			panic(err)
+	}
+	if f.packageType != "" {
+		in.PackageType = PackageType(f.packageType)
+	}
+	if f.name != "" {
+		in.Name = f.name
+	}
+	if f.starter {
+		in.Starter = true
+	}
+	if len(f.dependencies) > 0 {
+		in.Dependencies = nil
+	}
+	for _, d := range f.dependencies {
+		dep, err := parseDependency(d)
+		if err != nil {
+			return err
 		}
-	},
+		in.Dependencies = append(in.Dependencies, dep)
+	}
+	if in.PackageType != "" && in.PackageType != Configuration && in.PackageType != Provider {
+		return errors.Errorf("package must be of type %s or %s", Configuration, Provider)
+	}
+	return nil
 }
@@ -0,0 +1,330 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/pkg/prompt"
+)
+
+const answers = `type: Configuration
+name: network
+`
+
+func TestSteps(t *testing.T) {
+	gcp := Dependency{Package: "crossplane/provider-gcp", Version: "v0.11.0"}
+	cases := map[string]struct {
+		suggested []Dependency
+		input     string
+		want      []Dependency
+
+		// offered are the defaults the user is offered.
+		offered []string
+	}{
+		"NoSuggestions": {
+			input: "y\ncrossplane/provider-gcp@v0.11.0\n\n\n\n",
+			want:  []Dependency{gcp},
+		},
+		"AcceptSuggestion": {
+			suggested: []Dependency{gcp},
+			input:     "\n\n\n\n\n",
+			want:      []Dependency{gcp},
+			offered:   []string{"[crossplane/provider-gcp@v0.11.0]"},
+		},
+		"AcceptUnversionedSuggestion": {
+			suggested: []Dependency{{Package: "crossplane/provider-gcp"}},
+			input:     "\n\n\n\n\n",
+			want:      []Dependency{{Package: "crossplane/provider-gcp"}},
+			offered:   []string{"[crossplane/provider-gcp]"},
+		},
+		"CompleteSuggestion": {
+			suggested: []Dependency{{Package: "crossplane/provider-gcp"}},
+			input:     "\ncrossplane/provider-gcp@v0.11.0\n\n\n\n",
+			want:      []Dependency{gcp},
+			offered:   []string{"[crossplane/provider-gcp]"},
+		},
+		"SkipSuggestion": {
+			suggested: []Dependency{gcp, {Package: "crossplane/provider-helm", Version: "v0.2.0"}},
+			input:     "\nskip\n\n\n\n\n",
+			want:      []Dependency{{Package: "crossplane/provider-helm", Version: "v0.2.0"}},
+			offered:   []string{"[crossplane/provider-gcp@v0.11.0]", "[crossplane/provider-helm@v0.2.0]"},
+		},
+		"DeclineSuggestions": {
+			suggested: []Dependency{gcp},
+			input:     "n\n\n\n",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if err := afero.WriteFile(fs, "answers.yaml", []byte(answers), 0644); err != nil {
+				t.Fatal(err)
+			}
+			in := &initer{}
+			if err := supply(fs, in, initFlags{answers: "answers.yaml"}); err != nil {
+				t.Fatal(err)
+			}
+			in.suggested = tc.suggested
+
+			out := &bytes.Buffer{}
+			p := prompt.NewPrompter(prompt.WithInput(strings.NewReader(tc.input)), prompt.WithOutput(out))
+			if err := p.Run(in.steps(p)...); err != nil {
+				t.Fatalf("Run(...): %v\n%s", err, out)
+			}
+			if diff := cmp.Diff(tc.want, in.Dependencies); diff != "" {
+				t.Errorf("Run(...): -want dependencies, +got dependencies:\n%s", diff)
+			}
+			for _, o := range tc.offered {
+				if !strings.Contains(out.String(), o) {
+					t.Errorf("Run(...): want %s offered as a default, got:\n%s", o, out)
+				}
+			}
+		})
+	}
+}
+
+func TestSupply(t *testing.T) {
+	cases := map[string]struct {
+		answers string
+		flags   initFlags
+		want    *initer
+		err     string
+	}{
+		"Flags": {
+			flags: initFlags{packageType: "Provider", name: "provider-demo", dependencies: []string{"crossplane/provider-gcp@v0.11.0"}},
+			want:  &initer{PackageType: Provider, Name: "provider-demo", Dependencies: []Dependency{{Package: "crossplane/provider-gcp", Version: "v0.11.0"}}},
+		},
+		"Answers": {
+			answers: "type: Configuration\nname: demo\nstarter: true\ndependencies:\n- package: crossplane/provider-aws\n  version: v0.10.0\n",
+			want:    &initer{PackageType: Configuration, Name: "demo", Starter: true, Dependencies: []Dependency{{Package: "crossplane/provider-aws", Version: "v0.10.0"}}},
+		},
+		"FlagsOverrideAnswers": {
+			answers: "type: Provider\nname: answered\ndependencies:\n- package: crossplane/provider-aws\n  version: v0.10.0\n",
+			flags:   initFlags{packageType: "Configuration", name: "flagged", starter: true, dependencies: []string{"crossplane/provider-gcp@v0.11.0"}},
+			want: &initer{PackageType: Configuration, Name: "flagged", Starter: true, Dependencies: []Dependency{
+				{Package: "crossplane/provider-gcp", Version: "v0.11.0"},
+			}},
+		},
+		"AnsweredDependenciesWithoutFlags": {
+			answers: "type: Provider\nname: answered\ndependencies:\n- package: crossplane/provider-aws\n  version: v0.10.0\n",
+			flags:   initFlags{name: "flagged"},
+			want: &initer{PackageType: Provider, Name: "flagged", Dependencies: []Dependency{
+				{Package: "crossplane/provider-aws", Version: "v0.10.0"},
+			}},
+		},
+		"InvalidType": {
+			flags: initFlags{packageType: "Stack", name: "demo"},
+			err:   "package must be of type Configuration or Provider",
+		},
+		"InvalidAnsweredType": {
+			answers: "type: Stack\nname: demo\n",
+			err:     "package must be of type Configuration or Provider",
+		},
+		"InvalidDependency": {
+			flags: initFlags{packageType: "Configuration", name: "demo", dependencies: []string{"crossplane/provider-gcp"}},
+			err:   `invalid dependency "crossplane/provider-gcp": must be of the form package@version`,
+		},
+		"MalformedAnswers": {
+			answers: "type: [Configuration\n",
+			err:     "cannot parse answers file",
+		},
+		"MissingAnswers": {
+			flags: initFlags{answers: "/missing.yaml"},
+			err:   "cannot read answers file",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if tc.answers != "" {
+				tc.flags.answers = "/answers.yaml"
+				if err := afero.WriteFile(fs, tc.flags.answers, []byte(tc.answers), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := &initer{}
+			err := supply(fs, got, tc.flags)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("supply(...): want error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(initer{})); diff != "" {
+				t.Errorf("supply(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunInit(t *testing.T) {
+	existing := "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Provider\nmetadata:\n  name: existing\n"
+	cases := map[string]struct {
+		in       *initer
+		existing bool
+		force    bool
+
+		// want is the content of crossplane.yaml after init.
+		want string
+		err  error
+	}{
+		"Configuration": {
+			in: &initer{PackageType: Configuration, Name: "demo", Dependencies: []Dependency{{Package: "crossplane/provider-gcp", Version: "v0.11.0"}}},
+			want: `apiVersion: pkg.crossplane.io/v1alpha1
+kind: Configuration
+metadata:
+  name: demo
+  annotations:
+spec:
+  dependsOn:
+  - package: crossplane/provider-gcp
+    version: v0.11.0
+  ignore:
+  - path: examples/
+`,
+		},
+		"Provider": {
+			in: &initer{PackageType: Provider, Name: "provider-demo"},
+			want: `apiVersion: pkg.crossplane.io/v1alpha1
+kind: Provider
+metadata:
+  name: provider-demo
+  annotations:
+spec:
+  dependsOn:
+  ignore:
+  - path: examples/
+`,
+		},
+		"RefuseOverwrite": {
+			in:       &initer{PackageType: Configuration, Name: "demo"},
+			existing: true,
+			want:     existing,
+		},
+		"Force": {
+			in:       &initer{PackageType: Configuration, Name: "demo"},
+			existing: true,
+			force:    true,
+			want: `apiVersion: pkg.crossplane.io/v1alpha1
+kind: Configuration
+metadata:
+  name: demo
+  annotations:
+spec:
+  dependsOn:
+  ignore:
+  - path: examples/
+`,
+		},
+		"IncompleteWithoutInput": {
+			in:  &initer{PackageType: Configuration},
+			err: prompt.ErrEOF,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if err := fs.MkdirAll("/pkg", 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tc.existing {
+				if err := afero.WriteFile(fs, "/pkg/crossplane.yaml", []byte(existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// There is no terminal: input ends immediately.
+			prompts := &bytes.Buffer{}
+			p := prompt.NewPrompter(prompt.WithInput(strings.NewReader("")), prompt.WithOutput(prompts))
+			err := runInit(&bytes.Buffer{}, fs, "/pkg", tc.in, p, tc.force)
+			switch {
+			case tc.err != nil:
+				if err != tc.err {
+					t.Fatalf("runInit(...): want error %v, got %v", tc.err, err)
+				}
+			case tc.existing && !tc.force:
+				if err == nil || !strings.Contains(err.Error(), "refusing to overwrite crossplane.yaml") {
+					t.Fatalf("runInit(...): want error refusing to overwrite, got %v", err)
+				}
+			case err != nil:
+				t.Fatal(err)
+			default:
+				if prompts.Len() != 0 {
+					t.Errorf("runInit(...): want no prompts, got:\n%s", prompts)
+				}
+			}
+
+			got, _ := afero.ReadFile(fs, "/pkg/crossplane.yaml")
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("runInit(...): -want crossplane.yaml, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunInitSuggestions(t *testing.T) {
+	composition := `apiVersion: apiextensions.crossplane.io/v1alpha1
+kind: Composition
+metadata:
+  name: buckets.example.org
+spec:
+  from:
+    apiVersion: example.org/v1alpha1
+    kind: Bucket
+  to:
+  - base:
+      apiVersion: storage.gcp.crossplane.io/v1alpha3
+      kind: Bucket
+  - base:
+      apiVersion: helm.crossplane.io/v1alpha1
+      kind: Release
+`
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/pkg/composition.yaml": composition,
+		"/table.yaml":           "groups:\n  gcp.crossplane.io: crossplane/provider-gcp:v0.11.0\n",
+	}
+	for p, c := range files {
+		if err := afero.WriteFile(fs, p, []byte(c), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	depsTable = "/table.yaml"
+	defer func() { depsTable = "" }()
+
+	in := &initer{PackageType: Configuration, Name: "demo"}
+	out := &bytes.Buffer{}
+	p := prompt.NewPrompter(prompt.WithInput(strings.NewReader("")), prompt.WithOutput(&bytes.Buffer{}))
+	if err := runInit(out, fs, "/pkg", in, p, false); err != nil {
+		t.Fatal(err)
+	}
+	want := []Dependency{{Package: "crossplane/provider-gcp", Version: "v0.11.0"}}
+	if diff := cmp.Diff(want, in.Dependencies); diff != "" {
+		t.Errorf("runInit(...): -want dependencies, +got dependencies:\n%s", diff)
+	}
+	if !strings.Contains(out.String(), "crossplane/provider-helm has no version and was not added") {
+		t.Errorf("runInit(...): want unversioned suggestion reported, got:\n%s", out)
+	}
+}
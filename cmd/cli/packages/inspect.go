@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/hasheddan/crank/pkg/inspect"
+	"github.com/hasheddan/crank/pkg/parser"
+	"github.com/hasheddan/crank/pkg/printer"
+	"github.com/hasheddan/crank/pkg/unpack"
+)
+
+var inspectOutput string
+
+// inspector will summarize the contents of a Crossplane package.
+var inspector = &cobra.Command{
+	Use:   "inspect <image|dir>",
+	Short: "Summarize the contents of a Crossplane package",
+	Long: `Summarizes the metadata, dependencies, CustomResourceDefinitions,
+InfrastructureDefinitions, InfrastructurePublications, Compositions and
+controller images of a Crossplane package. The package may be a directory or an
+image reference. The digest of an image is that of the image that was pulled.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := printer.ValidateFormat(inspectOutput, "text"); err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		return inspectPackage(os.Stdout, afero.NewOsFs(), unpack.NewFetcher(), args[0], inspectOutput)
+	},
+}
+
+func init() {
+	inspector.Flags().StringVarP(&inspectOutput, "output", "o", "text", "Output format. One of: text, json, yaml.")
+}
+
+// inspectPackage writes a summary of the package in the directory or image ref
+// to w.
+func inspectPackage(w io.Writer, fs afero.Fs, f *unpack.Fetcher, ref, format string) error {
+	var (
+		pkg    *parser.Package
+		digest string
+		err    error
+	)
+	if ok, _ := afero.DirExists(fs, ref); ok {
+		pkg, err = loadPackage(fs, f, ref)
+	} else {
+		pkg, digest, err = f.PackageDigest(ref)
+	}
+	if err != nil {
+		return err
+	}
+	r := inspect.Inspect(ref, pkg)
+	r.Digest = digest
+	if format == "text" {
+		return r.WriteText(w)
+	}
+	write, err := printer.PrinterFor(format)
+	if err != nil {
+		return err
+	}
+	return write(w, r, printer.Table{})
+}
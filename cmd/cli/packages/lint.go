@@ -18,40 +18,271 @@ package packages
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
 	"github.com/hasheddan/crank/pkg/lint"
+	"github.com/hasheddan/crank/pkg/lockfile"
 	"github.com/hasheddan/crank/pkg/parser"
 	"github.com/hasheddan/crank/pkg/prompt"
-	"github.com/spf13/afero"
-	"github.com/spf13/cobra"
+	"github.com/hasheddan/crank/pkg/unpack"
+)
+
+// Exit codes returned by lint.
+const (
+	lintExitFindings = 1
+	lintExitError    = 2
+)
+
+var (
+	lintOutput   string
+	lintSkipDeps bool
+	lintCacheDir string
+	lintFix      bool
+	lintDryRun   bool
 )
 
 // linter will lint a Crossplane package.
 var linter = &cobra.Command{
-	Use:   "lint",
+	Use:   "lint [path]",
 	Short: "Lints a Crossplane package",
-	Long:  `Linting a Crossplane package ensures that the format is suitable for building.`,
+	Long: `Linting a Crossplane package ensures that the format is suitable for building.
+
+Findings can be written as text, json, sarif, or junit. Lint exits 1 if any
+finding has error severity and 2 if the package could not be linted.
+
+The packages listed in the dependsOn of crossplane.yaml, and the packages they
+depend on, are pulled and their CRDs and InfrastructureDefinitions are used to
+lint the package. If the package has a crank.lock every package it locks,
+including transitive dependencies, is pulled at the digest it locks. Otherwise
+tags are resolved to a digest every time the package is linted. Pulled packages
+are cached by digest in --cache-dir.
+
+Findings with a mechanical fix are fixed in place with --fix. The package is
+then linted again and any remaining findings are reported. Use --fix with
+--dry-run to print the fixes as a unified diff instead.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		path := "."
 		if len(args) == 1 {
 			path = args[0]
 		}
-		s, _ := filepath.Abs(path)
-		fs := afero.NewOsFs()
-		p := parser.NewParser(fs)
-		pkg, err := p.ParsePackage(s)
+		o := lintOptions{format: lintOutput, fix: lintFix, dryRun: lintDryRun}
+		if !lintSkipDeps {
+			var err error
+			if o.fetcher, err = cachingFetcher(lintCacheDir); err != nil {
+				fmt.Fprintln(os.Stderr, prompt.FmtError(err.Error()))
+				os.Exit(lintExitError)
+			}
+		}
+		code, err := lintPackage(os.Stdout, afero.NewOsFs(), path, o)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, prompt.FmtError(err.Error()))
+		}
+		os.Exit(code)
+	},
+}
+
+func init() {
+	linter.Flags().StringVarP(&lintOutput, "output", "o", "text", "Output format. One of: text, json, sarif, junit.")
+	linter.Flags().BoolVar(&lintSkipDeps, "skip-dependencies", false, "Lint without pulling package dependencies.")
+	linter.Flags().StringVar(&lintCacheDir, "cache-dir", defaultCacheDir(), "Directory in which pulled packages are cached.")
+	linter.Flags().BoolVar(&lintFix, "fix", false, "Fix findings that have a mechanical fix in place.")
+	linter.Flags().BoolVar(&lintDryRun, "dry-run", false, "Print fixes as a unified diff rather than applying them. Requires --fix.")
+}
+
+// defaultCacheDir returns the default directory in which pulled packages are
+// cached.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "crank")
+	}
+	return filepath.Join(dir, "crank")
+}
+
+// cachingFetcher returns a Fetcher that caches pulled packages in dir.
+func cachingFetcher(dir string) (*unpack.Fetcher, error) {
+	fs := afero.NewOsFs()
+	if err := fs.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "cannot create cache directory")
+	}
+	return unpack.NewFetcher(unpack.WithCache(afero.NewBasePathFs(fs, dir))), nil
+}
+
+// lintOptions configure how a package is linted.
+type lintOptions struct {
+	// format of reported findings.
+	format string
+
+	// fetcher fetches the dependencies of the package. Dependencies are not
+	// fetched if it is nil.
+	fetcher *unpack.Fetcher
+
+	// fix findings in place, or print a diff of the fixes if dryRun is true.
+	fix    bool
+	dryRun bool
+}
+
+// lintPackage lints the package at path and writes findings to w. It returns
+// the exit code for the command.
+func lintPackage(w io.Writer, fs afero.Fs, path string, o lintOptions) (int, error) {
+	report, err := lint.ReporterFor(o.format)
+	if err != nil {
+		return lintExitError, err
+	}
+	if o.dryRun && !o.fix {
+		return lintExitError, errors.New("--dry-run requires --fix")
+	}
+	s, err := filepath.Abs(path)
+	if err != nil {
+		return lintExitError, err
+	}
+	pkg, deps, err := parseForLint(fs, s, o.fetcher)
+	if err != nil {
+		return lintExitError, err
+	}
+	l := lint.NewLinter(pkg, deps...)
+	findings := l.Lint()
+	if o.fix {
+		fixed, _, err := lint.Fixes(pkg.Files, findings)
+		if err != nil {
+			return lintExitError, err
+		}
+		if o.dryRun {
+			if err := writeDiff(w, s, pkg.Files, fixed); err != nil {
+				return lintExitError, err
+			}
+			return lintCode(findings), nil
+		}
+		for p, b := range fixed {
+			info, err := fs.Stat(p)
+			if err != nil {
+				return lintExitError, err
+			}
+			if err := afero.WriteFile(fs, p, b, info.Mode()); err != nil {
+				return lintExitError, errors.Wrapf(err, "cannot write %s", p)
+			}
+		}
+		if pkg, _, err = parseForLint(fs, s, nil); err != nil {
+			return lintExitError, err
+		}
+		l = lint.NewLinter(pkg, deps...)
+		findings = l.Lint()
+	}
+	for i, f := range findings {
+		if rel, err := filepath.Rel(s, f.Path); err == nil {
+			findings[i].Path = filepath.ToSlash(rel)
+		}
+	}
+	if err := report(w, l.Rules(), findings); err != nil {
+		return lintExitError, err
+	}
+	return lintCode(findings), nil
+}
+
+// parseForLint parses the package at root and, unless f is nil, fetches its
+// dependencies. If the package has a crank.lock every package it locks is
+// fetched at its locked digest. It returns the options with which the package
+// should be linted.
+func parseForLint(fs afero.Fs, root string, f *unpack.Fetcher) (*parser.Package, []lint.LinterOption, error) {
+	pkg, err := parser.NewParser(fs).ParsePackage(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	if f == nil {
+		return pkg, nil, nil
+	}
+	l, err := lockfile.Read(fs, root)
+	if err != nil {
+		return nil, nil, err
+	}
+	if l == nil || pkg.Meta == nil {
+		deps, err := f.Dependencies(pkg.Dependencies)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "cannot fetch dependencies")
+		}
+		return pkg, []lint.LinterOption{lint.WithDependencies(deps...)}, nil
+	}
+	locked, err := l.Closure(pkg.Meta.Spec.DependsOn)
+	if err != nil {
+		return nil, nil, err
+	}
+	deps := make([]*parser.Package, 0, len(locked))
+	for _, d := range locked {
+		dep, err := f.Package(d.Package)
 		if err != nil {
-			panic(err)
+			return nil, nil, errors.Wrap(err, "cannot fetch dependencies")
+		}
+		deps = append(deps, dep)
+	}
+	return pkg, []lint.LinterOption{lint.WithDependencies(deps...)}, nil
+}
+
+// pinnedDependencies returns the dependencies of the package at root, pinned
+// to the digests locked by its crank.lock. The dependencies are not pinned if
+// the package has no crank.lock.
+func pinnedDependencies(fs afero.Fs, root string, pkg *parser.Package) ([]v1alpha1.Dependency, error) {
+	l, err := lockfile.Read(fs, root)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil || pkg.Meta == nil {
+		return pkg.Dependencies, nil
+	}
+	return l.Pin(pkg.Meta.Spec.DependsOn)
+}
+
+// lintCode returns the exit code for the supplied findings.
+func lintCode(findings []lint.Finding) int {
+	for _, f := range findings {
+		if f.Severity == lint.SeverityError {
+			return lintExitFindings
 		}
-		l := lint.NewLinter(pkg)
-		errs := l.Lint()
-		errLen := len(errs)
-		if errLen != 0 {
-			fmt.Printf(prompt.FmtWarning(fmt.Sprintf("Found %d errors in package.\n", errLen)))
+	}
+	return 0
+}
+
+// writeDiff writes a unified diff between the original and fixed content of
+// each fixed file to w.
+func writeDiff(w io.Writer, root string, original, fixed map[string][]byte) error {
+	paths := make([]string, 0, len(fixed))
+	for p := range fixed {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		rel := p
+		if r, err := filepath.Rel(root, p); err == nil {
+			rel = filepath.ToSlash(r)
 		}
-		for i, e := range errs {
-			fmt.Printf(prompt.FmtError(fmt.Sprintf("[%d/%d] %s\n", i+1, errLen, e)))
+		if err := difflib.WriteUnifiedDiff(w, difflib.UnifiedDiff{
+			A:        splitLines(original[p]),
+			B:        splitLines(fixed[p]),
+			FromFile: "a/" + rel,
+			ToFile:   "b/" + rel,
+			Context:  3,
+		}); err != nil {
+			return err
 		}
-	},
+	}
+	return nil
+}
+
+// splitLines splits b into lines that retain their line endings.
+func splitLines(b []byte) []string {
+	lines := strings.SplitAfter(string(b), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
 }
@@ -0,0 +1,177 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/pkg/unpack"
+)
+
+const configurationMeta = `apiVersion: pkg.crossplane.io/v1alpha1
+kind: Configuration
+metadata:
+  name: network
+`
+
+const networkCRD = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: networks.example.org
+spec:
+  group: example.org
+  names:
+    kind: Network
+    listKind: NetworkList
+    plural: networks
+    singular: network
+  scope: Cluster
+  version: v1alpha1
+`
+
+const network = `apiVersion: example.org/v1alpha1
+kind: Network
+metadata:
+  name: example
+`
+
+func TestLintPackageExitCode(t *testing.T) {
+	cases := map[string]struct {
+		files map[string]string
+		path  string
+		o     lintOptions
+		want  int
+
+		// report is contained in the written findings.
+		report string
+	}{
+		"Clean": {
+			files: map[string]string{"/pkg/crossplane.yaml": configurationMeta},
+			path:  "/pkg",
+			o:     lintOptions{format: "text"},
+			want:  0,
+		},
+		"Warnings": {
+			files: map[string]string{
+				"/pkg/crossplane.yaml":       configurationMeta,
+				"/pkg/examples/network.yaml": network,
+			},
+			path:   "/pkg",
+			o:      lintOptions{format: "json"},
+			want:   0,
+			report: `"rule": "meta-ignore"`,
+		},
+		"Errors": {
+			files: map[string]string{
+				"/pkg/crossplane.yaml": configurationMeta,
+				"/pkg/crd.yaml":        networkCRD,
+			},
+			path:   "/pkg",
+			o:      lintOptions{format: "sarif"},
+			want:   lintExitFindings,
+			report: `"ruleId": "configuration-contents"`,
+		},
+		"UnknownFormat": {
+			files: map[string]string{"/pkg/crossplane.yaml": configurationMeta},
+			path:  "/pkg",
+			o:     lintOptions{format: "html"},
+			want:  lintExitError,
+		},
+		"DryRunWithoutFix": {
+			files: map[string]string{"/pkg/crossplane.yaml": configurationMeta},
+			path:  "/pkg",
+			o:     lintOptions{format: "text", dryRun: true},
+			want:  lintExitError,
+		},
+		"MissingPackage": {
+			path: "/missing",
+			o:    lintOptions{format: "junit"},
+			want: lintExitError,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for p, c := range tc.files {
+				if err := afero.WriteFile(fs, p, []byte(c), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			w := &bytes.Buffer{}
+			got, err := lintPackage(w, fs, tc.path, tc.o)
+			if (err != nil) != (tc.want == lintExitError) {
+				t.Errorf("lintPackage(...): want error %t, got %v", tc.want == lintExitError, err)
+			}
+			if got != tc.want {
+				t.Errorf("lintPackage(...): want exit code %d, got %d", tc.want, got)
+			}
+			if !strings.Contains(w.String(), tc.report) {
+				t.Errorf("lintPackage(...): want report containing %s, got:\n%s", tc.report, w)
+			}
+		})
+	}
+}
+
+func TestParseForLintLocked(t *testing.T) {
+	a, b := "sha256:"+strings.Repeat("a", 64), "sha256:"+strings.Repeat("b", 64)
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/pkg/crossplane.yaml": configurationMeta + "spec:\n  dependsOn:\n  - package: acme/network\n    version: v1\n",
+		"/pkg/crank.lock": `apiVersion: crank.crossplane.io/v1alpha1
+kind: Lockfile
+packages:
+- package: acme/network
+  version: v1
+  digest: ` + a + `
+  dependencies:
+  - crossplane/provider-gcp:v0.12.0
+- package: crossplane/provider-gcp
+  version: v0.12.0
+  digest: ` + b + `
+`,
+	}
+	for p, c := range files {
+		if err := afero.WriteFile(fs, p, []byte(c), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pulled := []string{}
+	f := unpack.NewFetcher(unpack.WithPullFn(func(image string) (afero.Fs, string, error) {
+		pulled = append(pulled, image)
+		pkg := afero.NewMemMapFs()
+		return pkg, image[strings.LastIndex(image, "@")+1:], afero.WriteFile(pkg, ".registry/crd.yaml", []byte(networkCRD), 0644)
+	}))
+
+	_, opts, err := parseForLint(fs, "/pkg", f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts) != 1 {
+		t.Errorf("parseForLint(...): want dependency option, got %d options", len(opts))
+	}
+	sort.Strings(pulled)
+	want := []string{"index.docker.io/acme/network@" + a, "index.docker.io/crossplane/provider-gcp@" + b}
+	if diff := cmp.Diff(want, pulled); diff != "" {
+		t.Errorf("parseForLint(...): -want, +got pulled images:\n%s", diff)
+	}
+}
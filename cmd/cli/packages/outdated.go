@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/cmd/cli/kube"
+	"github.com/hasheddan/crank/pkg/install"
+	"github.com/hasheddan/crank/pkg/outdated"
+	"github.com/hasheddan/crank/pkg/parser"
+	"github.com/hasheddan/crank/pkg/printer"
+)
+
+var (
+	outdatedCluster bool
+	outdatedOutput  string
+)
+
+// outdater will list newer versions of the dependencies of a Crossplane
+// package, or of the packages installed in a cluster.
+var outdater = &cobra.Command{
+	Use:   "outdated [path]",
+	Short: "List newer versions of package dependencies",
+	Long: `Lists the tags of each package in the dependsOn of crossplane.yaml, or with
+--cluster of each package in the cluster's PackageLock, and reports its current
+version, the newest version that satisfies its version constraint, and the
+newest version overall. Tags that are not semantic versions, and pre-releases,
+are ignored.
+
+A version constraint is one or more comparators separated by commas or spaces,
+e.g. '>=v0.11.0, <v0.13.0'. A comparator is a version preceded by one of =, >,
+>=, <, <=, ^ or ~. A bare version is a caret constraint, so v0.12.0 wants any
+v0.12 version and v1.2.0 any v1 version from v1.2.0. The version of a package
+installed in a cluster is treated as a bare version.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		write, err := printer.PrinterFor(outdatedOutput)
+		if err != nil {
+			return err
+		}
+		if outdatedCluster && len(args) > 0 {
+			return errors.New("a path cannot be supplied with --cluster")
+		}
+		cmd.SilenceUsage = true
+		var pkgs []outdated.Package
+		if outdatedCluster {
+			pkgs, err = clusterPackages()
+		} else {
+			pkgs, err = localPackages(afero.NewOsFs(), pathArg(args))
+		}
+		if err != nil {
+			return err
+		}
+		results, err := outdated.Check(pkgs, outdated.ListTags)
+		if err != nil {
+			return err
+		}
+		return write(os.Stdout, results, outdatedTable(results))
+	},
+}
+
+func init() {
+	outdater.Flags().BoolVar(&outdatedCluster, "cluster", false, "Check the packages installed in the cluster rather than a local package.")
+	outdater.Flags().StringVarP(&outdatedOutput, "output", "o", printer.FormatTable, printer.Usage)
+}
+
+// localPackages returns the dependencies of the package at path.
+func localPackages(fs afero.Fs, path string) ([]outdated.Package, error) {
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := parser.NewParser(fs).ParsePackage(root)
+	if err != nil {
+		return nil, err
+	}
+	if pkg.Meta == nil {
+		return nil, errors.Errorf("%s has no crossplane.yaml", path)
+	}
+	pkgs := []outdated.Package{}
+	for _, d := range pkg.Meta.Spec.DependsOn {
+		pkgs = append(pkgs, outdated.Package{Package: d.Package, Current: d.Version})
+	}
+	return pkgs, nil
+}
+
+// clusterPackages returns the packages in the PackageLock of the cluster,
+// sorted by package.
+func clusterPackages() ([]outdated.Package, error) {
+	c, err := kube.Default.Client()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := kube.Default.Context()
+	defer cancel()
+	l := &v1alpha1.PackageLock{}
+	if err := c.Get(ctx, types.NamespacedName{Name: install.LockName}, l); err != nil {
+		return nil, errors.Wrap(err, "cannot get PackageLock")
+	}
+	pkgs := []outdated.Package{}
+	for _, p := range l.Spec.Packages {
+		pkgs = append(pkgs, outdated.FromImage(p.Image))
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Package < pkgs[j].Package })
+	return pkgs, nil
+}
+
+// outdatedTable describes the results of checking packages as a table.
+func outdatedTable(results []outdated.Result) printer.Table {
+	t := printer.Table{Columns: []printer.Column{
+		{Name: "PACKAGE"},
+		{Name: "CURRENT"},
+		{Name: "WANTED"},
+		{Name: "LATEST"},
+	}}
+	for _, r := range results {
+		t.Rows = append(t.Rows, printer.Row{Name: r.Package, Cells: []string{r.Package, r.Current, r.Wanted, r.Latest}})
+	}
+	return t
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/hasheddan/crank/pkg/lockfile"
+	"github.com/hasheddan/crank/pkg/parser"
+	"github.com/hasheddan/crank/pkg/prompt"
+	"github.com/hasheddan/crank/pkg/unpack"
+)
+
+var resolveCacheDir string
+
+// resolver will lock the dependencies of a Crossplane package.
+var resolver = &cobra.Command{
+	Use:   "resolve [path]",
+	Short: "Lock the dependencies of a Crossplane package to digests",
+	Long: `Resolves every package in the dependency closure of the dependsOn of
+crossplane.yaml to a digest, and writes them to a crank.lock next to
+crossplane.yaml. Packages that are already locked at the version that is
+required are not resolved again, so lint and build use the same digests until
+they are updated with 'crank package update'.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		f, err := cachingFetcher(resolveCacheDir)
+		if err != nil {
+			return err
+		}
+		return resolvePackage(os.Stdout, afero.NewOsFs(), f, pathArg(args), nil)
+	},
+}
+
+// updater will update the locked dependencies of a Crossplane package.
+var updater = &cobra.Command{
+	Use:   "update [path] [package...]",
+	Short: "Update the locked dependencies of a Crossplane package",
+	Long: `Resolves the packages in the dependency closure of a Crossplane package to
+digests again, updating its crank.lock. Only the supplied packages are updated
+if any are supplied, e.g. 'crank package update . crossplane/provider-gcp'.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		f, err := cachingFetcher(resolveCacheDir)
+		if err != nil {
+			return err
+		}
+		update := []string{}
+		if len(args) > 1 {
+			update = args[1:]
+		}
+		return resolvePackage(os.Stdout, afero.NewOsFs(), f, pathArg(args), func(l *lockfile.Lockfile) *lockfile.Lockfile {
+			if len(update) == 0 {
+				return nil
+			}
+			kept := &lockfile.Lockfile{}
+			for _, p := range l.Packages {
+				if !contains(update, p.Package) {
+					kept.Packages = append(kept.Packages, p)
+				}
+			}
+			return kept
+		})
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{resolver, updater} {
+		cmd.Flags().StringVar(&resolveCacheDir, "cache-dir", defaultCacheDir(), "Directory in which pulled packages are cached.")
+	}
+}
+
+// pathArg returns the path supplied as the first argument, or the working
+// directory if there is none.
+func pathArg(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return "."
+}
+
+// resolvePackage resolves the dependencies of the package at path and writes
+// its lockfile. Packages locked by the existing lockfile are not resolved
+// again, unless they are omitted by unlock.
+func resolvePackage(w io.Writer, fs afero.Fs, f *unpack.Fetcher, path string, unlock func(*lockfile.Lockfile) *lockfile.Lockfile) error {
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	pkg, err := parser.NewParser(fs).ParsePackage(root)
+	if err != nil {
+		return err
+	}
+	if pkg.Meta == nil {
+		return errors.Errorf("%s has no crossplane.yaml", path)
+	}
+	previous, err := lockfile.Read(fs, root)
+	if err != nil {
+		return err
+	}
+	if previous != nil && unlock != nil {
+		previous = unlock(previous)
+	}
+	l, err := lockfile.Resolve(f, pkg.Meta.Spec.DependsOn, previous)
+	if err != nil {
+		return err
+	}
+	if err := lockfile.Write(fs, root, l); err != nil {
+		return err
+	}
+	for _, p := range l.Packages {
+		fmt.Fprintln(w, prompt.FmtInfo(fmt.Sprintf("-- %s:%s %s", p.Package, p.Version, p.Digest))) // nolint:errcheck
+	}
+	_, err = fmt.Fprintln(w, prompt.FmtNotice(fmt.Sprintf("Locked %d packages in %s.", len(l.Packages), filepath.Join(path, lockfile.File))))
+	return err
+}
+
+// contains returns true if s is one of ss.
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -34,4 +34,11 @@ func Execute() error {
 func init() {
 	Root.AddCommand(initialize)
 	Root.AddCommand(linter)
+	Root.AddCommand(differ)
+	Root.AddCommand(inspector)
+	Root.AddCommand(resolver)
+	Root.AddCommand(updater)
+	Root.AddCommand(outdater)
+	Root.AddCommand(builder)
+	Root.AddCommand(depsRoot)
 }
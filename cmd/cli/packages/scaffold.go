@@ -0,0 +1,229 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// Dockerfile is the Dockerfile template. It produces an image whose top layer
+// contains the package in a .registry directory.
+var Dockerfile = template.Must(template.New("dockerfile").Parse(
+	`FROM scratch
+ADD . .registry
+`))
+
+// DockerIgnore is the .dockerignore template. It excludes everything that is
+// not part of the package from the .registry layer.
+var DockerIgnore = template.Must(template.New("dockerignore").Parse(
+	`Dockerfile
+.dockerignore
+.git
+examples/
+`))
+
+// StarterDefinition is the starter InfrastructureDefinition template.
+var StarterDefinition = template.Must(template.New("definition").Parse(
+	`apiVersion: apiextensions.crossplane.io/v1alpha1
+kind: InfrastructureDefinition
+metadata:
+  name: buckets.{{ .Group }}
+spec:
+  crdSpecTemplate:
+    group: {{ .Group }}
+    version: v1alpha1
+    names:
+      kind: Bucket
+      listKind: BucketList
+      plural: buckets
+      singular: bucket
+    validation:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              parameters:
+                type: object
+                properties:
+                  location:
+                    type: string
+                required:
+                - location
+            required:
+            - parameters
+`))
+
+// StarterComposition is the starter Composition template.
+var StarterComposition = template.Must(template.New("composition").Parse(
+	`apiVersion: apiextensions.crossplane.io/v1alpha1
+kind: Composition
+metadata:
+  name: buckets.gcp.{{ .Group }}
+  labels:
+    provider: gcp
+spec:
+  writeConnectionSecretsToNamespace: crossplane-system
+  from:
+    apiVersion: {{ .Group }}/v1alpha1
+    kind: Bucket
+  to:
+  - base:
+      apiVersion: storage.gcp.crossplane.io/v1alpha3
+      kind: Bucket
+      spec:
+        storageClass: STANDARD
+        providerRef:
+          name: gcp-provider
+    patches:
+    - fromFieldPath: spec.parameters.location
+      toFieldPath: spec.location
+`))
+
+// StarterDependency is the package that provides the resources composed by the
+// starter Composition.
+var StarterDependency = Dependency{Package: "crossplane/provider-gcp", Version: "v0.11.0"}
+
+// StarterExample is the template of an example instance of the starter
+// InfrastructureDefinition.
+var StarterExample = template.Must(template.New("example").Parse(
+	`apiVersion: {{ .Group }}/v1alpha1
+kind: Bucket
+metadata:
+  name: example
+spec:
+  parameters:
+    location: US
+`))
+
+// invalidLabel matches runs of characters that are not allowed in a DNS label.
+var invalidLabel = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// A scaffoldFile is a file written by init.
+type scaffoldFile struct {
+	path    string
+	content []byte
+}
+
+// scaffoldValues are the values available to scaffold templates.
+type scaffoldValues struct {
+	*initer
+
+	// Dependencies of the package, including those of starter resources.
+	Dependencies []Dependency
+
+	// Group is the API group of starter resources.
+	Group string
+}
+
+// scaffold returns the files of a new package, with paths relative to the
+// package root.
+func scaffold(in *initer, starter bool) ([]scaffoldFile, error) {
+	v := scaffoldValues{initer: in, Dependencies: in.Dependencies, Group: dnsLabel(in.Name) + ".example.org"}
+	if starter && !dependsOn(in.Dependencies, StarterDependency.Package) {
+		v.Dependencies = append(append([]Dependency{}, in.Dependencies...), StarterDependency)
+	}
+	templates := []struct {
+		path string
+		t    *template.Template
+	}{
+		{path: "crossplane.yaml", t: CrossplaneYAML},
+		{path: "Dockerfile", t: Dockerfile},
+		{path: ".dockerignore", t: DockerIgnore},
+	}
+	if starter {
+		templates = append(templates, []struct {
+			path string
+			t    *template.Template
+		}{
+			{path: "definition.yaml", t: StarterDefinition},
+			{path: "composition.yaml", t: StarterComposition},
+			{path: filepath.Join("examples", "bucket.yaml"), t: StarterExample},
+		}...)
+	}
+	files := []scaffoldFile{}
+	for _, t := range templates {
+		b := &bytes.Buffer{}
+		if err := t.t.Execute(b, v); err != nil {
+			return nil, errors.Wrapf(err, "cannot render %s", t.path)
+		}
+		files = append(files, scaffoldFile{path: t.path, content: b.Bytes()})
+	}
+	if !starter {
+		// Ensure the examples directory exists, even though it is empty.
+		files = append(files, scaffoldFile{path: filepath.Join("examples", ".gitkeep")})
+	}
+	return files, nil
+}
+
+// dnsLabel returns s as a DNS label, so that it may be used in an API group.
+// It is lower cased, and each run of characters that are not allowed in a DNS
+// label is replaced by a hyphen.
+func dnsLabel(s string) string {
+	l := strings.Trim(invalidLabel.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if len(l) > 63 {
+		l = strings.Trim(l[:63], "-")
+	}
+	if l == "" {
+		return "package"
+	}
+	return l
+}
+
+// dependsOn returns true if deps contains a dependency on the supplied package.
+func dependsOn(deps []Dependency, pkg string) bool {
+	for _, d := range deps {
+		if d.Package == pkg {
+			return true
+		}
+	}
+	return false
+}
+
+// writeScaffold writes files beneath root. It refuses to overwrite existing
+// files unless force is true.
+func writeScaffold(fs afero.Fs, root string, files []scaffoldFile, force bool) error {
+	if !force {
+		existing := []string{}
+		for _, f := range files {
+			if ok, _ := afero.Exists(fs, filepath.Join(root, f.path)); ok {
+				existing = append(existing, f.path)
+			}
+		}
+		if len(existing) > 0 {
+			return errors.Errorf("refusing to overwrite %s: use --force to overwrite", strings.Join(existing, ", "))
+		}
+	}
+	for _, f := range files {
+		path := filepath.Join(root, f.path)
+		if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := afero.WriteFile(fs, path, f.content, 0644); err != nil {
+			return errors.Wrapf(err, "cannot write %s", f.path)
+		}
+	}
+	return nil
+}
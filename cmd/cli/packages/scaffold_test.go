@@ -0,0 +1,220 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/pkg/lint"
+	"github.com/hasheddan/crank/pkg/parser"
+	"github.com/hasheddan/crank/pkg/unpack"
+)
+
+// gcpBucketCRD stands in for the Bucket CRD of crossplane/provider-gcp, which
+// is not pulled by tests.
+const gcpBucketCRD = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: buckets.storage.gcp.crossplane.io
+spec:
+  group: storage.gcp.crossplane.io
+  names:
+    kind: Bucket
+    listKind: BucketList
+    plural: buckets
+    singular: bucket
+  scope: Cluster
+  version: v1alpha3
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          properties:
+            location:
+              type: string
+            storageClass:
+              type: string
+            providerRef:
+              type: object
+              properties:
+                name:
+                  type: string
+`
+
+func TestScaffoldLints(t *testing.T) {
+	fake := afero.NewMemMapFs()
+	if err := afero.WriteFile(fake, "/gcp/crd.yaml", []byte(gcpBucketCRD), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gcp, err := parser.NewParser(fake).ParsePackage("/gcp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	providers := map[string]*parser.Package{StarterDependency.Package: gcp}
+
+	cases := map[string]*initer{
+		"Configuration": {PackageType: Configuration, Name: "demo"},
+		"Starter":       {PackageType: Configuration, Name: "demo", Starter: true},
+		"StarterWithDependency": {
+			PackageType:  Configuration,
+			Name:         "demo",
+			Starter:      true,
+			Dependencies: []Dependency{{Package: StarterDependency.Package, Version: "v0.12.0"}},
+		},
+		"StarterInvalidGroup": {PackageType: Configuration, Name: "My_Demo.Package", Starter: true},
+		"Provider":            {PackageType: Provider, Name: "provider-demo"},
+	}
+	for name, in := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "scaffold")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			files, err := scaffold(in, in.Starter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fs := afero.NewOsFs()
+			if err := writeScaffold(fs, dir, files, false); err != nil {
+				t.Fatal(err)
+			}
+			pkg, err := parser.NewParser(fs).ParsePackage(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pkg.Meta == nil || pkg.Meta.GetName() != in.Name || pkg.Meta.Kind != string(in.PackageType) {
+				t.Fatalf("scaffold(...): want crossplane.yaml for %s %s, got %+v", in.PackageType, in.Name, pkg.Meta)
+			}
+
+			// Lint the package as 'crank package lint' would, with every
+			// dependency it declares.
+			deps := []*parser.Package{}
+			for _, d := range pkg.Meta.Spec.DependsOn {
+				p, ok := providers[d.Package]
+				if !ok {
+					t.Fatalf("scaffold(...): unexpected dependency %s", d.Image())
+				}
+				deps = append(deps, p)
+			}
+			if in.Starter && len(deps) != 1 {
+				t.Errorf("scaffold(...): want a single dependency on %s, got %+v", StarterDependency.Package, pkg.Meta.Spec.DependsOn)
+			}
+			for _, f := range lint.NewLinter(pkg, lint.WithDependencies(deps...)).Lint() {
+				t.Errorf("Lint(...): %s:%d:%d: %s (%s)", f.Path, f.Range.Start.Line, f.Range.Start.Column, f.Message, f.Rule)
+			}
+		})
+	}
+}
+
+func TestDNSLabel(t *testing.T) {
+	cases := map[string]string{
+		"demo":            "demo",
+		"My_Demo.Package": "my-demo-package",
+		"_demo_":          "demo",
+		"__":              "package",
+	}
+	for name, want := range cases {
+		if got := dnsLabel(name); got != want {
+			t.Errorf("dnsLabel(%q): want %q, got %q", name, want, got)
+		}
+	}
+}
+
+func TestScaffoldUnpacks(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(ioutil.Discard, "", 0))))
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.NewTag(u.Host + "/acme/demo:v0.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := &initer{
+		PackageType:  Configuration,
+		Name:         "demo",
+		Starter:      true,
+		Dependencies: []Dependency{{Package: "crossplane/provider-aws", Version: "v0.12.0"}},
+	}
+	files, err := scaffold(in, in.Starter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Build the image as the scaffolded Dockerfile would, adding every file
+	// that is not excluded by the scaffolded .dockerignore.
+	b := &bytes.Buffer{}
+	tw := tar.NewWriter(b)
+	for _, f := range files {
+		if f.path == "Dockerfile" || f.path == ".dockerignore" || strings.HasPrefix(f.path, "examples") {
+			continue
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: filepath.Join(unpack.RegistryDir, f.path), Mode: 0644, Size: int64(len(f.content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(f.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l, err := tarball.LayerFromReader(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := mutate.AppendLayers(empty.Image, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+
+	_, deps, err := unpack.Unpack(ref.String())
+	if err != nil {
+		t.Fatalf("Unpack(...): %s", err)
+	}
+	want := []string{"crossplane/provider-aws:v0.12.0", StarterDependency.Package + ":" + StarterDependency.Version}
+	if diff := cmp.Diff(want, deps); diff != "" {
+		t.Errorf("Unpack(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plan plans the installation of packages without a cluster.
+package plan
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hasheddan/crank/pkg/lock"
+	"github.com/hasheddan/crank/pkg/printer"
+	"github.com/hasheddan/crank/pkg/prompt"
+	"github.com/hasheddan/crank/pkg/unpack"
+)
+
+var (
+	add    []string
+	output string
+)
+
+// Root will plan the installation of packages.
+var Root = &cobra.Command{
+	Use:   "plan <lock> --add <image>",
+	Short: "Plan the installation of packages without a cluster",
+	Long: `Plans how the package manager would add packages to the supplied
+PackageLock file, without a cluster. The dependencies of each package are read
+from the app.yaml of its image, as the package manager reads them, or from its
+crossplane.yaml if it has no app.yaml. Packages are installed once every package they depend on is
+installed, so the plan lists the packages that would be installed in order, and
+those that would remain blocked.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(add) == 0 {
+			return errors.New("at least one package must be supplied with --add")
+		}
+		if err := printer.ValidateFormat(output, "text"); err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		b, err := ioutil.ReadFile(args[0])
+		if err != nil {
+			return errors.Wrap(err, "cannot read PackageLock")
+		}
+		l, err := lock.Read(b)
+		if err != nil {
+			return err
+		}
+		pkgs, err := packages(unpack.NewFetcher(), add)
+		if err != nil {
+			return err
+		}
+		plan, err := lock.NewPlan(l, pkgs)
+		if err != nil {
+			return err
+		}
+		if output == "text" {
+			return writePlan(os.Stdout, plan)
+		}
+		write, err := printer.PrinterFor(output)
+		if err != nil {
+			return err
+		}
+		return write(os.Stdout, plan, printer.Table{})
+	},
+}
+
+func init() {
+	Root.Flags().StringArrayVar(&add, "add", nil, "Image of a package to add. May be repeated.")
+	Root.Flags().StringVarP(&output, "output", "o", "text", "Output format. One of: text, json, yaml.")
+}
+
+// packages fetches each image and reads the packages it depends on.
+func packages(f *unpack.Fetcher, images []string) ([]lock.Package, error) {
+	pkgs := []lock.Package{}
+	for _, image := range images {
+		deps, err := f.PackageDependencies(image)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, lock.Package{Image: image, Dependencies: deps})
+	}
+	return pkgs, nil
+}
+
+// writePlan writes a human readable plan to w.
+func writePlan(w io.Writer, p *lock.Plan) error {
+	lines := []string{}
+	if len(p.Install) > 0 {
+		lines = append(lines, prompt.FmtNotice(fmt.Sprintf("%d package(s) would be installed, in order:", len(p.Install))))
+		for i, pkg := range p.Install {
+			lines = append(lines, prompt.FmtInfo(fmt.Sprintf("  %d. %s", i+1, pkg.Image)))
+		}
+	}
+	if len(p.Unchanged) > 0 {
+		lines = append(lines, prompt.FmtNotice(fmt.Sprintf("%d package(s) are already in the PackageLock:", len(p.Unchanged))))
+		for _, pkg := range p.Unchanged {
+			lines = append(lines, prompt.FmtInfo("  "+pkg.Image))
+		}
+	}
+	if len(p.Blocked) > 0 {
+		lines = append(lines, prompt.FmtWarning(fmt.Sprintf("%d package(s) would remain blocked:", len(p.Blocked))))
+		for _, b := range p.Blocked {
+			lines = append(lines, prompt.FmtWarning("  "+b.Image))
+			for _, pr := range b.Problems {
+				lines = append(lines, "    "+pr.String())
+			}
+		}
+	}
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
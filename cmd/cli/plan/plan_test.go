@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/lock"
+	"github.com/hasheddan/crank/pkg/unpack"
+)
+
+const app = `title: "Root"
+packageType: Stack
+dependsOn:
+- package: "example.org/network"
+`
+
+func TestPackages(t *testing.T) {
+	d := "sha256:" + strings.Repeat("a", 64)
+	f := unpack.NewFetcher(
+		unpack.WithDigestFn(func(image string) (string, error) {
+			return d, nil
+		}),
+		unpack.WithPullFn(func(image string) (afero.Fs, string, error) {
+			fs := afero.NewMemMapFs()
+			return fs, d, afero.WriteFile(fs, unpack.AppMetadataFile, []byte(app), 0644)
+		}),
+	)
+
+	pkgs, err := packages(f, []string{"example.org/root:v0.1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []lock.Package{{Image: "example.org/root:v0.1.0", Dependencies: []string{"example.org/network"}}}
+	if diff := cmp.Diff(want, pkgs); diff != "" {
+		t.Errorf("packages(...): -want, +got:\n%s", diff)
+	}
+
+	p, err := lock.NewPlan(&v1alpha1.PackageLock{}, pkgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Install) != 0 {
+		t.Errorf("NewPlan(...): want no packages installed, got %v", p.Install)
+	}
+	if len(p.Blocked) != 1 || p.Blocked[0].Image != "example.org/root:v0.1.0" {
+		t.Errorf("NewPlan(...): want example.org/root:v0.1.0 blocked, got %v", p.Blocked)
+	}
+}
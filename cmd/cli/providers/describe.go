@@ -0,0 +1,72 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providers
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/cmd/cli/kube"
+	"github.com/hasheddan/crank/pkg/describe"
+	"github.com/hasheddan/crank/pkg/printer"
+)
+
+var describeOutput string
+
+// describer will describe an installed Crossplane Provider.
+var describer = &cobra.Command{
+	Use:   "describe <name>",
+	Short: "Describe an installed Crossplane Provider",
+	Long:  `Describe an installed Crossplane Provider, including its conditions, revisions, the CustomResourceDefinitions they own, the trees of its transitive dependencies and dependents, and recent events.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := printer.ValidateFormat(describeOutput, "text"); err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		c, err := kube.Default.Client()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := kube.Default.Context()
+		defer cancel()
+		p := &v1alpha1.Provider{}
+		if err := c.Get(ctx, types.NamespacedName{Name: args[0]}, p); err != nil {
+			return errors.Wrapf(err, "cannot get provider %s", args[0])
+		}
+		d, err := describe.Describe(ctx, c, p, &v1alpha1.ProviderRevisionList{})
+		if err != nil {
+			return err
+		}
+		if describeOutput == "text" {
+			return d.WriteText(os.Stdout)
+		}
+		write, err := printer.PrinterFor(describeOutput)
+		if err != nil {
+			return err
+		}
+		return write(os.Stdout, d, printer.Table{})
+	},
+}
+
+func init() {
+	describer.Flags().StringVarP(&describeOutput, "output", "o", "text", "Output format. One of: text, json, yaml.")
+}
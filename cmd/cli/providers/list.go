@@ -17,48 +17,82 @@ limitations under the License.
 package providers
 
 import (
-	"context"
-	"fmt"
+	"os"
+	"strings"
 
-	"github.com/hasheddan/crank/apis"
 	"github.com/hasheddan/crank/apis/v1alpha1"
-	"github.com/hasheddan/crank/pkg/prompt"
+	"github.com/hasheddan/crank/cmd/cli/kube"
+	"github.com/hasheddan/crank/pkg/printer"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-
-	ctrl "sigs.k8s.io/controller-runtime"
 )
 
+var listOutput string
+
 // list will list all installed Crossplane Providers.
 var list = &cobra.Command{
 	Use:   "list",
 	Short: "List all installed Crossplane Providers",
 	Long:  ``,
-	Run: func(cmd *cobra.Command, args []string) {
-		conf, err := ctrl.GetConfig()
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		write, err := printer.PrinterFor(listOutput)
 		if err != nil {
-			panic(err)
+			return err
 		}
-		s := runtime.NewScheme()
-		if err := apis.AddToScheme(s); err != nil {
-			panic(err)
-		}
-		c, err := client.New(conf, client.Options{Scheme: s})
+		cmd.SilenceUsage = true
+		c, err := kube.Default.Client()
 		if err != nil {
-			panic(err)
+			return err
+		}
+		ctx, cancel := kube.Default.Context()
+		defer cancel()
+		ps := &v1alpha1.ProviderList{}
+		if err := c.List(ctx, ps); err != nil {
+			return errors.Wrap(err, "cannot list Providers")
 		}
 		m := &v1alpha1.PackageLock{}
-		if err := c.Get(context.TODO(), types.NamespacedName{Name: "packages"}, m); err != nil {
-			panic(err)
+		if err := c.Get(ctx, types.NamespacedName{Name: "packages"}, m); client.IgnoreNotFound(err) != nil {
+			return errors.Wrap(err, "cannot get PackageLock")
 		}
-		for n, p := range m.Spec.Packages {
-			fmt.Printf(prompt.FmtInfo(n))
-			fmt.Printf(prompt.FmtNotice("  📦 " + p.Image))
-			for _, d := range p.Dependencies {
-				fmt.Printf(prompt.FmtNotice(" 👉 " + d))
-			}
+		ps.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("ProviderList"))
+		for i := range ps.Items {
+			ps.Items[i].SetGroupVersionKind(v1alpha1.ProviderGroupVersionKind)
 		}
+		return write(os.Stdout, ps, providerTable(ps, m))
 	},
 }
+
+// providerTable describes Providers as a table. Their dependencies are read
+// from the supplied PackageLock.
+func providerTable(ps *v1alpha1.ProviderList, m *v1alpha1.PackageLock) printer.Table {
+	deps := map[string][]string{}
+	for _, p := range m.Spec.Packages {
+		deps[p.Name] = p.Dependencies
+	}
+	t := printer.Table{Columns: []printer.Column{
+		{Name: "NAME"},
+		{Name: "PACKAGE"},
+		{Name: "READY"},
+		{Name: "AGE"},
+		{Name: "REVISION", Wide: true},
+		{Name: "DEPENDENCIES", Wide: true},
+	}}
+	for _, p := range ps.Items {
+		t.Rows = append(t.Rows, printer.Row{Name: p.GetName(), Cells: []string{
+			p.GetName(),
+			p.Spec.Package,
+			printer.Ready(p.Status.ConditionedStatus),
+			printer.Age(p.GetCreationTimestamp()),
+			p.Status.CurrentRevision,
+			strings.Join(deps[p.GetName()], ","),
+		}})
+	}
+	return t
+}
+
+func init() {
+	list.Flags().StringVarP(&listOutput, "output", "o", printer.FormatTable, printer.Usage)
+}
@@ -17,42 +17,83 @@ limitations under the License.
 package providers
 
 import (
-	"context"
-	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
-	"github.com/hasheddan/crank/apis"
 	"github.com/hasheddan/crank/apis/v1alpha1"
-	"github.com/hasheddan/crank/pkg/prompt"
+	"github.com/hasheddan/crank/cmd/cli/kube"
+	"github.com/hasheddan/crank/pkg/printer"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
-	"k8s.io/apimachinery/pkg/runtime"
-	ctrl "sigs.k8s.io/controller-runtime"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+var revisionsOutput string
+
 // revisions will list revisions for a Provider.
 var revisions = &cobra.Command{
-	Use:   "revisions",
+	Use:   "revisions <name>",
 	Short: "List all revisions for an installed Provider",
 	Long:  ``,
-	Run: func(cmd *cobra.Command, args []string) {
-		conf, err := ctrl.GetConfig()
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		write, err := printer.PrinterFor(revisionsOutput)
 		if err != nil {
-			panic(err)
-		}
-		s := runtime.NewScheme()
-		if err := apis.AddToScheme(s); err != nil {
-			panic(err)
+			return err
 		}
-		c, err := client.New(conf, client.Options{Scheme: s})
+		cmd.SilenceUsage = true
+		c, err := kube.Default.Client()
 		if err != nil {
-			panic(err)
+			return err
 		}
+		ctx, cancel := kube.Default.Context()
+		defer cancel()
 		prs := &v1alpha1.ProviderRevisionList{}
-		if err := c.List(context.TODO(), prs, client.MatchingLabels(map[string]string{"crank.crossplane.io/package": args[0]})); err != nil {
-			panic(err)
+		if err := c.List(ctx, prs, client.MatchingLabels(map[string]string{"crank.crossplane.io/package": args[0]})); err != nil {
+			return errors.Wrapf(err, "cannot list revisions of %s", args[0])
 		}
-		for _, p := range prs.Items {
-			fmt.Printf(prompt.FmtInfo(p.Name))
+		prs.SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind("ProviderRevisionList"))
+		t := revisionTable()
+		for i, p := range prs.Items {
+			prs.Items[i].SetGroupVersionKind(v1alpha1.ProviderRevisionGroupVersionKind)
+			t.Rows = append(t.Rows, revisionRow(p.GetName(), p.GetCreationTimestamp(), p.Spec, p.Status))
 		}
+		return write(os.Stdout, prs, t)
 	},
 }
+
+// revisionTable returns a table with the columns of a package revision.
+func revisionTable() printer.Table {
+	return printer.Table{Columns: []printer.Column{
+		{Name: "NAME"},
+		{Name: "IMAGE"},
+		{Name: "REVISION"},
+		{Name: "STATE"},
+		{Name: "READY"},
+		{Name: "AGE"},
+		{Name: "DEPENDENCIES", Wide: true},
+	}}
+}
+
+// revisionRow returns the row of a package revision.
+func revisionRow(name string, created metav1.Time, spec v1alpha1.PackageRevisionSpec, status v1alpha1.PackageRevisionStatus) printer.Row {
+	deps := make([]string, 0, len(spec.DependsOn))
+	for _, d := range spec.DependsOn {
+		deps = append(deps, d.Package)
+	}
+	return printer.Row{Name: name, Cells: []string{
+		name,
+		spec.Image,
+		strconv.FormatInt(spec.Revision, 10),
+		string(spec.DesiredState),
+		printer.Ready(status.ConditionedStatus),
+		printer.Age(created),
+		strings.Join(deps, ","),
+	}}
+}
+
+func init() {
+	revisions.Flags().StringVarP(&revisionsOutput, "output", "o", printer.FormatTable, printer.Usage)
+}
@@ -34,4 +34,10 @@ func Execute() error {
 func init() {
 	Root.AddCommand(list)
 	Root.AddCommand(revisions)
+	Root.AddCommand(describer)
+	Root.AddCommand(installer)
+	Root.AddCommand(uninstaller)
+	Root.AddCommand(upgrader)
+	Root.AddCommand(activator)
+	Root.AddCommand(rollbacker)
 }
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package providers
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/cmd/cli/kube"
+	"github.com/hasheddan/crank/pkg/install"
+	"github.com/hasheddan/crank/pkg/prompt"
+)
+
+// uninstaller will uninstall a Crossplane Provider.
+var uninstaller = &cobra.Command{
+	Use:   "uninstall <name>",
+	Short: "Uninstall a Crossplane Provider",
+	Long:  `Uninstall a Crossplane Provider. A Provider that other packages depend on is not uninstalled.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		c, err := kube.Default.Client()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := kube.Default.Context()
+		defer cancel()
+		p := &v1alpha1.Provider{}
+		if err := c.Get(ctx, types.NamespacedName{Name: args[0]}, p); err != nil {
+			return errors.Wrapf(err, "cannot get provider %s", args[0])
+		}
+		if err := install.Uninstall(ctx, c, p); err != nil {
+			return err
+		}
+		fmt.Println(prompt.FmtInfo(fmt.Sprintf("Provider %s uninstalled.", p.GetName())))
+		return nil
+	},
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hasheddan/crank/cmd/cli/kube"
+	"github.com/hasheddan/crank/pkg/install"
+	"github.com/hasheddan/crank/pkg/prompt"
+	"github.com/hasheddan/crank/pkg/state"
+)
+
+var (
+	applyFile        string
+	applyPrune       bool
+	applyDryRun      bool
+	applyWaitTimeout time.Duration
+)
+
+// Applier will converge a cluster to an exported state.
+var Applier = &cobra.Command{
+	Use:   "apply -f <file>",
+	Short: "Converge the packages installed in a cluster to an exported state",
+	Long: `Converges the Providers and Configurations installed in a cluster to the state
+in a file written by crank export. Packages are created and updated in
+dependency order, and the revision named by the digest of each package is
+activated. Packages that are not in the file are only deleted if --prune is
+set. With --dry-run the changes are printed but not made.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		b, err := ioutil.ReadFile(applyFile)
+		if err != nil {
+			return errors.Wrapf(err, "cannot read %s", applyFile)
+		}
+		desired, err := state.Read(b)
+		if err != nil {
+			return err
+		}
+		c, err := kube.Default.Client()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), applyWaitTimeout)
+		defer cancel()
+		current, err := state.Export(ctx, c)
+		if err != nil {
+			return err
+		}
+		changes, err := state.Diff(current, desired, applyPrune)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			fmt.Println(prompt.FmtInfo("No changes."))
+			return nil
+		}
+		if applyDryRun {
+			for _, ch := range changes {
+				switch ch.Action {
+				case state.ActionCreate:
+					fmt.Println(prompt.FmtInfo(ch.String()))
+				case state.ActionDelete:
+					fmt.Println(prompt.FmtError(ch.String()))
+				default:
+					fmt.Println(prompt.FmtWarning(ch.String()))
+				}
+			}
+			fmt.Println(prompt.FmtNotice(fmt.Sprintf("%d change(s) would be made.", len(changes))))
+			return nil
+		}
+		if err := state.Apply(ctx, c, os.Stdout, changes, install.DefaultInterval); err != nil {
+			return err
+		}
+		fmt.Println(prompt.FmtInfo(fmt.Sprintf("✔️  %d change(s) made.", len(changes))))
+		return nil
+	},
+}
+
+func init() {
+	Applier.Flags().StringVarP(&applyFile, "file", "f", state.DefaultFile, "File to read the desired state from.")
+	Applier.Flags().BoolVar(&applyPrune, "prune", false, "Delete packages that are not in the file.")
+	Applier.Flags().BoolVar(&applyDryRun, "dry-run", false, "Print the changes that would be made without making them.")
+	Applier.Flags().DurationVar(&applyWaitTimeout, "wait-timeout", 5*time.Minute, "How long to wait for the changes to be made.")
+}
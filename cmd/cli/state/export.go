@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package state exports the packages installed in a cluster, and converges a
+// cluster to an exported state.
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/hasheddan/crank/cmd/cli/kube"
+	"github.com/hasheddan/crank/pkg/printer"
+	"github.com/hasheddan/crank/pkg/prompt"
+	"github.com/hasheddan/crank/pkg/state"
+)
+
+var exportFile string
+
+// Exporter will export the packages installed in a cluster.
+var Exporter = &cobra.Command{
+	Use:   "export",
+	Short: "Export the packages installed in a cluster",
+	Long: `Exports the Providers and Configurations installed in a cluster, the digests
+of their active revisions, and their dependencies to a file that crank apply
+converges a cluster to. The file is written to stdout if --file is -.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		c, err := kube.Default.Client()
+		if err != nil {
+			return err
+		}
+		ctx, cancel := kube.Default.Context()
+		defer cancel()
+		s, err := state.Export(ctx, c)
+		if err != nil {
+			return err
+		}
+		b := &bytes.Buffer{}
+		if err := printer.PrintYAML(b, s, printer.Table{}); err != nil {
+			return err
+		}
+		if exportFile == "-" {
+			_, err := os.Stdout.Write(b.Bytes())
+			return err
+		}
+		if err := ioutil.WriteFile(exportFile, b.Bytes(), 0644); err != nil {
+			return errors.Wrapf(err, "cannot write %s", exportFile)
+		}
+		fmt.Println(prompt.FmtInfo(fmt.Sprintf("Exported %d packages to %s.", len(s.Packages), exportFile)))
+		return nil
+	},
+}
+
+func init() {
+	Exporter.Flags().StringVarP(&exportFile, "file", "f", state.DefaultFile, "File to write the exported state to.")
+}
@@ -19,12 +19,12 @@ package main
 import (
 	"bufio"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
 	"os"
 	"strings"
 
+	"github.com/hasheddan/crank/pkg/lint"
 	"github.com/hasheddan/crank/pkg/parser"
 	"github.com/sourcegraph/go-lsp"
 	"github.com/sourcegraph/jsonrpc2"
@@ -90,13 +90,14 @@ func main() {
 			if err != nil {
 				log.Print(err)
 			}
-			d, err := checkCompositionFrom(parse, pkg, params.TextDocument.URI)
+			d, err := diagnose(parse, pkg, params.TextDocument.URI)
 			if err != nil {
+				log.Print(err)
 				continue
 			}
 			re, err = json.Marshal(&lsp.PublishDiagnosticsParams{
 				URI:         lsp.DocumentURI(params.TextDocument.URI),
-				Diagnostics: []lsp.Diagnostic{d},
+				Diagnostics: d,
 			})
 			if err != nil {
 				log.Print(err)
@@ -113,15 +114,14 @@ func main() {
 			if err != nil {
 				log.Print(err)
 			}
-			d, err := checkCompositionFrom(parse, pkg, params.TextDocument.URI)
+			d, err := diagnose(parse, pkg, params.TextDocument.URI)
 			if err != nil {
 				log.Print(err)
 				continue
 			}
-			log.Print(d)
 			re, err = json.Marshal(&lsp.PublishDiagnosticsParams{
 				URI:         lsp.DocumentURI(params.TextDocument.URI),
-				Diagnostics: []lsp.Diagnostic{d},
+				Diagnostics: d,
 			})
 			if err != nil {
 				log.Print(err)
@@ -136,14 +136,14 @@ func main() {
 			if err != nil {
 				log.Print(err)
 			}
-			d, err := checkCompositionFrom(parse, pkg, params.TextDocument.URI)
+			d, err := diagnose(parse, pkg, params.TextDocument.URI)
 			if err != nil {
 				log.Print(err)
 				continue
 			}
 			re, err = json.Marshal(&lsp.PublishDiagnosticsParams{
 				URI:         lsp.DocumentURI(params.TextDocument.URI),
-				Diagnostics: []lsp.Diagnostic{d},
+				Diagnostics: d,
 			})
 			if err != nil {
 				log.Print(err)
@@ -158,14 +158,14 @@ func main() {
 			if err != nil {
 				log.Print(err)
 			}
-			d, err := checkCompositionFrom(parse, pkg, params.TextDocument.URI)
+			d, err := diagnose(parse, pkg, params.TextDocument.URI)
 			if err != nil {
 				log.Print(err)
 				continue
 			}
 			re, err = json.Marshal(&lsp.PublishDiagnosticsParams{
 				URI:         lsp.DocumentURI(params.TextDocument.URI),
-				Diagnostics: []lsp.Diagnostic{d},
+				Diagnostics: d,
 			})
 			if err != nil {
 				log.Print(err)
@@ -188,26 +188,32 @@ func stripFilePrefix(uri lsp.DocumentURI) string {
 	return strings.TrimPrefix(string(uri), "file://")
 }
 
-func checkCompositionFrom(parse *parser.Parser, pkg *parser.Package, uri lsp.DocumentURI) (lsp.Diagnostic, error) {
-	f, ok := pkg.Compositions[stripFilePrefix(uri)]
-	if !ok {
-		return lsp.Diagnostic{}, errors.New("file is not a Composition")
-	}
-	idExists := false
-	for _, id := range pkg.InfrastructureDefinitions {
-		if id.Spec.CRDSpecTemplate.Names.Kind == f.Spec.From.Kind && fmt.Sprintf("%s/%s", id.Spec.CRDSpecTemplate.Group, id.Spec.CRDSpecTemplate.Version) == f.Spec.From.APIVersion {
-			idExists = true
-			break
+func diagnose(parse *parser.Parser, pkg *parser.Package, uri lsp.DocumentURI) ([]lsp.Diagnostic, error) {
+	r := lint.NewResolver(pkg.InfrastructureDefinitions)
+	path := stripFilePrefix(uri)
+	if _, ok := pkg.Compositions[path]; ok {
+		d, err := checkCompositionFrom(parse, pkg, r, path)
+		if err != nil {
+			return nil, err
 		}
+		return append(d, lintDiagnostics(pkg, path, lint.CompositionConnectionMissingRule, lint.CompositionConnectionExtraRule)...), nil
+	}
+	if _, ok := pkg.InfrastructureDefinitions[path]; ok {
+		return checkDefinitionSatisfied(parse, pkg, r, path)
 	}
-	if idExists {
-		return lsp.Diagnostic{}, nil
+	return []lsp.Diagnostic{}, nil
+}
+
+func checkCompositionFrom(parse *parser.Parser, pkg *parser.Package, r *lint.Resolver, path string) ([]lsp.Diagnostic, error) {
+	f := pkg.Compositions[path]
+	if _, ok := r.Resolve(f.Spec.From); ok {
+		return []lsp.Diagnostic{}, nil
 	}
-	start, end, err := parse.ParseLines(stripFilePrefix(uri), "from:", "kind:")
+	start, end, err := parse.ParseLines(path, "from:", "kind:")
 	if err != nil {
-		return lsp.Diagnostic{}, err
+		return nil, err
 	}
-	return lsp.Diagnostic{
+	return []lsp.Diagnostic{{
 		Range: lsp.Range{
 			Start: lsp.Position{
 				Line:      start,
@@ -221,5 +227,58 @@ func checkCompositionFrom(parse *parser.Parser, pkg *parser.Package, uri lsp.Doc
 		Severity: lsp.Error,
 		Source:   "crosspls",
 		Message:  fmt.Sprintf("InfrastructureDefinition %s is undefined", f.Spec.From.Kind),
-	}, nil
+	}}, nil
+}
+
+func checkDefinitionSatisfied(parse *parser.Parser, pkg *parser.Package, r *lint.Resolver, path string) ([]lsp.Diagnostic, error) {
+	for _, u := range r.Unsatisfied(pkg.Compositions) {
+		if u != path {
+			continue
+		}
+		start, end, err := parse.ParseLines(path, "kind:", "name:")
+		if err != nil {
+			return nil, err
+		}
+		return []lsp.Diagnostic{{
+			Range: lsp.Range{
+				Start: lsp.Position{
+					Line:      start,
+					Character: 0,
+				},
+				End: lsp.Position{
+					Line:      end + 1,
+					Character: 0,
+				},
+			},
+			Severity: lsp.Warning,
+			Source:   "crosspls",
+			Message:  fmt.Sprintf("InfrastructureDefinition %s is not satisfied by any Composition", pkg.InfrastructureDefinitions[path].Name),
+		}}, nil
+	}
+	return []lsp.Diagnostic{}, nil
+}
+
+// lintDiagnostics returns diagnostics for the findings the supplied lint rules
+// report in the file at path.
+func lintDiagnostics(pkg *parser.Package, path string, rules ...lint.Rule) []lsp.Diagnostic {
+	d := []lsp.Diagnostic{}
+	for _, f := range lint.NewLinter(pkg, lint.WithRules(rules...)).Lint() {
+		if f.Path != path {
+			continue
+		}
+		severity := lsp.Error
+		if f.Severity == lint.SeverityWarning {
+			severity = lsp.Warning
+		}
+		d = append(d, lsp.Diagnostic{
+			Range: lsp.Range{
+				Start: lsp.Position{Line: f.Range.Start.Line - 1, Character: f.Range.Start.Column - 1},
+				End:   lsp.Position{Line: f.Range.End.Line - 1, Character: f.Range.End.Column - 1},
+			},
+			Severity: severity,
+			Source:   "crosspls",
+			Message:  f.Message,
+		})
+	}
+	return d
 }
@@ -11,11 +11,13 @@ require (
 	github.com/hasheddan/veneer v0.0.0-20200709230737-7da9988fceb1
 	github.com/hashicorp/golang-lru v0.5.4 // indirect
 	github.com/pkg/errors v0.9.1
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/sourcegraph/go-lsp v0.0.0-20200429204803-219e11d77f5d
 	github.com/sourcegraph/jsonrpc2 v0.0.0-20200429184054-15c2290dcb37
 	github.com/spf13/afero v1.2.2
 	github.com/spf13/cobra v1.0.0
 	golang.org/x/net v0.0.0-20200625001655-4c5254603344 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 	k8s.io/api v0.18.2
 	k8s.io/apiextensions-apiserver v0.18.2
 	k8s.io/apimachinery v0.18.2
@@ -0,0 +1,227 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package build builds Crossplane package images without a container runtime.
+package build
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/parser"
+	"github.com/hasheddan/crank/pkg/unpack"
+)
+
+// Annotations of a package image. They are added to the labels of its config
+// and to its descriptor in an OCI image layout.
+const (
+	AnnotationCreated      = "org.opencontainers.image.created"
+	AnnotationTitle        = "org.opencontainers.image.title"
+	AnnotationRefName      = "org.opencontainers.image.ref.name"
+	AnnotationKind         = parser.MetaGroup + "/kind"
+	AnnotationDependencies = parser.MetaGroup + "/dependencies"
+)
+
+// Epoch is the time at which a package is built unless another is supplied.
+// Every timestamp of an image is set to the time at which it is built, so
+// that the same package is always built to the same digest.
+var Epoch = time.Unix(0, 0).UTC()
+
+// excluded files are never built into an image. They are those excluded by the
+// .dockerignore that is written by crank package init.
+var excluded = []string{"Dockerfile", ".dockerignore", ".git"}
+
+// An Option configures how a package is built.
+type Option func(*builder)
+
+// WithTime sets every timestamp of the image to t.
+func WithTime(t time.Time) Option {
+	return func(b *builder) {
+		b.time = t.UTC()
+	}
+}
+
+// WithDependencies records the images of the supplied dependencies in the
+// annotations of the image, e.g. the digests locked by a crank.lock.
+func WithDependencies(deps ...v1alpha1.Dependency) Option {
+	return func(b *builder) {
+		b.deps = deps
+	}
+}
+
+// WithExclude excludes the supplied paths from the image, e.g. the path to
+// which the image is written.
+func WithExclude(paths ...string) Option {
+	return func(b *builder) {
+		b.exclude = append(b.exclude, paths...)
+	}
+}
+
+type builder struct {
+	time    time.Time
+	deps    []v1alpha1.Dependency
+	exclude []string
+}
+
+// Image builds an image of the supplied package. Its only layer contains the
+// files beneath the root of the package in the registry directory, except for
+// those ignored by its crossplane.yaml. Files are added in lexical order with
+// fixed ownership, permissions and timestamps.
+func Image(fs afero.Fs, pkg *parser.Package, opts ...Option) (v1.Image, error) {
+	b := &builder{time: Epoch}
+	for _, o := range opts {
+		o(b)
+	}
+	l, err := b.layer(fs, pkg)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot build package layer")
+	}
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: l,
+		History: v1.History{
+			Created:   v1.Time{Time: b.time},
+			CreatedBy: "crank package build",
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	cfg = cfg.DeepCopy()
+	cfg.Created = v1.Time{Time: b.time}
+	cfg.OS = "linux"
+	cfg.Architecture = "amd64"
+	cfg.Config.Labels = Annotations(pkg, b.time, b.deps...)
+	return mutate.ConfigFile(img, cfg)
+}
+
+// Annotations returns the annotations of the supplied package, built at t with
+// the supplied dependencies.
+func Annotations(pkg *parser.Package, t time.Time, deps ...v1alpha1.Dependency) map[string]string {
+	a := map[string]string{AnnotationCreated: t.UTC().Format(time.RFC3339)}
+	if pkg.Name != "" {
+		a[AnnotationTitle] = pkg.Name
+	}
+	if pkg.Meta != nil && pkg.Meta.Kind != "" {
+		a[AnnotationKind] = pkg.Meta.Kind
+	}
+	images := []string{}
+	for _, d := range deps {
+		if d.Package != "" {
+			images = append(images, d.Package)
+		}
+	}
+	if len(images) > 0 {
+		a[AnnotationDependencies] = strings.Join(images, ",")
+	}
+	return a
+}
+
+// WriteLayout writes img to an OCI image layout at path, replacing the index of
+// any existing layout. The descriptor of img is annotated with the supplied
+// annotations.
+func WriteLayout(path string, img v1.Image, annotations map[string]string) error {
+	p, err := layout.Write(path, empty.Index)
+	if err != nil {
+		return errors.Wrapf(err, "cannot write image layout %s", path)
+	}
+	return errors.Wrapf(p.AppendImage(img, layout.WithAnnotations(annotations)), "cannot write image to %s", path)
+}
+
+// layer returns a layer containing the files of the package.
+func (b *builder) layer(fs afero.Fs, pkg *parser.Package) (v1.Layer, error) {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	if err := afero.Walk(fs, pkg.Root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(pkg.Root, path)
+		if err != nil {
+			return err
+		}
+		if b.excluded(pkg, path, rel) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		hdr := &tar.Header{
+			Name:    filepath.ToSlash(filepath.Join(unpack.RegistryDir, rel)),
+			ModTime: b.time,
+		}
+		switch {
+		case info.IsDir():
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name += "/"
+			hdr.Mode = 0755
+			return tw.WriteHeader(hdr)
+		case info.Mode().IsRegular():
+			hdr.Typeflag = tar.TypeReg
+			hdr.Mode = 0644
+		default:
+			return errors.Errorf("%s is not a regular file", path)
+		}
+		f, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+		hdr.Size = int64(len(f))
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err = tw.Write(f)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return tarball.LayerFromReader(buf)
+}
+
+// excluded returns true if the file at path, which is rel to the root of the
+// package, should not be built into the image.
+func (b *builder) excluded(pkg *parser.Package, path, rel string) bool {
+	for _, e := range excluded {
+		if filepath.ToSlash(rel) == e {
+			return true
+		}
+	}
+	for _, e := range b.exclude {
+		if filepath.Clean(e) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return pkg.Ignored(path)
+}
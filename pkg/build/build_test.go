@@ -0,0 +1,179 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/parser"
+)
+
+const meta = `apiVersion: pkg.crossplane.io/v1alpha1
+kind: Configuration
+metadata:
+  name: network
+spec:
+  ignore:
+  - path: examples/
+`
+
+func write(t *testing.T, fs afero.Fs, files map[string]string) *parser.Package {
+	t.Helper()
+	for p, c := range files {
+		if err := afero.WriteFile(fs, p, []byte(c), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pkg, err := parser.NewParser(fs).ParsePackage("/pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+func TestImage(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	pkg := write(t, fs, map[string]string{
+		"/pkg/crossplane.yaml":       meta,
+		"/pkg/network/network.yaml":  "kind: Composition",
+		"/pkg/examples/network.yaml": "kind: Network",
+		"/pkg/Dockerfile":            "FROM scratch",
+	})
+	deps := []v1alpha1.Dependency{{Package: "crossplane/provider-gcp@sha256:abc"}}
+
+	img, err := Image(fs, pkg, WithDependencies(deps...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]tar.Header{}
+	tr := tar.NewReader(mutate.Extract(img))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[hdr.Name] = tar.Header{Mode: hdr.Mode, Size: hdr.Size}
+	}
+	want := map[string]tar.Header{
+		".registry/":                     {Mode: 0755},
+		".registry/crossplane.yaml":      {Mode: 0644, Size: int64(len(meta))},
+		".registry/network/":             {Mode: 0755},
+		".registry/network/network.yaml": {Mode: 0644, Size: int64(len("kind: Composition"))},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Image(...): -want files, +got files:\n%s", diff)
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLabels := map[string]string{
+		AnnotationCreated:      "1970-01-01T00:00:00Z",
+		AnnotationTitle:        "network",
+		AnnotationKind:         "Configuration",
+		AnnotationDependencies: "crossplane/provider-gcp@sha256:abc",
+	}
+	if diff := cmp.Diff(wantLabels, cfg.Config.Labels); diff != "" {
+		t.Errorf("Image(...): -want labels, +got labels:\n%s", diff)
+	}
+}
+
+func TestImageReproducible(t *testing.T) {
+	files := map[string]string{
+		"/pkg/crossplane.yaml":      meta,
+		"/pkg/network/network.yaml": "kind: Composition",
+	}
+	digest := func(opts ...Option) string {
+		fs := afero.NewMemMapFs()
+		img, err := Image(fs, write(t, fs, files), opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := img.Digest()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d.String()
+	}
+
+	first := digest()
+	if second := digest(); first != second {
+		t.Errorf("Image(...): want the same digest for the same package, got %s and %s", first, second)
+	}
+	if later := digest(WithTime(time.Unix(1600000000, 0))); first == later {
+		t.Errorf("Image(...): want a different digest for a different time, got %s", later)
+	}
+}
+
+func TestWriteLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fs := afero.NewMemMapFs()
+	img, err := Image(fs, write(t, fs, map[string]string{"/pkg/crossplane.yaml": meta}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotations := map[string]string{AnnotationRefName: "v0.1.0"}
+	// Writing twice must leave a single image in the index.
+	for i := 0; i < 2; i++ {
+		if err := WriteLayout(dir, img, annotations); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ii, err := layout.ImageIndexFromPath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := ii.IndexManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Manifests) != 1 {
+		t.Fatalf("WriteLayout(...): want 1 manifest, got %d", len(m.Manifests))
+	}
+	if m.Manifests[0].Digest != want {
+		t.Errorf("WriteLayout(...): want digest %s, got %s", want, m.Manifests[0].Digest)
+	}
+	if diff := cmp.Diff(annotations, m.Manifests[0].Annotations); diff != "" {
+		t.Errorf("WriteLayout(...): -want annotations, +got annotations:\n%s", diff)
+	}
+}
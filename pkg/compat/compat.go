@@ -0,0 +1,203 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package compat detects changes between two versions of a package that
+// break compatibility with existing resources.
+package compat
+
+import (
+	"fmt"
+	"sort"
+
+	apiv1alpha1 "github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1/ccrd"
+	"github.com/pkg/errors"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+
+	"github.com/hasheddan/crank/pkg/parser"
+)
+
+// A Change is a difference between two versions of a package.
+type Change struct {
+	// Object is the kind and name of the changed object.
+	Object string `json:"object"`
+
+	// Version is the API version whose schema changed, if any.
+	Version string `json:"version,omitempty"`
+
+	// Field is the path of the changed field, if any.
+	Field string `json:"field,omitempty"`
+
+	// Message describes the change.
+	Message string `json:"message"`
+
+	// Breaking is true if the change may break existing resources or their
+	// clients.
+	Breaking bool `json:"breaking"`
+}
+
+// Breaking returns true if any of the supplied changes is breaking.
+func Breaking(changes []Change) bool {
+	for _, c := range changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare returns the changes between the previous and next versions of a
+// package. CustomResourceDefinitions and InfrastructureDefinitions are matched
+// by name.
+func Compare(prev, next *parser.Package) ([]Change, error) {
+	changes := []Change{}
+
+	changes = append(changes, compareCRDs("CustomResourceDefinition", crdsByName(prev), crdsByName(next))...)
+
+	oldXRDs, oldCRDs, err := definitions(prev)
+	if err != nil {
+		return nil, err
+	}
+	newXRDs, newCRDs, err := definitions(next)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, compareCRDs("InfrastructureDefinition", oldCRDs, newCRDs)...)
+	for name, o := range oldXRDs {
+		n, ok := newXRDs[name]
+		if !ok {
+			continue
+		}
+		obj := fmt.Sprintf("InfrastructureDefinition %s", name)
+		keys := map[string]bool{}
+		for _, k := range n.Spec.ConnectionSecretKeys {
+			keys[k] = true
+		}
+		for _, k := range o.Spec.ConnectionSecretKeys {
+			if !keys[k] {
+				changes = append(changes, Change{Object: obj, Message: fmt.Sprintf("connection secret key %q removed", k), Breaking: true})
+			}
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		switch {
+		case a.Object != b.Object:
+			return a.Object < b.Object
+		case a.Version != b.Version:
+			return a.Version < b.Version
+		case a.Field != b.Field:
+			return a.Field < b.Field
+		}
+		return a.Message < b.Message
+	})
+	return changes, nil
+}
+
+func crdsByName(p *parser.Package) map[string]apiextensions.CustomResourceDefinition {
+	crds := map[string]apiextensions.CustomResourceDefinition{}
+	for _, crd := range p.CustomResourceDefinitions {
+		crds[crd.GetName()] = crd
+	}
+	return crds
+}
+
+// definitions returns the InfrastructureDefinitions of a package along with
+// the CustomResourceDefinitions Crossplane generates for them, both keyed by
+// the name of the InfrastructureDefinition.
+func definitions(p *parser.Package) (map[string]apiv1alpha1.InfrastructureDefinition, map[string]apiextensions.CustomResourceDefinition, error) {
+	xrds := map[string]apiv1alpha1.InfrastructureDefinition{}
+	crds := map[string]apiextensions.CustomResourceDefinition{}
+	for _, d := range p.InfrastructureDefinitions {
+		d := d
+		crd, err := ccrd.New(ccrd.ForInfrastructureDefinition(&d))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "cannot generate CustomResourceDefinition for InfrastructureDefinition %s", d.GetName())
+		}
+		xrds[d.GetName()] = d
+		crds[d.GetName()] = *crd
+	}
+	return xrds, crds, nil
+}
+
+func compareCRDs(kind string, prev, next map[string]apiextensions.CustomResourceDefinition) []Change {
+	changes := []Change{}
+	for name, o := range prev {
+		obj := fmt.Sprintf("%s %s", kind, name)
+		n, ok := next[name]
+		if !ok {
+			changes = append(changes, Change{Object: obj, Message: "removed", Breaking: true})
+			continue
+		}
+		if o.Spec.Names.Kind != n.Spec.Names.Kind {
+			changes = append(changes, Change{Object: obj, Message: fmt.Sprintf("kind renamed from %s to %s", o.Spec.Names.Kind, n.Spec.Names.Kind), Breaking: true})
+		}
+		if o.Spec.Group != n.Spec.Group {
+			changes = append(changes, Change{Object: obj, Message: fmt.Sprintf("group changed from %s to %s", o.Spec.Group, n.Spec.Group), Breaking: true})
+		}
+		if o.Spec.Scope != n.Spec.Scope {
+			changes = append(changes, Change{Object: obj, Message: fmt.Sprintf("scope changed from %s to %s", o.Spec.Scope, n.Spec.Scope), Breaking: true})
+		}
+		ov, nv := versions(o), versions(n)
+		for v, oldSchema := range ov {
+			newSchema, ok := nv[v]
+			if !ok {
+				changes = append(changes, Change{Object: obj, Version: v, Message: "version removed", Breaking: true})
+				continue
+			}
+			for _, c := range compareSchemas("", oldSchema, newSchema) {
+				c.Object, c.Version = obj, v
+				changes = append(changes, c)
+			}
+		}
+		for v := range nv {
+			if _, ok := ov[v]; !ok {
+				changes = append(changes, Change{Object: obj, Version: v, Message: "version added"})
+			}
+		}
+	}
+	for name := range next {
+		if _, ok := prev[name]; !ok {
+			changes = append(changes, Change{Object: fmt.Sprintf("%s %s", kind, name), Message: "added"})
+		}
+	}
+	return changes
+}
+
+// versions returns the schema of each served version of a
+// CustomResourceDefinition. The schema is nil if the version has none.
+func versions(crd apiextensions.CustomResourceDefinition) map[string]*apiextensions.JSONSchemaProps {
+	var common *apiextensions.JSONSchemaProps
+	if crd.Spec.Validation != nil {
+		common = crd.Spec.Validation.OpenAPIV3Schema
+	}
+	vs := map[string]*apiextensions.JSONSchemaProps{}
+	if len(crd.Spec.Versions) == 0 && crd.Spec.Version != "" {
+		vs[crd.Spec.Version] = common
+	}
+	for _, v := range crd.Spec.Versions {
+		if !v.Served {
+			continue
+		}
+		s := common
+		if v.Schema != nil {
+			s = v.Schema.OpenAPIV3Schema
+		}
+		vs[v.Name] = s
+	}
+	return vs
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compat
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/pkg/parser"
+)
+
+const oldCRD = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: buckets.storage.example.org
+spec:
+  group: storage.example.org
+  names:
+    kind: Bucket
+    plural: buckets
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: false
+  - name: v1beta1
+    served: true
+    storage: true
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          properties:
+            location:
+              type: string
+            storageClass:
+              type: string
+              enum:
+              - STANDARD
+              - NEARLINE
+            size:
+              type: integer
+              maximum: 100
+`
+
+const newCRD = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: buckets.storage.example.org
+spec:
+  group: storage.example.org
+  names:
+    kind: Bucket
+    plural: buckets
+  versions:
+  - name: v1beta1
+    served: true
+    storage: true
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          required:
+          - storageClass
+          properties:
+            storageClass:
+              type: string
+              enum:
+              - STANDARD
+            size:
+              type: integer
+              maximum: 200
+            labels:
+              type: object
+`
+
+const oldXRD = `apiVersion: apiextensions.crossplane.io/v1alpha1
+kind: InfrastructureDefinition
+metadata:
+  name: buckets.example.org
+spec:
+  connectionSecretKeys:
+  - endpoint
+  crdSpecTemplate:
+    group: example.org
+    version: v1alpha1
+    names:
+      kind: Bucket
+      plural: buckets
+`
+
+const newXRD = `apiVersion: apiextensions.crossplane.io/v1alpha1
+kind: InfrastructureDefinition
+metadata:
+  name: buckets.example.org
+spec:
+  crdSpecTemplate:
+    group: example.org
+    version: v1alpha1
+    names:
+      kind: ObjectBucket
+      plural: buckets
+`
+
+func parse(t *testing.T, files map[string]string) *parser.Package {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	for path, content := range files {
+		if err := afero.WriteFile(fs, path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pkg, err := parser.NewParser(fs).ParsePackage("/pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+func TestCompare(t *testing.T) {
+	prev := parse(t, map[string]string{"/pkg/crd.yaml": oldCRD, "/pkg/definition.yaml": oldXRD})
+	next := parse(t, map[string]string{"/pkg/crd.yaml": newCRD, "/pkg/definition.yaml": newXRD})
+
+	crd := "CustomResourceDefinition buckets.storage.example.org"
+	xrd := "InfrastructureDefinition buckets.example.org"
+	want := []Change{
+		{Object: crd, Version: "v1alpha1", Message: "version removed", Breaking: true},
+		{Object: crd, Version: "v1beta1", Field: "spec.labels", Message: "field added"},
+		{Object: crd, Version: "v1beta1", Field: "spec.location", Message: "field removed", Breaking: true},
+		{Object: crd, Version: "v1beta1", Field: "spec.size", Message: "maximum changed from 100 to 200"},
+		{Object: crd, Version: "v1beta1", Field: "spec.storageClass", Message: "enum value \"NEARLINE\" removed", Breaking: true},
+		{Object: crd, Version: "v1beta1", Field: "spec.storageClass", Message: "field is now required", Breaking: true},
+		{Object: xrd, Message: "connection secret key \"endpoint\" removed", Breaking: true},
+		{Object: xrd, Message: "kind renamed from Bucket to ObjectBucket", Breaking: true},
+	}
+
+	got, err := Compare(prev, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Compare(...): -want, +got:\n%s", diff)
+	}
+	if !Breaking(got) {
+		t.Errorf("Breaking(...): want true, got false")
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package compat
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+// compareSchemas returns the changes between two versions of the schema of
+// the field at path.
+func compareSchemas(path string, prev, next *apiextensions.JSONSchemaProps) []Change {
+	switch {
+	case prev == nil && next == nil:
+		return nil
+	case prev == nil:
+		return []Change{{Field: path, Message: "validation added", Breaking: true}}
+	case next == nil:
+		return []Change{{Field: path, Message: "validation removed"}}
+	}
+
+	changes := []Change{}
+	change := func(breaking bool, format string, a ...interface{}) {
+		changes = append(changes, Change{Field: path, Message: fmt.Sprintf(format, a...), Breaking: breaking})
+	}
+
+	if prev.Type != next.Type {
+		change(true, "type changed from %s to %s", describe(prev.Type), describe(next.Type))
+	}
+	if prev.XIntOrString != next.XIntOrString {
+		change(prev.XIntOrString, "int-or-string changed from %t to %t", prev.XIntOrString, next.XIntOrString)
+	}
+	if preserves(prev) != preserves(next) {
+		change(preserves(prev), "preserving unknown fields changed from %t to %t", preserves(prev), preserves(next))
+	}
+	if prev.Format != next.Format {
+		change(next.Format != "", "format changed from %s to %s", describe(prev.Format), describe(next.Format))
+	}
+	if prev.Pattern != next.Pattern {
+		change(next.Pattern != "", "pattern changed from %s to %s", describe(prev.Pattern), describe(next.Pattern))
+	}
+	changes = append(changes, compareEnums(path, prev.Enum, next.Enum)...)
+	compareBound(change, "minimum", prev.Minimum, next.Minimum, true)
+	compareBound(change, "maximum", prev.Maximum, next.Maximum, false)
+	compareIntBound(change, "minLength", prev.MinLength, next.MinLength, true)
+	compareIntBound(change, "maxLength", prev.MaxLength, next.MaxLength, false)
+	compareIntBound(change, "minItems", prev.MinItems, next.MinItems, true)
+	compareIntBound(change, "maxItems", prev.MaxItems, next.MaxItems, false)
+	compareIntBound(change, "minProperties", prev.MinProperties, next.MinProperties, true)
+	compareIntBound(change, "maxProperties", prev.MaxProperties, next.MaxProperties, false)
+
+	required := map[string]bool{}
+	for _, r := range prev.Required {
+		required[r] = true
+	}
+	for _, r := range next.Required {
+		if !required[r] {
+			changes = append(changes, Change{Field: field(path, r), Message: "field is now required", Breaking: true})
+		}
+		delete(required, r)
+	}
+	for r := range required {
+		changes = append(changes, Change{Field: field(path, r), Message: "field is no longer required"})
+	}
+
+	for name, o := range prev.Properties {
+		o := o
+		n, ok := next.Properties[name]
+		if !ok {
+			if !allowsAdditional(next) {
+				changes = append(changes, Change{Field: field(path, name), Message: "field removed", Breaking: true})
+			}
+			continue
+		}
+		changes = append(changes, compareSchemas(field(path, name), &o, &n)...)
+	}
+	for name := range next.Properties {
+		if _, ok := prev.Properties[name]; !ok {
+			changes = append(changes, Change{Field: field(path, name), Message: "field added"})
+		}
+	}
+
+	if prev.Items != nil && next.Items != nil {
+		changes = append(changes, compareSchemas(path+"[*]", prev.Items.Schema, next.Items.Schema)...)
+	}
+	if prev.AdditionalProperties != nil && next.AdditionalProperties != nil {
+		changes = append(changes, compareSchemas(path+"[*]", prev.AdditionalProperties.Schema, next.AdditionalProperties.Schema)...)
+	}
+	return changes
+}
+
+func compareEnums(path string, prev, next []apiextensions.JSON) []Change {
+	switch {
+	case len(prev) == 0 && len(next) == 0:
+		return nil
+	case len(prev) == 0:
+		return []Change{{Field: path, Message: "enum added", Breaking: true}}
+	case len(next) == 0:
+		return []Change{{Field: path, Message: "enum removed"}}
+	}
+	values := func(e []apiextensions.JSON) map[string]bool {
+		m := map[string]bool{}
+		for _, v := range e {
+			m[string(v.Raw)] = true
+		}
+		return m
+	}
+	ov, nv := values(prev), values(next)
+	changes := []Change{}
+	for _, v := range sortedKeys(ov) {
+		if !nv[v] {
+			changes = append(changes, Change{Field: path, Message: fmt.Sprintf("enum value %s removed", v), Breaking: true})
+		}
+	}
+	for _, v := range sortedKeys(nv) {
+		if !ov[v] {
+			changes = append(changes, Change{Field: path, Message: fmt.Sprintf("enum value %s added", v)})
+		}
+	}
+	return changes
+}
+
+// compareBound reports a change to a numeric bound. A lower bound tightens if
+// it is added or increased, and an upper bound if it is added or decreased.
+func compareBound(change func(bool, string, ...interface{}), name string, prev, next *float64, lower bool) {
+	switch {
+	case prev == nil && next == nil:
+	case prev == nil:
+		change(true, "%s of %v added", name, *next)
+	case next == nil:
+		change(false, "%s of %v removed", name, *prev)
+	case *prev != *next:
+		change((*next > *prev) == lower, "%s changed from %v to %v", name, *prev, *next)
+	}
+}
+
+func compareIntBound(change func(bool, string, ...interface{}), name string, prev, next *int64, lower bool) {
+	var o, n *float64
+	if prev != nil {
+		f := float64(*prev)
+		o = &f
+	}
+	if next != nil {
+		f := float64(*next)
+		n = &f
+	}
+	compareBound(change, name, o, n, lower)
+}
+
+func preserves(s *apiextensions.JSONSchemaProps) bool {
+	return s.XPreserveUnknownFields != nil && *s.XPreserveUnknownFields
+}
+
+// allowsAdditional returns true if an object with the supplied schema accepts
+// fields that are not defined by its properties.
+func allowsAdditional(s *apiextensions.JSONSchemaProps) bool {
+	return preserves(s) || (s.AdditionalProperties != nil && (s.AdditionalProperties.Allows || s.AdditionalProperties.Schema != nil))
+}
+
+func field(path, name string) string {
+	if strings.ContainsAny(name, ".[]") {
+		b, _ := json.Marshal(name)
+		return fmt.Sprintf("%s[%s]", path, b)
+	}
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func describe(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
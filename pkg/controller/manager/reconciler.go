@@ -26,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
@@ -45,6 +46,15 @@ const (
 	aShortWait = 30 * time.Second
 )
 
+// Reasons for events recorded by the Reconciler.
+const (
+	ReasonBuildState         event.Reason = "failed building state"
+	ReasonUnpack             event.Reason = "failed to unpack package"
+	ReasonAddNode            event.Reason = "failed adding node"
+	ReasonDependencies       event.Reason = "failed adding package dependencies"
+	ReasonCyclicalDependency event.Reason = "cyclical dependency"
+)
+
 // ReconcilerOption is used to configure the Reconciler.
 type ReconcilerOption func(*Reconciler)
 
@@ -62,6 +72,14 @@ func WithNewPackageRevisionFn(f func() v1alpha1.PackageRevision) ReconcilerOptio
 	}
 }
 
+// WithNewPackageRevisionListFn determines the type of package revision list
+// used to find the revisions of a package.
+func WithNewPackageRevisionListFn(f func() v1alpha1.PackageRevisionList) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.newPackageRevisionList = f
+	}
+}
+
 // WithLogger specifies how the Reconciler should log messages.
 func WithLogger(log logging.Logger) ReconcilerOption {
 	return func(r *Reconciler) {
@@ -82,8 +100,9 @@ type Reconciler struct {
 	log    logging.Logger
 	record event.Recorder
 
-	newPackage         func() v1alpha1.Package
-	newPackageRevision func() v1alpha1.PackageRevision
+	newPackage             func() v1alpha1.Package
+	newPackageRevision     func() v1alpha1.PackageRevision
+	newPackageRevisionList func() v1alpha1.PackageRevisionList
 }
 
 // SetupProvider adds a controller that reconciles Providers.
@@ -91,10 +110,12 @@ func SetupProvider(mgr ctrl.Manager, l logging.Logger) error {
 	name := "packages/" + strings.ToLower(v1alpha1.ProviderGroupKind)
 	np := func() v1alpha1.Package { return &v1alpha1.Provider{} }
 	nr := func() v1alpha1.PackageRevision { return &v1alpha1.ProviderRevision{} }
+	nl := func() v1alpha1.PackageRevisionList { return &v1alpha1.ProviderRevisionList{} }
 
 	r := NewReconciler(mgr,
 		WithNewPackageFn(np),
 		WithNewPackageRevisionFn(nr),
+		WithNewPackageRevisionListFn(nl),
 		WithLogger(l.WithValues("controller", name)),
 		WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor("provider"))),
 	)
@@ -111,10 +132,12 @@ func SetupConfiguration(mgr ctrl.Manager, l logging.Logger) error {
 	name := "packages/" + strings.ToLower(v1alpha1.ConfigurationGroupKind)
 	np := func() v1alpha1.Package { return &v1alpha1.Configuration{} }
 	nr := func() v1alpha1.PackageRevision { return &v1alpha1.ConfigurationRevision{} }
+	nl := func() v1alpha1.PackageRevisionList { return &v1alpha1.ConfigurationRevisionList{} }
 
 	r := NewReconciler(mgr,
 		WithNewPackageFn(np),
 		WithNewPackageRevisionFn(nr),
+		WithNewPackageRevisionListFn(nl),
 		WithLogger(l.WithValues("controller", name)),
 		WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor("configuration"))),
 	)
@@ -170,7 +193,7 @@ func (r *Reconciler) Reconcile(req reconcile.Request) (reconcile.Result, error)
 	d, err := dag.New(m.Spec.Packages)
 	if err != nil {
 		p.SetConditions(runtimev1alpha1.Unavailable(), runtimev1alpha1.ReconcileSuccess())
-		r.record.Event(p, event.Warning(event.Reason("failed building state"), err))
+		r.record.Event(p, event.Warning(ReasonBuildState, err))
 		return reconcile.Result{RequeueAfter: aShortWait}, errors.Wrap(r.client.Status().Update(ctx, p), "cannot update package status")
 	}
 
@@ -179,7 +202,7 @@ func (r *Reconciler) Reconcile(req reconcile.Request) (reconcile.Result, error)
 	digest, deps, err := unpack.Unpack(p.GetSource())
 	if err != nil {
 		p.SetConditions(runtimev1alpha1.Unavailable(), runtimev1alpha1.ReconcileSuccess())
-		r.record.Event(p, event.Warning(event.Reason("failed to unpack package"), err))
+		r.record.Event(p, event.Warning(ReasonUnpack, err))
 		return reconcile.Result{RequeueAfter: aShortWait}, errors.Wrap(r.client.Status().Update(ctx, p), "cannot update package status")
 	}
 	// If node does not exist in DAG then we want to add it and make sure it is
@@ -188,20 +211,20 @@ func (r *Reconciler) Reconcile(req reconcile.Request) (reconcile.Result, error)
 		// If node does not exist already then adding it should always be successful.
 		if err := d.AddNode(strings.Split(p.GetSource(), ":")[0]); err != nil {
 			p.SetConditions(runtimev1alpha1.Unavailable(), runtimev1alpha1.ReconcileSuccess())
-			r.record.Event(p, event.Warning(event.Reason("failed adding node"), err))
+			r.record.Event(p, event.Warning(ReasonAddNode, err))
 			return reconcile.Result{RequeueAfter: aShortWait}, errors.Wrap(r.client.Status().Update(ctx, p), "cannot update package status")
 		}
 		// Check to see if all dependencies are satisfied.
 		if err := d.AddEdges(map[string][]string{strings.Split(p.GetSource(), ":")[0]: deps}); err != nil {
 			// If dependencies are not satisfied, we need to install them.
 			p.SetConditions(runtimev1alpha1.Unavailable(), runtimev1alpha1.ReconcileSuccess())
-			r.record.Event(p, event.Warning(event.Reason("failed adding package dependencies"), err))
+			r.record.Event(p, event.Warning(ReasonDependencies, err))
 			return reconcile.Result{RequeueAfter: aShortWait}, errors.Wrap(r.client.Status().Update(ctx, p), "cannot update package status")
 		}
 		// Check that adding dependencies does not result in cyclical dependency.
 		if _, err := d.Sort(); err != nil {
 			p.SetConditions(runtimev1alpha1.Unavailable(), runtimev1alpha1.ReconcileSuccess())
-			r.record.Event(p, event.Warning(event.Reason("cyclical dependency"), err))
+			r.record.Event(p, event.Warning(ReasonCyclicalDependency, err))
 			return reconcile.Result{RequeueAfter: aShortWait}, errors.Wrap(r.client.Status().Update(ctx, p), "cannot update package status")
 		}
 		// Append the package to the PackageLock and attempt to update.
@@ -221,12 +244,30 @@ func (r *Reconciler) Reconcile(req reconcile.Request) (reconcile.Result, error)
 		}
 	}
 	// If updating the PackageLock was successful then we can create PackageRevision safely.
+	prs := r.newPackageRevisionList()
+	if err := r.client.List(ctx, prs, client.MatchingLabels{"crank.crossplane.io/package": p.GetName()}); err != nil {
+		log.Debug("Cannot list PackageRevisions", "error", err)
+		return reconcile.Result{}, errors.Wrap(err, "cannot list PackageRevisions")
+	}
+	// A new revision is numbered after the package's latest revision, and is
+	// only active if no other revision is. The number and desired state of an
+	// existing revision are preserved by the applicator.
+	var latest int64
+	state := v1alpha1.PackageRevisionActive
+	for _, rev := range prs.GetRevisions() {
+		if rev.GetRevision() > latest {
+			latest = rev.GetRevision()
+		}
+		if rev.GetName() != digest && rev.GetDesiredState() == v1alpha1.PackageRevisionActive {
+			state = v1alpha1.PackageRevisionInactive
+		}
+	}
 	pr := r.newPackageRevision()
 	pr.SetName(digest)
 	pr.SetLabels(map[string]string{"crank.crossplane.io/package": p.GetName()})
-	pr.SetDesiredState(v1alpha1.PackageRevisionInactive)
+	pr.SetDesiredState(state)
 	pr.SetSource(p.GetSource())
-	pr.SetRevision(1)
+	pr.SetRevision(latest + 1)
 
 	meta.AddOwnerReference(pr, meta.AsController(meta.ReferenceTo(p, p.GetObjectKind().GroupVersionKind())))
 	if err := r.client.Applicator.Apply(ctx, pr, resource.MustBeControllableBy(p.GetUID()), desiredStateApplicator()); err != nil {
@@ -251,6 +292,7 @@ func desiredStateApplicator() resource.ApplyOption {
 			return errors.New("not package revision")
 		}
 		dr.SetDesiredState(cr.GetDesiredState())
+		dr.SetRevision(cr.GetRevision())
 		return nil
 	}
 }
@@ -186,8 +186,12 @@ func ownerReferenceApplicator(r v1.OwnerReference) resource.ApplyOption {
 		if !ok {
 			return errors.New("not crd")
 		}
-		dr.OwnerReferences = cr.OwnerReferences
-		meta.AddOwnerReference(cr, r)
+		// Preserve the references of other owners, replacing any existing
+		// reference to this owner. Only one owner may be the controller, so an
+		// active revision must wait for the previously active revision to
+		// release control.
+		dr.OwnerReferences = append([]v1.OwnerReference{}, cr.OwnerReferences...)
+		meta.AddOwnerReference(dr, r)
 		return nil
 	}
 }
@@ -18,6 +18,7 @@ package dag
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/hasheddan/crank/apis/v1alpha1"
@@ -96,14 +97,15 @@ func (d *Dag) AddEdge(from, to string) error {
 	return nil
 }
 
-// Sort performs topological sort on the graph.
+// Sort performs topological sort on the graph. Nodes are visited in order of
+// name, so the result is deterministic. A *CycleError is returned if the graph
+// has a cycle.
 func (d *Dag) Sort() ([]string, error) {
 	visited := map[string]bool{}
-	results := make([]string, len(d.nodes))
-	for n, deps := range d.nodes {
-		if visited[n] == false {
-			stack := map[string]bool{}
-			if err := d.visit(n, deps, stack, visited, results); err != nil {
+	results := make([]string, 0, len(d.nodes))
+	for _, n := range d.names() {
+		if !visited[n] {
+			if err := d.visit(n, []string{}, visited, &results); err != nil {
 				return nil, err
 			}
 		}
@@ -111,24 +113,104 @@ func (d *Dag) Sort() ([]string, error) {
 	return results, nil
 }
 
-func (d *Dag) visit(name string, deps []string, stack map[string]bool, visited map[string]bool, results []string) error {
+func (d *Dag) visit(name string, path []string, visited map[string]bool, results *[]string) error {
 	visited[name] = true
-	stack[name] = true
-	for _, dep := range deps {
-		if visited[dep] == false {
-			if err := d.visit(dep, d.nodes[dep], stack, visited, results); err != nil {
+	path = append(path, name)
+	for _, dep := range d.nodes[name] {
+		if i := index(path, dep); i >= 0 {
+			return &CycleError{Path: append(append([]string{}, path[i:]...), dep)}
+		}
+		if !visited[dep] {
+			if err := d.visit(dep, path, visited, results); err != nil {
 				return err
 			}
-		} else if stack[dep] == true {
-			return errors.New(fmt.Sprintf("detected cycle: %s", dep))
 		}
 	}
-	for i, r := range results {
-		if r == "" {
-			results[i] = name
-			break
+	*results = append(*results, name)
+	return nil
+}
+
+// A CycleError is returned when a graph has a cycle.
+type CycleError struct {
+	// Path of the cycle, which starts and ends with the same node.
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("detected cycle: %s", strings.Join(e.Path, " -> "))
+}
+
+// Cycles returns the path of every cycle found by a depth-first search of the
+// graph. Each path starts and ends with the same node.
+func (d *Dag) Cycles() [][]string {
+	cycles := [][]string{}
+	visited := map[string]bool{}
+	var visit func(name string, path []string)
+	visit = func(name string, path []string) {
+		visited[name] = true
+		path = append(path, name)
+		for _, dep := range d.nodes[name] {
+			if i := index(path, dep); i >= 0 {
+				cycles = append(cycles, append(append([]string{}, path[i:]...), dep))
+				continue
+			}
+			if !visited[dep] {
+				visit(dep, path)
+			}
 		}
 	}
-	stack[name] = false
-	return nil
+	for _, n := range d.names() {
+		if !visited[n] {
+			visit(n, []string{})
+		}
+	}
+	return cycles
+}
+
+// Paths returns every path to the named node from a node that no other node
+// depends on. Paths that reach a cycle stop before they would revisit a node.
+func (d *Dag) Paths(to string) [][]string {
+	dependents := map[string][]string{}
+	for _, n := range d.names() {
+		for _, dep := range d.nodes[n] {
+			dependents[dep] = append(dependents[dep], n)
+		}
+	}
+	paths := [][]string{}
+	var walk func(path []string)
+	walk = func(path []string) {
+		more := false
+		for _, n := range dependents[path[0]] {
+			if index(path, n) >= 0 {
+				continue
+			}
+			more = true
+			walk(append([]string{n}, path...))
+		}
+		if !more {
+			paths = append(paths, path)
+		}
+	}
+	walk([]string{to})
+	return paths
+}
+
+// names returns the names of the nodes of the graph in order.
+func (d *Dag) names() []string {
+	names := make([]string, 0, len(d.nodes))
+	for n := range d.nodes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// index returns the index of s in ss, or -1 if it is not present.
+func index(ss []string, s string) int {
+	for i, v := range ss {
+		if v == s {
+			return i
+		}
+	}
+	return -1
 }
@@ -40,3 +40,34 @@ func TestSort(t *testing.T) {
 		t.Fatalf("wrong order: %v", res)
 	}
 }
+
+func TestSortCycle(t *testing.T) {
+	dag := &Dag{map[string][]string{}}
+	if err := dag.AddNodes("A", "B", "C"); err != nil {
+		t.Fatalf("cannot add node: %s", err)
+	}
+	if err := dag.AddEdges(map[string][]string{"A": {"B"}, "B": {"C"}, "C": {"B"}}); err != nil {
+		t.Fatalf("cannot add edges: %s", err)
+	}
+	_, err := dag.Sort()
+	if diff := cmp.Diff("detected cycle: B -> C -> B", fmt.Sprint(err)); diff != "" {
+		t.Errorf("Sort(): -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff([][]string{{"B", "C", "B"}}, dag.Cycles()); diff != "" {
+		t.Errorf("Cycles(): -want, +got:\n%s", diff)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	dag := &Dag{map[string][]string{}}
+	if err := dag.AddNodes("A", "B", "C", "D"); err != nil {
+		t.Fatalf("cannot add node: %s", err)
+	}
+	if err := dag.AddEdges(map[string][]string{"A": {"B", "C"}, "B": {"D"}, "C": {"D"}}); err != nil {
+		t.Fatalf("cannot add edges: %s", err)
+	}
+	want := [][]string{{"A", "B", "D"}, {"A", "C", "D"}}
+	if diff := cmp.Diff(want, dag.Paths("D")); diff != "" {
+		t.Errorf("Paths(...): -want, +got:\n%s", diff)
+	}
+}
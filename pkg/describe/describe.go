@@ -0,0 +1,338 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package describe describes installed Crossplane packages.
+package describe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/install"
+	"github.com/hasheddan/crank/pkg/lock"
+	"github.com/hasheddan/crank/pkg/printer"
+)
+
+// MaxEvents is the maximum number of recent events included in a Description.
+const MaxEvents = 20
+
+// A Description of an installed package.
+type Description struct {
+	Name            string                      `json:"name"`
+	Kind            string                      `json:"kind"`
+	Package         string                      `json:"package"`
+	CurrentRevision string                      `json:"currentRevision,omitempty"`
+	Conditions      []runtimev1alpha1.Condition `json:"conditions,omitempty"`
+	Revisions       []Revision                  `json:"revisions"`
+	Dependencies    []Node                      `json:"dependencies"`
+	Dependents      []Node                      `json:"dependents"`
+	Events          []Event                     `json:"events"`
+}
+
+// A Revision of a described package.
+type Revision struct {
+	// Name of the revision, which is the digest of its image.
+	Name         string                               `json:"name"`
+	Revision     int64                                `json:"revision"`
+	Image        string                               `json:"image"`
+	DesiredState v1alpha1.PackageRevisionDesiredState `json:"desiredState"`
+	Ready        corev1.ConditionStatus               `json:"ready"`
+	Created      metav1.Time                          `json:"created"`
+
+	// CustomResourceDefinitions owned by the revision.
+	CustomResourceDefinitions []CustomResourceDefinition `json:"crds"`
+}
+
+// A CustomResourceDefinition owned by a revision.
+type CustomResourceDefinition struct {
+	Name string `json:"name"`
+
+	// Controller is true if the revision controls the CRD.
+	Controller bool `json:"controller"`
+}
+
+// A Node of a tree of the transitive dependencies or dependents of a
+// described package.
+type Node struct {
+	// Package is the repository of the package, as recorded in the
+	// PackageLock.
+	Package string `json:"package"`
+
+	// Nodes are the dependencies of the package in a tree of dependencies,
+	// or its dependents in a tree of dependents.
+	Nodes []Node `json:"nodes,omitempty"`
+}
+
+// An Event of a package or one of its revisions.
+type Event struct {
+	Object   string      `json:"object"`
+	Type     string      `json:"type"`
+	Reason   string      `json:"reason"`
+	Message  string      `json:"message"`
+	Count    int32       `json:"count"`
+	LastSeen metav1.Time `json:"lastSeen"`
+}
+
+// Describe returns a Description of the supplied package, which must have
+// been read from the API server. The type of its revisions is determined by l.
+func Describe(ctx context.Context, c client.Client, p v1alpha1.Package, l v1alpha1.PackageRevisionList) (*Description, error) {
+	d := &Description{
+		Name:            p.GetName(),
+		Kind:            install.KindOf(p),
+		Package:         p.GetSource(),
+		CurrentRevision: p.GetCurrentRevision(),
+		Revisions:       []Revision{},
+		Dependencies:    []Node{},
+		Dependents:      []Node{},
+		Events:          []Event{},
+	}
+	for _, t := range []runtimev1alpha1.ConditionType{runtimev1alpha1.TypeReady, runtimev1alpha1.TypeSynced} {
+		if cd := p.GetCondition(t); cd.Reason != "" {
+			d.Conditions = append(d.Conditions, cd)
+		}
+	}
+
+	revs, err := install.Revisions(ctx, c, p, l)
+	if err != nil {
+		return nil, err
+	}
+	crds := &apiextensions.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crds); err != nil {
+		return nil, errors.Wrap(err, "cannot list CustomResourceDefinitions")
+	}
+	objects := []resource.Object{p}
+	for _, r := range revs {
+		d.Revisions = append(d.Revisions, Revision{
+			Name:                      r.GetName(),
+			Revision:                  r.GetRevision(),
+			Image:                     r.GetSource(),
+			DesiredState:              r.GetDesiredState(),
+			Ready:                     r.GetCondition(runtimev1alpha1.TypeReady).Status,
+			Created:                   r.GetCreationTimestamp(),
+			CustomResourceDefinitions: owned(crds.Items, r.GetUID()),
+		})
+		objects = append(objects, r)
+	}
+
+	pl := &v1alpha1.PackageLock{}
+	if err := c.Get(ctx, types.NamespacedName{Name: install.LockName}, pl); client.IgnoreNotFound(err) != nil {
+		return nil, errors.Wrap(err, "cannot get PackageLock")
+	}
+	d.Dependencies, d.Dependents = trees(pl.Spec.Packages, lock.Key(p.GetSource()))
+
+	for _, o := range objects {
+		events, err := install.Events(ctx, c, o)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			d.Events = append(d.Events, Event{
+				Object:   install.KindOf(o) + "/" + o.GetName(),
+				Type:     e.Type,
+				Reason:   e.Reason,
+				Message:  e.Message,
+				Count:    e.Count,
+				LastSeen: e.LastTimestamp,
+			})
+		}
+	}
+	sort.SliceStable(d.Events, func(i, j int) bool { return d.Events[i].LastSeen.Before(&d.Events[j].LastSeen) })
+	if len(d.Events) > MaxEvents {
+		d.Events = d.Events[len(d.Events)-MaxEvents:]
+	}
+	return d, nil
+}
+
+// trees returns the trees of the transitive dependencies and dependents of the
+// named node of the dependency graph of the supplied packages. Both are built
+// from the paths that lead to nodes of the graph from packages that nothing
+// depends on: the part of a path after the named node is a chain of
+// dependencies, and the part before it, reversed, is a chain of dependents.
+func trees(pkgs map[string]v1alpha1.PackageDependencies, name string) ([]Node, []Node) {
+	g := lock.Graph(pkgs)
+	nodes := map[string]bool{}
+	for _, p := range pkgs {
+		nodes[lock.Key(p.Image)] = true
+		for _, dep := range p.Dependencies {
+			nodes[dep] = true
+		}
+	}
+	deps := []Node{}
+	for n := range nodes {
+		if n == name {
+			continue
+		}
+		for _, path := range g.Paths(n) {
+			for i := range path {
+				if path[i] == name {
+					deps = insert(deps, path[i+1:])
+					break
+				}
+			}
+		}
+	}
+	dependents := []Node{}
+	if nodes[name] {
+		for _, path := range g.Paths(name) {
+			chain := make([]string, 0, len(path)-1)
+			for i := len(path) - 2; i >= 0; i-- {
+				chain = append(chain, path[i])
+			}
+			dependents = insert(dependents, chain)
+		}
+	}
+	return sorted(deps), sorted(dependents)
+}
+
+// insert adds the supplied chain of packages to a tree, reusing the nodes
+// that are already in it.
+func insert(tree []Node, chain []string) []Node {
+	if len(chain) == 0 {
+		return tree
+	}
+	for i := range tree {
+		if tree[i].Package == chain[0] {
+			tree[i].Nodes = insert(tree[i].Nodes, chain[1:])
+			return tree
+		}
+	}
+	return append(tree, Node{Package: chain[0], Nodes: insert(nil, chain[1:])})
+}
+
+// sorted orders every level of the supplied tree by package.
+func sorted(tree []Node) []Node {
+	sort.Slice(tree, func(i, j int) bool { return tree[i].Package < tree[j].Package })
+	for i := range tree {
+		tree[i].Nodes = sorted(tree[i].Nodes)
+	}
+	return tree
+}
+
+// owned returns the supplied CRDs that are owned by the supplied UID.
+func owned(crds []apiextensions.CustomResourceDefinition, uid types.UID) []CustomResourceDefinition {
+	out := []CustomResourceDefinition{}
+	for _, crd := range crds {
+		for _, ref := range crd.GetOwnerReferences() {
+			if ref.UID == uid {
+				out = append(out, CustomResourceDefinition{Name: crd.GetName(), Controller: ref.Controller != nil && *ref.Controller})
+				break
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// WriteText writes the Description in a human readable format.
+func (d *Description) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	line := func(indent int, cells ...string) {
+		fmt.Fprintln(tw, strings.Repeat("  ", indent)+strings.Join(cells, "\t")) // nolint:errcheck
+	}
+	or := func(s string) string {
+		if s == "" {
+			return "<none>"
+		}
+		return s
+	}
+
+	line(0, "Name:", d.Name)
+	line(0, "Kind:", d.Kind)
+	line(0, "Package:", d.Package)
+	line(0, "Current Revision:", or(d.CurrentRevision))
+
+	line(0, "Conditions:")
+	if len(d.Conditions) == 0 {
+		line(1, "<none>")
+	} else {
+		line(1, "TYPE", "STATUS", "REASON", "AGE", "MESSAGE")
+		for _, c := range d.Conditions {
+			line(1, string(c.Type), string(c.Status), string(c.Reason), printer.Age(c.LastTransitionTime), or(c.Message))
+		}
+	}
+
+	line(0, "Revisions:")
+	if len(d.Revisions) == 0 {
+		line(1, "<none>")
+	} else {
+		line(1, "REVISION", "NAME", "IMAGE", "STATE", "READY", "AGE")
+		for _, r := range d.Revisions {
+			line(1, strconv.FormatInt(r.Revision, 10), r.Name, r.Image, or(string(r.DesiredState)), string(r.Ready), printer.Age(r.Created))
+		}
+	}
+
+	line(0, "CustomResourceDefinitions:")
+	n := 0
+	for _, r := range d.Revisions {
+		for _, crd := range r.CustomResourceDefinitions {
+			if n == 0 {
+				line(1, "NAME", "REVISION", "CONTROLLER")
+			}
+			line(1, crd.Name, strconv.FormatInt(r.Revision, 10), strconv.FormatBool(crd.Controller))
+			n++
+		}
+	}
+	if n == 0 {
+		line(1, "<none>")
+	}
+
+	line(0, "Dependencies:")
+	tree(line, d.Dependencies)
+	line(0, "Dependents:")
+	tree(line, d.Dependents)
+
+	line(0, "Events:")
+	if len(d.Events) == 0 {
+		line(1, "<none>")
+	} else {
+		line(1, "TYPE", "REASON", "OBJECT", "AGE", "COUNT", "MESSAGE")
+		for _, e := range d.Events {
+			line(1, e.Type, e.Reason, e.Object, printer.Age(e.LastSeen), strconv.Itoa(int(e.Count)), e.Message)
+		}
+	}
+	return tw.Flush()
+}
+
+// tree writes the supplied tree, indenting each node beneath its parent.
+func tree(line func(int, ...string), nodes []Node) {
+	if len(nodes) == 0 {
+		line(1, "<none>")
+		return
+	}
+	var write func(indent int, nodes []Node)
+	write = func(indent int, nodes []Node) {
+		for _, n := range nodes {
+			line(indent, n.Package)
+			write(indent+1, n.Nodes)
+		}
+	}
+	write(1, nodes)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package describe
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hasheddan/crank/apis"
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/install"
+)
+
+func TestDescribe(t *testing.T) {
+	s := runtime.NewScheme()
+	for _, add := range []func(*runtime.Scheme) error{apis.AddToScheme, clientgoscheme.AddToScheme, apiextensions.AddToScheme} {
+		if err := add(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ready := runtimev1alpha1.Available()
+	ready.LastTransitionTime = metav1.Time{}
+	p := install.NewProvider(install.Options{Name: "provider-gcp", Package: "crossplane/provider-gcp:v2"})
+	p.SetUID("provider")
+	p.SetCurrentRevision("def456")
+	p.SetConditions(ready)
+
+	revision := func(name string, n int64, state v1alpha1.PackageRevisionDesiredState) *v1alpha1.ProviderRevision {
+		r := &v1alpha1.ProviderRevision{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			UID:    types.UID(name),
+			Labels: map[string]string{install.LabelPackage: "provider-gcp"},
+		}}
+		r.SetRevision(n)
+		r.SetDesiredState(state)
+		r.SetSource("crossplane/provider-gcp:v" + string(rune('0'+n)))
+		r.SetConditions(ready)
+		return r
+	}
+	controller := true
+	crd := &apiextensions.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{
+		Name: "buckets.storage.gcp.crossplane.io",
+		OwnerReferences: []metav1.OwnerReference{
+			{Name: "abc123", UID: "abc123"},
+			{Name: "def456", UID: "def456", Controller: &controller},
+		},
+	}}
+	lock := &v1alpha1.PackageLock{
+		ObjectMeta: metav1.ObjectMeta{Name: install.LockName},
+		Spec: v1alpha1.PackageLockSpec{Packages: map[string]v1alpha1.PackageDependencies{
+			"crossplane/provider-gcp":  {Name: "provider-gcp", Image: "crossplane/provider-gcp:v2", Dependencies: []string{"crossplane/provider-helm"}},
+			"crossplane/provider-helm": {Name: "provider-helm", Image: "crossplane/provider-helm:v1", Dependencies: []string{"crossplane/provider-kubernetes"}},
+			"acme/platform":            {Name: "platform", Image: "acme/platform:v1", Dependencies: []string{"crossplane/provider-gcp"}},
+			"acme/storage":             {Name: "storage", Image: "acme/storage:v1", Dependencies: []string{"crossplane/provider-gcp"}},
+			"acme/app":                 {Name: "app", Image: "acme/app:v1", Dependencies: []string{"acme/platform"}},
+		}},
+	}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: "e1"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Provider", Name: "provider-gcp", UID: "provider"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "failed to unpack package",
+		Message:        "unauthorized",
+		Count:          3,
+	}
+
+	c := fake.NewFakeClientWithScheme(s, p, revision("abc123", 1, v1alpha1.PackageRevisionInactive), revision("def456", 2, v1alpha1.PackageRevisionActive), crd, lock, event)
+	d, err := Describe(context.Background(), c, p, &v1alpha1.ProviderRevisionList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &bytes.Buffer{}
+	if err := d.WriteText(b); err != nil {
+		t.Fatal(err)
+	}
+	want := `Name:              provider-gcp
+Kind:              Provider
+Package:           crossplane/provider-gcp:v2
+Current Revision:  def456
+Conditions:
+  TYPE   STATUS  REASON                         AGE        MESSAGE
+  Ready  True    Resource is available for use  <unknown>  <none>
+Revisions:
+  REVISION  NAME    IMAGE                       STATE     READY  AGE
+  1         abc123  crossplane/provider-gcp:v1  Inactive  True   <unknown>
+  2         def456  crossplane/provider-gcp:v2  Active    True   <unknown>
+CustomResourceDefinitions:
+  NAME                               REVISION  CONTROLLER
+  buckets.storage.gcp.crossplane.io  1         false
+  buckets.storage.gcp.crossplane.io  2         true
+Dependencies:
+  crossplane/provider-helm
+    crossplane/provider-kubernetes
+Dependents:
+  acme/platform
+    acme/app
+  acme/storage
+Events:
+  TYPE     REASON                    OBJECT                 AGE        COUNT  MESSAGE
+  Warning  failed to unpack package  Provider/provider-gcp  <unknown>  3      unauthorized
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("WriteText(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,290 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package doctor finds and repairs inconsistencies between packages, their
+// revisions, and the PackageLock.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/install"
+)
+
+// A Problem is an inconsistency found by a Check.
+type Problem struct {
+	// Object that has the problem, e.g. "PackageLock packages".
+	Object string
+
+	// Message explains the problem.
+	Message string
+
+	// Fix describes how the problem is repaired. It is empty if the problem
+	// cannot be repaired automatically.
+	Fix string
+
+	repair func(ctx context.Context, c client.Client) error
+}
+
+// Repair the problem.
+func (p Problem) Repair(ctx context.Context, c client.Client) error {
+	if p.repair == nil {
+		return errors.Errorf("%s cannot be repaired automatically", p.Object)
+	}
+	return p.repair(ctx, c)
+}
+
+// A Check finds problems in the cluster.
+type Check struct {
+	// Name of the check.
+	Name string
+
+	// Run the check, returning the problems it finds.
+	Run func(ctx context.Context, c client.Client) ([]Problem, error)
+}
+
+// Checks are the checks run by crank doctor, in order. A missing PackageLock
+// is checked first because the other checks read it.
+var Checks = []Check{
+	{Name: "PackageLock exists", Run: CheckLock},
+	{Name: "PackageLock entries have packages", Run: CheckLockEntries},
+	{Name: "Revisions have owners", Run: CheckRevisionOwners},
+	{Name: "CustomResourceDefinitions belong to one package", Run: CheckCRDOwners},
+}
+
+// CheckLock checks that the PackageLock exists. The package manager does
+// nothing until it does.
+func CheckLock(ctx context.Context, c client.Client) ([]Problem, error) {
+	err := c.Get(ctx, types.NamespacedName{Name: install.LockName}, &v1alpha1.PackageLock{})
+	if err == nil {
+		return nil, nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return nil, errors.Wrap(err, "cannot get PackageLock")
+	}
+	return []Problem{{
+		Object:  "PackageLock " + install.LockName,
+		Message: "does not exist, so packages are never installed",
+		Fix:     "create an empty PackageLock",
+		repair: func(ctx context.Context, c client.Client) error {
+			l := &v1alpha1.PackageLock{
+				ObjectMeta: metav1.ObjectMeta{Name: install.LockName},
+				Spec:       v1alpha1.PackageLockSpec{Packages: map[string]v1alpha1.PackageDependencies{}},
+			}
+			if err := c.Create(ctx, l); err != nil && !kerrors.IsAlreadyExists(err) {
+				return errors.Wrap(err, "cannot create PackageLock")
+			}
+			return nil
+		},
+	}}, nil
+}
+
+// CheckLockEntries checks that every entry of the PackageLock was made by a
+// package that still exists. A stale entry prevents the package from being
+// installed again, and may prevent its dependencies from being uninstalled.
+func CheckLockEntries(ctx context.Context, c client.Client) ([]Problem, error) {
+	lock := &v1alpha1.PackageLock{}
+	if err := c.Get(ctx, types.NamespacedName{Name: install.LockName}, lock); err != nil {
+		return nil, errors.Wrap(client.IgnoreNotFound(err), "cannot get PackageLock")
+	}
+	pkgs, err := packages(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	problems := []Problem{}
+	repos := make([]string, 0, len(lock.Spec.Packages))
+	for repo := range lock.Spec.Packages {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	for _, repo := range repos {
+		e := lock.Spec.Packages[repo]
+		_, provider := pkgs[key(v1alpha1.ProviderKind, e.Name)]
+		_, configuration := pkgs[key(v1alpha1.ConfigurationKind, e.Name)]
+		if provider || configuration {
+			continue
+		}
+		repo := repo
+		problems = append(problems, Problem{
+			Object:  "PackageLock " + install.LockName,
+			Message: fmt.Sprintf("entry %s refers to package %s, which does not exist", repo, e.Name),
+			Fix:     fmt.Sprintf("remove entry %s from the PackageLock", repo),
+			repair: func(ctx context.Context, c client.Client) error {
+				lock := &v1alpha1.PackageLock{}
+				if err := c.Get(ctx, types.NamespacedName{Name: install.LockName}, lock); err != nil {
+					return errors.Wrap(err, "cannot get PackageLock")
+				}
+				delete(lock.Spec.Packages, repo)
+				return errors.Wrap(c.Update(ctx, lock), "cannot update PackageLock")
+			},
+		})
+	}
+	return problems, nil
+}
+
+// CheckRevisionOwners checks that every revision is controlled by a package
+// that still exists. An orphaned revision is never activated or deactivated,
+// so it may keep control of CustomResourceDefinitions.
+func CheckRevisionOwners(ctx context.Context, c client.Client) ([]Problem, error) {
+	pkgs, err := packages(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	revs, err := revisions(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	problems := []Problem{}
+	for _, rev := range revs {
+		msg := "has no controlling package"
+		if ref := metav1.GetControllerOf(rev); ref != nil {
+			if p, ok := pkgs[key(ref.Kind, ref.Name)]; ok && p.GetUID() == ref.UID {
+				continue
+			}
+			msg = fmt.Sprintf("is controlled by %s %s, which does not exist", ref.Kind, ref.Name)
+		}
+		rev := rev
+		problems = append(problems, Problem{
+			Object:  install.KindOf(rev) + " " + rev.GetName(),
+			Message: msg,
+			Fix:     "delete the revision",
+			repair: func(ctx context.Context, c client.Client) error {
+				return errors.Wrapf(client.IgnoreNotFound(c.Delete(ctx, rev)), "cannot delete %s", rev.GetName())
+			},
+		})
+	}
+	return problems, nil
+}
+
+// CheckCRDOwners checks that every CustomResourceDefinition is owned by the
+// revisions of at most one package. Revisions of other packages are replaced
+// as owners whenever the CustomResourceDefinition is applied, so the packages
+// fight over it. References to revisions of packages other than that of the
+// controlling revision are removed when the problem is repaired.
+func CheckCRDOwners(ctx context.Context, c client.Client) ([]Problem, error) {
+	revs, err := revisions(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	pkgOf := map[types.UID]string{}
+	for _, rev := range revs {
+		pkgOf[rev.GetUID()] = rev.GetLabels()[install.LabelPackage]
+	}
+	crds := &apiextensions.CustomResourceDefinitionList{}
+	if err := c.List(ctx, crds); err != nil {
+		return nil, errors.Wrap(err, "cannot list CustomResourceDefinitions")
+	}
+	problems := []Problem{}
+	for i := range crds.Items {
+		crd := &crds.Items[i]
+		owners := []string{}
+		for _, ref := range crd.GetOwnerReferences() {
+			if p, ok := pkgOf[ref.UID]; ok && !contains(owners, p) {
+				owners = append(owners, p)
+			}
+		}
+		if len(owners) < 2 {
+			continue
+		}
+		sort.Strings(owners)
+		pr := Problem{
+			Object:  "CustomResourceDefinition " + crd.GetName(),
+			Message: fmt.Sprintf("is owned by revisions of packages %s", strings.Join(owners, ", ")),
+		}
+		if ref := metav1.GetControllerOf(crd); ref != nil && pkgOf[ref.UID] != "" {
+			keep := pkgOf[ref.UID]
+			name := crd.GetName()
+			pr.Fix = fmt.Sprintf("remove owner references to revisions of packages other than %s", keep)
+			pr.repair = func(ctx context.Context, c client.Client) error {
+				crd := &apiextensions.CustomResourceDefinition{}
+				if err := c.Get(ctx, types.NamespacedName{Name: name}, crd); err != nil {
+					return errors.Wrapf(err, "cannot get CustomResourceDefinition %s", name)
+				}
+				refs := []metav1.OwnerReference{}
+				for _, ref := range crd.GetOwnerReferences() {
+					if p, ok := pkgOf[ref.UID]; ok && p != keep {
+						continue
+					}
+					refs = append(refs, ref)
+				}
+				crd.SetOwnerReferences(refs)
+				return errors.Wrapf(c.Update(ctx, crd), "cannot update CustomResourceDefinition %s", name)
+			}
+		}
+		problems = append(problems, pr)
+	}
+	return problems, nil
+}
+
+// packages returns every Provider and Configuration, keyed by kind and name.
+func packages(ctx context.Context, c client.Client) (map[string]v1alpha1.Package, error) {
+	out := map[string]v1alpha1.Package{}
+	ps := &v1alpha1.ProviderList{}
+	if err := c.List(ctx, ps); err != nil {
+		return nil, errors.Wrap(err, "cannot list Providers")
+	}
+	for i := range ps.Items {
+		out[key(v1alpha1.ProviderKind, ps.Items[i].GetName())] = &ps.Items[i]
+	}
+	cs := &v1alpha1.ConfigurationList{}
+	if err := c.List(ctx, cs); err != nil {
+		return nil, errors.Wrap(err, "cannot list Configurations")
+	}
+	for i := range cs.Items {
+		out[key(v1alpha1.ConfigurationKind, cs.Items[i].GetName())] = &cs.Items[i]
+	}
+	return out, nil
+}
+
+// revisions returns every ProviderRevision and ConfigurationRevision, sorted
+// by name.
+func revisions(ctx context.Context, c client.Client) ([]v1alpha1.PackageRevision, error) {
+	out := []v1alpha1.PackageRevision{}
+	for _, l := range []v1alpha1.PackageRevisionList{&v1alpha1.ProviderRevisionList{}, &v1alpha1.ConfigurationRevisionList{}} {
+		if err := c.List(ctx, l); err != nil {
+			return nil, errors.Wrap(err, "cannot list PackageRevisions")
+		}
+		out = append(out, l.GetRevisions()...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].GetName() < out[j].GetName() })
+	return out, nil
+}
+
+// key returns the key of a package of the supplied kind and name.
+func key(kind, name string) string {
+	return kind + "/" + name
+}
+
+// contains returns true if s is one of ss.
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
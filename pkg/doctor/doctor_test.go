@@ -0,0 +1,226 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doctor
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hasheddan/crank/apis"
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/install"
+)
+
+func scheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := apiextensions.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func ref(kind, name string, uid types.UID, controller bool) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		Kind:       kind,
+		Name:       name,
+		UID:        uid,
+		Controller: &controller,
+	}
+}
+
+func revision(name, pkg string, refs ...metav1.OwnerReference) *v1alpha1.ProviderRevision {
+	return &v1alpha1.ProviderRevision{ObjectMeta: metav1.ObjectMeta{
+		Name:            name,
+		UID:             types.UID(name),
+		Labels:          map[string]string{install.LabelPackage: pkg},
+		OwnerReferences: refs,
+	}}
+}
+
+func crd(name string, refs ...metav1.OwnerReference) *apiextensions.CustomResourceDefinition {
+	return &apiextensions.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: name, OwnerReferences: refs}}
+}
+
+// cluster returns objects with one problem of each kind, other than a missing
+// PackageLock.
+func cluster() []runtime.Object {
+	return []runtime.Object{
+		&v1alpha1.Provider{ObjectMeta: metav1.ObjectMeta{Name: "provider-aws", UID: "aws"}},
+		&v1alpha1.Provider{ObjectMeta: metav1.ObjectMeta{Name: "provider-gcp", UID: "gcp"}},
+		&v1alpha1.PackageLock{
+			ObjectMeta: metav1.ObjectMeta{Name: install.LockName},
+			Spec: v1alpha1.PackageLockSpec{Packages: map[string]v1alpha1.PackageDependencies{
+				"crossplane/provider-aws":   {Name: "provider-aws", Image: "crossplane/provider-aws:v0.12.0"},
+				"crossplane/provider-azure": {Name: "provider-azure", Image: "crossplane/provider-azure:v0.12.0"},
+			}},
+		},
+		revision("aws-1", "provider-aws", ref(v1alpha1.ProviderKind, "provider-aws", "aws", true)),
+		revision("azure-1", "provider-azure", ref(v1alpha1.ProviderKind, "provider-azure", "azure", true)),
+		revision("gcp-1", "provider-gcp", ref(v1alpha1.ProviderKind, "provider-gcp", "gcp", true)),
+		revision("unowned", "provider-gcp"),
+		crd("buckets.example.org", ref(v1alpha1.ProviderRevisionKind, "aws-1", "aws-1", true), ref(v1alpha1.ProviderRevisionKind, "gcp-1", "gcp-1", false)),
+		crd("networks.example.org", ref(v1alpha1.ProviderRevisionKind, "azure-1", "azure-1", false), ref(v1alpha1.ProviderRevisionKind, "gcp-1", "gcp-1", false)),
+		crd("databases.example.org", ref(v1alpha1.ProviderRevisionKind, "gcp-1", "gcp-1", true)),
+	}
+}
+
+type problem struct {
+	Object  string
+	Message string
+	Fix     string
+}
+
+func TestChecks(t *testing.T) {
+	cases := map[string]struct {
+		objs []runtime.Object
+		want map[string][]problem
+	}{
+		"Healthy": {
+			objs: []runtime.Object{
+				&v1alpha1.PackageLock{ObjectMeta: metav1.ObjectMeta{Name: install.LockName}},
+			},
+			want: map[string][]problem{},
+		},
+		"MissingLock": {
+			want: map[string][]problem{
+				"PackageLock exists": {{
+					Object:  "PackageLock packages",
+					Message: "does not exist, so packages are never installed",
+					Fix:     "create an empty PackageLock",
+				}},
+			},
+		},
+		"Inconsistent": {
+			objs: cluster(),
+			want: map[string][]problem{
+				"PackageLock entries have packages": {{
+					Object:  "PackageLock packages",
+					Message: "entry crossplane/provider-azure refers to package provider-azure, which does not exist",
+					Fix:     "remove entry crossplane/provider-azure from the PackageLock",
+				}},
+				"Revisions have owners": {
+					{
+						Object:  "ProviderRevision azure-1",
+						Message: "is controlled by Provider provider-azure, which does not exist",
+						Fix:     "delete the revision",
+					},
+					{
+						Object:  "ProviderRevision unowned",
+						Message: "has no controlling package",
+						Fix:     "delete the revision",
+					},
+				},
+				"CustomResourceDefinitions belong to one package": {
+					{
+						Object:  "CustomResourceDefinition buckets.example.org",
+						Message: "is owned by revisions of packages provider-aws, provider-gcp",
+						Fix:     "remove owner references to revisions of packages other than provider-aws",
+					},
+					{
+						Object:  "CustomResourceDefinition networks.example.org",
+						Message: "is owned by revisions of packages provider-azure, provider-gcp",
+					},
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme(t), tc.objs...)
+			got := map[string][]problem{}
+			for _, check := range Checks {
+				ps, err := check.Run(context.Background(), c)
+				if err != nil {
+					t.Fatalf("%s: %v", check.Name, err)
+				}
+				for _, p := range ps {
+					got[check.Name] = append(got[check.Name], problem{Object: p.Object, Message: p.Message, Fix: p.Fix})
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Checks: -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRepair(t *testing.T) {
+	c := fake.NewFakeClientWithScheme(scheme(t), cluster()...)
+	ctx := context.Background()
+	if err := c.Delete(ctx, &v1alpha1.PackageLock{ObjectMeta: metav1.ObjectMeta{Name: install.LockName}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Checks are repaired in order, as crank doctor --fix does, because the
+	// other checks cannot run until the PackageLock exists.
+	remaining := []string{}
+	for _, check := range Checks {
+		ps, err := check.Run(ctx, c)
+		if err != nil {
+			t.Fatalf("%s: %v", check.Name, err)
+		}
+		for _, p := range ps {
+			if p.Fix == "" {
+				remaining = append(remaining, p.Object)
+				continue
+			}
+			if err := p.Repair(ctx, c); err != nil {
+				t.Fatalf("%s: Repair(...): %v", p.Object, err)
+			}
+		}
+	}
+	// Deleting the orphaned azure-1 revision leaves networks.example.org owned
+	// by the revisions of one package.
+	if len(remaining) > 0 {
+		t.Errorf("Repair(...): unrepairable problems: %v", remaining)
+	}
+
+	revs := &v1alpha1.ProviderRevisionList{}
+	if err := c.List(ctx, revs); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, r := range revs.Items {
+		names = append(names, r.GetName())
+	}
+	sort.Strings(names)
+	if diff := cmp.Diff([]string{"aws-1", "gcp-1"}, names); diff != "" {
+		t.Errorf("Repair(...): revisions: -want, +got:\n%s", diff)
+	}
+
+	got := &apiextensions.CustomResourceDefinition{}
+	if err := c.Get(ctx, client.ObjectKey{Name: "buckets.example.org"}, got); err != nil {
+		t.Fatal(err)
+	}
+	want := []metav1.OwnerReference{ref(v1alpha1.ProviderRevisionKind, "aws-1", "aws-1", true)}
+	if diff := cmp.Diff(want, got.GetOwnerReferences()); diff != "" {
+		t.Errorf("Repair(...): owner references: -want, +got:\n%s", diff)
+	}
+}
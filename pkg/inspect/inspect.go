@@ -0,0 +1,241 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package inspect summarizes the contents of Crossplane packages.
+package inspect
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ghodss/yaml"
+	appsv1 "k8s.io/api/apps/v1"
+
+	"github.com/hasheddan/crank/pkg/parser"
+)
+
+// A Report summarizes the contents of a package.
+type Report struct {
+	// Source is the image or directory the package was read from.
+	Source string `json:"source"`
+
+	// Digest of the package image. Empty if the package was read from a
+	// directory.
+	Digest string `json:"digest,omitempty"`
+
+	Name         string   `json:"name"`
+	Type         string   `json:"type,omitempty"`
+	Dependencies []string `json:"dependencies"`
+
+	CustomResourceDefinitions  []CustomResourceDefinition  `json:"crds"`
+	InfrastructureDefinitions  []InfrastructureDefinition  `json:"infrastructureDefinitions"`
+	InfrastructurePublications []InfrastructurePublication `json:"infrastructurePublications"`
+	Compositions               []Composition               `json:"compositions"`
+
+	// ControllerImages are the images run by Deployments in the package.
+	ControllerImages []string `json:"controllerImages"`
+}
+
+// A CustomResourceDefinition in a package.
+type CustomResourceDefinition struct {
+	Name     string   `json:"name"`
+	Kind     string   `json:"kind"`
+	Group    string   `json:"group"`
+	Scope    string   `json:"scope"`
+	Versions []string `json:"versions"`
+}
+
+// An InfrastructureDefinition in a package.
+type InfrastructureDefinition struct {
+	Name                 string   `json:"name"`
+	Kind                 string   `json:"kind"`
+	Group                string   `json:"group"`
+	Version              string   `json:"version"`
+	ConnectionSecretKeys []string `json:"connectionSecretKeys,omitempty"`
+}
+
+// An InfrastructurePublication in a package.
+type InfrastructurePublication struct {
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+}
+
+// A Composition in a package.
+type Composition struct {
+	Name       string `json:"name"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Resources  int    `json:"resources"`
+}
+
+// Inspect returns a Report of the supplied package, read from source.
+func Inspect(source string, pkg *parser.Package) *Report {
+	r := &Report{
+		Source:                     source,
+		Name:                       pkg.Name,
+		Dependencies:               []string{},
+		CustomResourceDefinitions:  []CustomResourceDefinition{},
+		InfrastructureDefinitions:  []InfrastructureDefinition{},
+		InfrastructurePublications: []InfrastructurePublication{},
+		Compositions:               []Composition{},
+		ControllerImages:           []string{},
+	}
+	if pkg.Meta != nil {
+		r.Type = pkg.Meta.Kind
+	}
+	for _, d := range pkg.Dependencies {
+		r.Dependencies = append(r.Dependencies, d.Package)
+	}
+
+	for _, crd := range pkg.CustomResourceDefinitions {
+		versions := []string{}
+		for _, v := range crd.Spec.Versions {
+			versions = append(versions, v.Name)
+		}
+		if len(versions) == 0 && crd.Spec.Version != "" {
+			versions = append(versions, crd.Spec.Version)
+		}
+		r.CustomResourceDefinitions = append(r.CustomResourceDefinitions, CustomResourceDefinition{
+			Name:     crd.GetName(),
+			Kind:     crd.Spec.Names.Kind,
+			Group:    crd.Spec.Group,
+			Scope:    string(crd.Spec.Scope),
+			Versions: versions,
+		})
+	}
+	sort.Slice(r.CustomResourceDefinitions, func(i, j int) bool {
+		return r.CustomResourceDefinitions[i].Name < r.CustomResourceDefinitions[j].Name
+	})
+
+	for _, d := range pkg.InfrastructureDefinitions {
+		r.InfrastructureDefinitions = append(r.InfrastructureDefinitions, InfrastructureDefinition{
+			Name:                 d.GetName(),
+			Kind:                 d.Spec.CRDSpecTemplate.Names.Kind,
+			Group:                d.Spec.CRDSpecTemplate.Group,
+			Version:              d.Spec.CRDSpecTemplate.Version,
+			ConnectionSecretKeys: d.Spec.ConnectionSecretKeys,
+		})
+	}
+	sort.Slice(r.InfrastructureDefinitions, func(i, j int) bool {
+		return r.InfrastructureDefinitions[i].Name < r.InfrastructureDefinitions[j].Name
+	})
+
+	for _, p := range pkg.InfrastructurePublications {
+		r.InfrastructurePublications = append(r.InfrastructurePublications, InfrastructurePublication{
+			Name:       p.GetName(),
+			Definition: p.Spec.InfrastructureDefinitionReference.Name,
+		})
+	}
+	sort.Slice(r.InfrastructurePublications, func(i, j int) bool {
+		return r.InfrastructurePublications[i].Name < r.InfrastructurePublications[j].Name
+	})
+
+	for _, c := range pkg.Compositions {
+		r.Compositions = append(r.Compositions, Composition{
+			Name:       c.GetName(),
+			APIVersion: c.Spec.From.APIVersion,
+			Kind:       c.Spec.From.Kind,
+			Resources:  len(c.Spec.To),
+		})
+	}
+	sort.Slice(r.Compositions, func(i, j int) bool { return r.Compositions[i].Name < r.Compositions[j].Name })
+
+	seen := map[string]bool{}
+	for path, o := range pkg.Objects {
+		if o.Kind != "Deployment" {
+			continue
+		}
+		d := &appsv1.Deployment{}
+		if err := yaml.Unmarshal(pkg.Files[path], d); err != nil {
+			continue
+		}
+		for _, c := range d.Spec.Template.Spec.Containers {
+			if c.Image != "" && !seen[c.Image] {
+				seen[c.Image] = true
+				r.ControllerImages = append(r.ControllerImages, c.Image)
+			}
+		}
+	}
+	sort.Strings(r.ControllerImages)
+	return r
+}
+
+// WriteText writes the Report in a human readable format.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	line := func(indent int, cells ...string) {
+		fmt.Fprintln(tw, strings.Repeat("  ", indent)+strings.Join(cells, "\t")) // nolint:errcheck
+	}
+	or := func(s string) string {
+		if s == "" {
+			return "<none>"
+		}
+		return s
+	}
+	section := func(title string, n int, header []string, row func(i int) []string) {
+		line(0, fmt.Sprintf("%s (%d):", title, n))
+		if n == 0 {
+			line(1, "<none>")
+			return
+		}
+		line(1, header...)
+		for i := 0; i < n; i++ {
+			line(1, row(i)...)
+		}
+	}
+
+	line(0, "Source:", r.Source)
+	line(0, "Digest:", or(r.Digest))
+	line(0, "Name:", or(r.Name))
+	line(0, "Type:", or(r.Type))
+
+	line(0, fmt.Sprintf("Dependencies (%d):", len(r.Dependencies)))
+	if len(r.Dependencies) == 0 {
+		line(1, "<none>")
+	}
+	for _, d := range r.Dependencies {
+		line(1, d)
+	}
+
+	section("CustomResourceDefinitions", len(r.CustomResourceDefinitions), []string{"NAME", "KIND", "SCOPE", "VERSIONS"}, func(i int) []string {
+		c := r.CustomResourceDefinitions[i]
+		return []string{c.Name, c.Kind, or(c.Scope), or(strings.Join(c.Versions, ","))}
+	})
+	section("InfrastructureDefinitions", len(r.InfrastructureDefinitions), []string{"NAME", "KIND", "VERSION", "CONNECTION KEYS"}, func(i int) []string {
+		d := r.InfrastructureDefinitions[i]
+		return []string{d.Name, d.Kind, d.Group + "/" + d.Version, or(strings.Join(d.ConnectionSecretKeys, ","))}
+	})
+	section("InfrastructurePublications", len(r.InfrastructurePublications), []string{"NAME", "DEFINITION"}, func(i int) []string {
+		p := r.InfrastructurePublications[i]
+		return []string{p.Name, p.Definition}
+	})
+	section("Compositions", len(r.Compositions), []string{"NAME", "API VERSION", "KIND", "RESOURCES"}, func(i int) []string {
+		c := r.Compositions[i]
+		return []string{c.Name, c.APIVersion, c.Kind, fmt.Sprint(c.Resources)}
+	})
+
+	line(0, fmt.Sprintf("Controller Images (%d):", len(r.ControllerImages)))
+	if len(r.ControllerImages) == 0 {
+		line(1, "<none>")
+	}
+	for _, i := range r.ControllerImages {
+		line(1, i)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspect
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/pkg/parser"
+)
+
+var files = map[string]string{
+	"/pkg/crossplane.yaml": `apiVersion: pkg.crossplane.io/v1alpha1
+kind: Provider
+metadata:
+  name: provider-example
+spec:
+  dependsOn:
+  - package: crossplane/provider-helm
+    version: v0.2.0
+`,
+	"/pkg/crd.yaml": `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: buckets.storage.example.org
+spec:
+  group: storage.example.org
+  names:
+    kind: Bucket
+    plural: buckets
+  scope: Cluster
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: false
+  - name: v1beta1
+    served: true
+    storage: true
+`,
+	"/pkg/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: provider-example
+spec:
+  template:
+    spec:
+      containers:
+      - name: controller
+        image: example/provider-example-controller:v0.1.0
+`,
+}
+
+func TestInspect(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for path, content := range files {
+		if err := afero.WriteFile(fs, path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pkg, err := parser.NewParser(fs).ParsePackage("/pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := Inspect("example/provider-example:v0.1.0", pkg)
+	r.Digest = "sha256:abc"
+
+	want := &Report{
+		Source:       "example/provider-example:v0.1.0",
+		Digest:       "sha256:abc",
+		Name:         "provider-example",
+		Type:         "Provider",
+		Dependencies: []string{"crossplane/provider-helm:v0.2.0"},
+		CustomResourceDefinitions: []CustomResourceDefinition{{
+			Name:     "buckets.storage.example.org",
+			Kind:     "Bucket",
+			Group:    "storage.example.org",
+			Scope:    "Cluster",
+			Versions: []string{"v1alpha1", "v1beta1"},
+		}},
+		InfrastructureDefinitions:  []InfrastructureDefinition{},
+		InfrastructurePublications: []InfrastructurePublication{},
+		Compositions:               []Composition{},
+		ControllerImages:           []string{"example/provider-example-controller:v0.1.0"},
+	}
+	if diff := cmp.Diff(want, r); diff != "" {
+		t.Errorf("Inspect(...): -want, +got:\n%s", diff)
+	}
+
+	b := &bytes.Buffer{}
+	if err := r.WriteText(b); err != nil {
+		t.Fatal(err)
+	}
+	wantText := `Source:  example/provider-example:v0.1.0
+Digest:  sha256:abc
+Name:    provider-example
+Type:    Provider
+Dependencies (1):
+  crossplane/provider-helm:v0.2.0
+CustomResourceDefinitions (1):
+  NAME                         KIND    SCOPE    VERSIONS
+  buckets.storage.example.org  Bucket  Cluster  v1alpha1,v1beta1
+InfrastructureDefinitions (0):
+  <none>
+InfrastructurePublications (0):
+  <none>
+Compositions (0):
+  <none>
+Controller Images (1):
+  example/provider-example-controller:v0.1.0
+`
+	if diff := cmp.Diff(wantText, b.String()); diff != "" {
+		t.Errorf("WriteText(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package install installs and uninstalls Crossplane packages.
+package install
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/controller/manager"
+	"github.com/hasheddan/crank/pkg/prompt"
+)
+
+// LockName is the name of the PackageLock shared by all packages.
+const LockName = "packages"
+
+// DefaultInterval is the default interval at which Wait polls the API server.
+const DefaultInterval = 2 * time.Second
+
+// Options configure a package to be installed.
+type Options struct {
+	// Name of the package object. Derived from the package if empty.
+	Name string
+
+	// Package is the image of the package.
+	Package string
+
+	// PullSecrets are the names of secrets used to pull the package image.
+	PullSecrets []string
+
+	// PullPolicy is the image pull policy of the package.
+	PullPolicy corev1.PullPolicy
+}
+
+func (o Options) name() string {
+	if o.Name != "" {
+		return o.Name
+	}
+	return NameFor(o.Package)
+}
+
+func (o Options) pullSecrets() []corev1.LocalObjectReference {
+	if len(o.PullSecrets) == 0 {
+		return nil
+	}
+	refs := make([]corev1.LocalObjectReference, len(o.PullSecrets))
+	for i, s := range o.PullSecrets {
+		refs[i] = corev1.LocalObjectReference{Name: s}
+	}
+	return refs
+}
+
+// NewProvider returns a Provider with the supplied options.
+func NewProvider(o Options) *v1alpha1.Provider {
+	p := &v1alpha1.Provider{}
+	p.SetGroupVersionKind(v1alpha1.ProviderGroupVersionKind)
+	p.SetName(o.name())
+	p.Spec.Package = o.Package
+	p.Spec.ImagePullSecrets = o.pullSecrets()
+	p.Spec.ImagePullPolicy = o.PullPolicy
+	return p
+}
+
+// NewConfiguration returns a Configuration with the supplied options.
+func NewConfiguration(o Options) *v1alpha1.Configuration {
+	c := &v1alpha1.Configuration{}
+	c.SetGroupVersionKind(v1alpha1.ConfigurationGroupVersionKind)
+	c.SetName(o.name())
+	c.Spec.Package = o.Package
+	c.Spec.ImagePullSecrets = o.pullSecrets()
+	c.Spec.ImagePullPolicy = o.PullPolicy
+	return c
+}
+
+// ValidatePullPolicy returns an error if p is not a valid image pull policy.
+// An empty policy is valid.
+func ValidatePullPolicy(p string) error {
+	switch corev1.PullPolicy(p) {
+	case "", corev1.PullAlways, corev1.PullIfNotPresent, corev1.PullNever:
+		return nil
+	}
+	return errors.Errorf("invalid pull policy %q: must be one of %s, %s, %s", p, corev1.PullAlways, corev1.PullIfNotPresent, corev1.PullNever)
+}
+
+// NameFor returns the name of the object of a package installed from the
+// supplied image, e.g. crossplane-provider-aws for
+// crossplane/provider-aws:v0.12.0.
+func NameFor(image string) string {
+	repo := repository(image)
+	if ref, err := name.ParseReference(image); err == nil {
+		repo = strings.TrimPrefix(ref.Context().RepositoryStr(), "library/")
+	}
+	return strings.ToLower(strings.NewReplacer("/", "-", "_", "-", ".", "-").Replace(repo))
+}
+
+// repository returns the image without its tag or digest.
+func repository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// Dependents returns the names of the packages in the lock that depend on the
+// package installed from the supplied image, sorted by name.
+func Dependents(lock *v1alpha1.PackageLock, image string) []string {
+	repo := repository(image)
+	dependents := []string{}
+	for _, p := range lock.Spec.Packages {
+		if repository(p.Image) == repo {
+			continue
+		}
+		for _, d := range p.Dependencies {
+			if repository(d) == repo {
+				dependents = append(dependents, p.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// Uninstall deletes the supplied package, unless other packages depend on it.
+func Uninstall(ctx context.Context, c client.Client, p v1alpha1.Package) error {
+	lock := &v1alpha1.PackageLock{}
+	if err := c.Get(ctx, types.NamespacedName{Name: LockName}, lock); client.IgnoreNotFound(err) != nil {
+		return errors.Wrap(err, "cannot get PackageLock")
+	}
+	if d := Dependents(lock, p.GetSource()); len(d) > 0 {
+		return errors.Errorf("cannot uninstall %s: required by %s", p.GetName(), strings.Join(d, ", "))
+	}
+	return errors.Wrapf(c.Delete(ctx, p), "cannot delete %s", p.GetName())
+}
+
+// A waiter reports the progress of a package until it is ready.
+type waiter struct {
+	client client.Client
+	out    io.Writer
+
+	conditions map[string]runtimev1alpha1.Condition
+	events     map[types.UID]int32
+}
+
+// Wait polls the supplied package and its current revision, whose type is
+// that of rev, until both are ready. Changes to their Ready conditions and
+// events are written to w. It returns an error if the package cannot become
+// ready, or if ctx is done first.
+func Wait(ctx context.Context, c client.Client, w io.Writer, p v1alpha1.Package, rev v1alpha1.PackageRevision, interval time.Duration) error {
+	wt := &waiter{client: c, out: w, conditions: map[string]runtimev1alpha1.Condition{}, events: map[types.UID]int32{}}
+	for {
+		ready, err := wt.poll(ctx, p, rev)
+		if err != nil || ready {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("timed out waiting for %s to become ready", p.GetName())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// poll reports the progress of the package and returns true if it is ready.
+func (w *waiter) poll(ctx context.Context, p v1alpha1.Package, rev v1alpha1.PackageRevision) (bool, error) {
+	if err := w.client.Get(ctx, types.NamespacedName{Name: p.GetName()}, p); err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, errors.Errorf("%s was deleted", p.GetName())
+		}
+		return false, errors.Wrapf(err, "cannot get %s", p.GetName())
+	}
+	w.condition(KindOf(p), p.GetName(), p.GetCondition(runtimev1alpha1.TypeReady))
+	if err := w.report(ctx, p); err != nil {
+		return false, err
+	}
+
+	if p.GetCurrentRevision() == "" {
+		return false, nil
+	}
+	if err := w.client.Get(ctx, types.NamespacedName{Name: p.GetCurrentRevision()}, rev); err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "cannot get revision %s", p.GetCurrentRevision())
+	}
+	w.condition(KindOf(rev), rev.GetName(), rev.GetCondition(runtimev1alpha1.TypeReady))
+	if err := w.report(ctx, rev); err != nil {
+		return false, err
+	}
+
+	return p.GetCondition(runtimev1alpha1.TypeReady).Status == corev1.ConditionTrue &&
+		rev.GetCondition(runtimev1alpha1.TypeReady).Status == corev1.ConditionTrue, nil
+}
+
+// Events returns the events of the supplied object, oldest first.
+func Events(ctx context.Context, c client.Client, o resource.Object) ([]corev1.Event, error) {
+	kind := KindOf(o)
+	l := &corev1.EventList{}
+	if err := c.List(ctx, l, client.MatchingFields{"involvedObject.name": o.GetName(), "involvedObject.kind": kind}); err != nil {
+		return nil, errors.Wrapf(err, "cannot list events of %s", o.GetName())
+	}
+	events := []corev1.Event{}
+	for _, e := range l.Items {
+		// The API server filters events by field, but not every client does.
+		if e.InvolvedObject.Kind != kind || e.InvolvedObject.Name != o.GetName() {
+			continue
+		}
+		if e.InvolvedObject.UID != "" && o.GetUID() != "" && e.InvolvedObject.UID != o.GetUID() {
+			continue
+		}
+		events = append(events, e)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].LastTimestamp.Before(&events[j].LastTimestamp) })
+	return events, nil
+}
+
+// KindOf returns the kind of the supplied object. The type metadata of objects
+// read by a client is not always populated.
+func KindOf(o resource.Object) string {
+	return reflect.TypeOf(o).Elem().Name()
+}
+
+// condition reports the Ready condition of an object if it has changed.
+func (w *waiter) condition(kind, name string, c runtimev1alpha1.Condition) {
+	key := kind + "/" + name
+	if last, ok := w.conditions[key]; ok && last.Equal(c) {
+		return
+	}
+	w.conditions[key] = c
+	if c.Status == corev1.ConditionUnknown && c.Reason == "" {
+		// The object has no Ready condition yet.
+		return
+	}
+	msg := fmt.Sprintf("%s %s: Ready=%s", kind, name, c.Status)
+	if c.Reason != "" {
+		msg += fmt.Sprintf(" (%s)", c.Reason)
+	}
+	if c.Message != "" {
+		msg += ": " + c.Message
+	}
+	fmt.Fprintln(w.out, prompt.FmtInfo(msg)) // nolint:errcheck
+}
+
+// terminal are the reasons of warning events after which a package cannot
+// become ready without being changed. Missing dependencies are not terminal,
+// because they may yet be installed.
+var terminal = map[event.Reason]bool{
+	manager.ReasonBuildState:         true,
+	manager.ReasonUnpack:             true,
+	manager.ReasonAddNode:            true,
+	manager.ReasonCyclicalDependency: true,
+}
+
+// report reports events of the supplied object that have not yet been
+// reported. It returns an error if an event indicates the object cannot become
+// ready.
+func (w *waiter) report(ctx context.Context, o resource.Object) error {
+	events, err := Events(ctx, w.client, o)
+	if err != nil {
+		return err
+	}
+	kind := KindOf(o)
+	for _, e := range events {
+		if count, ok := w.events[e.UID]; ok && count >= e.Count {
+			continue
+		}
+		w.events[e.UID] = e.Count
+		format := prompt.FmtInfo
+		if e.Type == corev1.EventTypeWarning {
+			format = prompt.FmtWarning
+		}
+		fmt.Fprintln(w.out, format(fmt.Sprintf("%s %s: %s: %s", kind, o.GetName(), e.Reason, e.Message))) // nolint:errcheck
+		if e.Type == corev1.EventTypeWarning && terminal[event.Reason(e.Reason)] {
+			return errors.Errorf("%s cannot become ready: %s", o.GetName(), e.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hasheddan/crank/apis"
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/controller/manager"
+	"github.com/hasheddan/crank/pkg/prompt"
+)
+
+func init() {
+	prompt.SetColor(false)
+}
+
+func scheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := apiextensions.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestNameFor(t *testing.T) {
+	cases := map[string]string{
+		"crossplane/provider-aws:v0.12.0":                                    "crossplane-provider-aws",
+		"registry.example.org/acme/platform_ref:v1":                          "acme-platform-ref",
+		"localhost:5000/acme/provider-gcp@sha256:" + strings.Repeat("a", 64): "acme-provider-gcp",
+		"nginx": "nginx",
+	}
+	for image, want := range cases {
+		if got := NameFor(image); got != want {
+			t.Errorf("NameFor(%q): want %q, got %q", image, want, got)
+		}
+	}
+}
+
+func TestValidatePullPolicy(t *testing.T) {
+	for _, p := range []string{"", "Always", "IfNotPresent", "Never"} {
+		if err := ValidatePullPolicy(p); err != nil {
+			t.Errorf("ValidatePullPolicy(%q): %s", p, err)
+		}
+	}
+	if err := ValidatePullPolicy("Sometimes"); err == nil {
+		t.Errorf("ValidatePullPolicy(\"Sometimes\"): want error, got nil")
+	}
+}
+
+func lock() *v1alpha1.PackageLock {
+	return &v1alpha1.PackageLock{
+		ObjectMeta: metav1.ObjectMeta{Name: LockName},
+		Spec: v1alpha1.PackageLockSpec{Packages: map[string]v1alpha1.PackageDependencies{
+			"crossplane/provider-gcp": {Name: "provider-gcp", Image: "crossplane/provider-gcp:v0.12.0"},
+			"acme/platform":           {Name: "platform", Image: "acme/platform:v1", Dependencies: []string{"crossplane/provider-gcp", "acme/network"}},
+			"acme/network":            {Name: "network", Image: "acme/network:v1", Dependencies: []string{"crossplane/provider-gcp"}},
+		}},
+	}
+}
+
+func TestDependents(t *testing.T) {
+	cases := map[string]struct {
+		image string
+		want  []string
+	}{
+		"Shared":   {image: "crossplane/provider-gcp:v0.11.0", want: []string{"network", "platform"}},
+		"Single":   {image: "acme/network:v1", want: []string{"platform"}},
+		"Leaf":     {image: "acme/platform:v1", want: []string{}},
+		"Unlocked": {image: "acme/other", want: []string{}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, Dependents(lock(), tc.image)); diff != "" {
+				t.Errorf("Dependents(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUninstall(t *testing.T) {
+	provider := func(name, image string) *v1alpha1.Provider {
+		return NewProvider(Options{Name: name, Package: image})
+	}
+	cases := map[string]struct {
+		p       *v1alpha1.Provider
+		err     string
+		deleted bool
+	}{
+		"Blocked": {
+			p:   provider("provider-gcp", "crossplane/provider-gcp:v0.12.0"),
+			err: "cannot uninstall provider-gcp: required by network, platform",
+		},
+		"Deleted": {
+			p:       provider("platform", "acme/platform:v1"),
+			deleted: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme(t), lock(), tc.p)
+			err := Uninstall(context.Background(), c, tc.p)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tc.err {
+				t.Errorf("Uninstall(...): want error %q, got %q", tc.err, got)
+			}
+			err = c.Get(context.Background(), types.NamespacedName{Name: tc.p.GetName()}, &v1alpha1.Provider{})
+			if deleted := kerrors.IsNotFound(err); deleted != tc.deleted {
+				t.Errorf("Uninstall(...): want deleted %t, got %t", tc.deleted, deleted)
+			}
+		})
+	}
+}
+
+func TestWait(t *testing.T) {
+	ready := runtimev1alpha1.Available()
+	ready.LastTransitionTime = metav1.Time{}
+
+	provider := func(rev string, c ...runtimev1alpha1.Condition) *v1alpha1.Provider {
+		p := NewProvider(Options{Name: "provider-gcp", Package: "crossplane/provider-gcp:v0.12.0"})
+		p.SetUID("uid")
+		p.SetCurrentRevision(rev)
+		p.SetConditions(c...)
+		return p
+	}
+	revision := func(c ...runtimev1alpha1.Condition) *v1alpha1.ProviderRevision {
+		r := &v1alpha1.ProviderRevision{ObjectMeta: metav1.ObjectMeta{Name: "abc123"}}
+		r.SetConditions(c...)
+		return r
+	}
+	// msgs are the messages of events, by reason.
+	msgs := map[event.Reason]string{
+		manager.ReasonDependencies:       "to node crossplane/provider-aws does not exist",
+		manager.ReasonCyclicalDependency: "to node crossplane/provider-aws does not exist",
+		manager.ReasonUnpack:             "MANIFEST_UNKNOWN: manifest unknown",
+		manager.ReasonBuildState:         "dependency cycle",
+		manager.ReasonAddNode:            "node already exists",
+	}
+	newEvent := func(name string, reason event.Reason, uid types.UID) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID(name)},
+			InvolvedObject: corev1.ObjectReference{Kind: "Provider", Name: "provider-gcp", UID: uid},
+			Type:           corev1.EventTypeWarning,
+			Reason:         string(reason),
+			Message:        msgs[reason],
+			Count:          1,
+		}
+	}
+
+	cases := map[string]struct {
+		objects []runtime.Object
+		timeout time.Duration
+		want    string
+		err     string
+	}{
+		"Ready": {
+			objects: []runtime.Object{provider("abc123", ready), revision(ready), newEvent("e1", manager.ReasonDependencies, "uid"), newEvent("e2", manager.ReasonUnpack, "other")},
+			want: "Provider provider-gcp: Ready=True (Resource is available for use)\n" +
+				"Provider provider-gcp: failed adding package dependencies: to node crossplane/provider-aws does not exist\n" +
+				"ProviderRevision abc123: Ready=True (Resource is available for use)\n",
+		},
+		"CyclicalDependency": {
+			objects: []runtime.Object{provider(""), newEvent("e1", manager.ReasonCyclicalDependency, "uid")},
+			want:    "Provider provider-gcp: cyclical dependency: to node crossplane/provider-aws does not exist\n",
+			err:     "provider-gcp cannot become ready: to node crossplane/provider-aws does not exist",
+		},
+		"Unpack": {
+			objects: []runtime.Object{provider(""), newEvent("e1", manager.ReasonUnpack, "uid")},
+			want:    "Provider provider-gcp: failed to unpack package: MANIFEST_UNKNOWN: manifest unknown\n",
+			err:     "provider-gcp cannot become ready: MANIFEST_UNKNOWN: manifest unknown",
+		},
+		"BuildState": {
+			objects: []runtime.Object{provider(""), newEvent("e1", manager.ReasonBuildState, "uid")},
+			want:    "Provider provider-gcp: failed building state: dependency cycle\n",
+			err:     "provider-gcp cannot become ready: dependency cycle",
+		},
+		"AddNode": {
+			objects: []runtime.Object{provider(""), newEvent("e1", manager.ReasonAddNode, "uid")},
+			want:    "Provider provider-gcp: failed adding node: node already exists\n",
+			err:     "provider-gcp cannot become ready: node already exists",
+		},
+		"TimedOut": {
+			objects: []runtime.Object{provider("abc123", ready)},
+			timeout: 10 * time.Millisecond,
+			want:    "Provider provider-gcp: Ready=True (Resource is available for use)\n",
+			err:     "timed out waiting for provider-gcp to become ready",
+		},
+		"Deleted": {
+			err: "provider-gcp was deleted",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme(t), tc.objects...)
+			ctx := context.Background()
+			if tc.timeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+			b := &bytes.Buffer{}
+			err := Wait(ctx, c, b, provider(""), &v1alpha1.ProviderRevision{}, time.Millisecond)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tc.err {
+				t.Errorf("Wait(...): want error %q, got %q", tc.err, got)
+			}
+			if diff := cmp.Diff(tc.want, b.String()); diff != "" {
+				t.Errorf("Wait(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/prompt"
+)
+
+// LabelPackage is the label of a package revision whose value is the name of
+// its package.
+const LabelPackage = "crank.crossplane.io/package"
+
+// Revisions returns the revisions of the supplied package, ordered by revision
+// number. The type of the revisions is determined by l.
+func Revisions(ctx context.Context, c client.Client, p v1alpha1.Package, l v1alpha1.PackageRevisionList) ([]v1alpha1.PackageRevision, error) {
+	if err := c.List(ctx, l, client.MatchingLabels{LabelPackage: p.GetName()}); err != nil {
+		return nil, errors.Wrapf(err, "cannot list revisions of %s", p.GetName())
+	}
+	revs := l.GetRevisions()
+	sort.SliceStable(revs, func(i, j int) bool {
+		if revs[i].GetRevision() != revs[j].GetRevision() {
+			return revs[i].GetRevision() < revs[j].GetRevision()
+		}
+		ti, tj := revs[i].GetCreationTimestamp(), revs[j].GetCreationTimestamp()
+		return ti.Before(&tj)
+	})
+	return revs, nil
+}
+
+// FindRevision returns the revision with the supplied name or revision number.
+func FindRevision(revs []v1alpha1.PackageRevision, id string) (v1alpha1.PackageRevision, error) {
+	for _, r := range revs {
+		if r.GetName() == id {
+			return r, nil
+		}
+	}
+	if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+		for _, r := range revs {
+			if r.GetRevision() == n {
+				return r, nil
+			}
+		}
+	}
+	return nil, errors.Errorf("revision %s not found", id)
+}
+
+// Active returns the active revision, or nil if no revision is active.
+func Active(revs []v1alpha1.PackageRevision) v1alpha1.PackageRevision {
+	for _, r := range revs {
+		if r.GetDesiredState() == v1alpha1.PackageRevisionActive {
+			return r
+		}
+	}
+	return nil
+}
+
+// Previous returns the revision to roll back to from the active revision: the
+// latest revision older than it.
+func Previous(revs []v1alpha1.PackageRevision) (v1alpha1.PackageRevision, error) {
+	a := Active(revs)
+	if a == nil {
+		return nil, errors.New("no revision is active")
+	}
+	var prev v1alpha1.PackageRevision
+	for _, r := range revs {
+		if r.GetName() == a.GetName() || r.GetRevision() > a.GetRevision() {
+			continue
+		}
+		if prev == nil || r.GetRevision() >= prev.GetRevision() {
+			prev = r
+		}
+	}
+	if prev == nil {
+		return nil, errors.Errorf("revision %d is the oldest revision", a.GetRevision())
+	}
+	return prev, nil
+}
+
+// Activate makes target the only active revision of the supplied package, and
+// makes the package's source that of target. Other revisions are deactivated
+// before target is activated, so that at most one revision is ever active. If
+// a revision or the package cannot be updated target is deactivated again and
+// the revisions that were deactivated are reactivated.
+func Activate(ctx context.Context, c client.Client, p v1alpha1.Package, revs []v1alpha1.PackageRevision, target v1alpha1.PackageRevision) error {
+	deactivated := []v1alpha1.PackageRevision{}
+	activated := false
+	restore := func(err error) error {
+		if activated {
+			target.SetDesiredState(v1alpha1.PackageRevisionInactive)
+			if rerr := c.Update(ctx, target); rerr != nil {
+				return errors.Wrapf(err, "cannot deactivate revision %s after failure: %s", target.GetName(), rerr)
+			}
+		}
+		for _, r := range deactivated {
+			r.SetDesiredState(v1alpha1.PackageRevisionActive)
+			if rerr := c.Update(ctx, r); rerr != nil {
+				return errors.Wrapf(err, "cannot reactivate revision %s after failure: %s", r.GetName(), rerr)
+			}
+		}
+		return err
+	}
+	for _, r := range revs {
+		if r.GetName() == target.GetName() || r.GetDesiredState() != v1alpha1.PackageRevisionActive {
+			continue
+		}
+		r.SetDesiredState(v1alpha1.PackageRevisionInactive)
+		if err := c.Update(ctx, r); err != nil {
+			r.SetDesiredState(v1alpha1.PackageRevisionActive)
+			return restore(errors.Wrapf(err, "cannot deactivate revision %s", r.GetName()))
+		}
+		deactivated = append(deactivated, r)
+	}
+	if target.GetDesiredState() != v1alpha1.PackageRevisionActive {
+		target.SetDesiredState(v1alpha1.PackageRevisionActive)
+		if err := c.Update(ctx, target); err != nil {
+			target.SetDesiredState(v1alpha1.PackageRevisionInactive)
+			return restore(errors.Wrapf(err, "cannot activate revision %s", target.GetName()))
+		}
+		activated = true
+	}
+	if p.GetSource() != target.GetSource() {
+		source := p.GetSource()
+		p.SetSource(target.GetSource())
+		if err := c.Update(ctx, p); err != nil {
+			p.SetSource(source)
+			return restore(errors.Wrapf(err, "cannot update source of %s", p.GetName()))
+		}
+	}
+	return nil
+}
+
+// WaitForRevision polls until a revision of the supplied package with the
+// supplied source exists, and returns it. The type of the revision is
+// determined by l.
+func WaitForRevision(ctx context.Context, c client.Client, p v1alpha1.Package, l func() v1alpha1.PackageRevisionList, source string, interval time.Duration) (v1alpha1.PackageRevision, error) {
+	for {
+		revs, err := Revisions(ctx, c, p, l())
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range revs {
+			if r.GetSource() == source {
+				return r, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.Errorf("timed out waiting for a revision of %s from %s", p.GetName(), source)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// WaitForOwnership polls until every CustomResourceDefinition of the supplied
+// revisions is controlled by target. Progress is written to w.
+func WaitForOwnership(ctx context.Context, c client.Client, w io.Writer, revs []v1alpha1.PackageRevision, target v1alpha1.PackageRevision, interval time.Duration) error {
+	uids := map[types.UID]bool{}
+	for _, r := range revs {
+		uids[r.GetUID()] = true
+	}
+	last := -1
+	for {
+		l := &apiextensions.CustomResourceDefinitionList{}
+		if err := c.List(ctx, l); err != nil {
+			return errors.Wrap(err, "cannot list CustomResourceDefinitions")
+		}
+		want, controlled := reowned(l.Items, uids, target.GetUID())
+		if controlled != last {
+			fmt.Fprintln(w, prompt.FmtInfo(fmt.Sprintf("%d of %d CustomResourceDefinitions controlled by revision %s.", controlled, want, target.GetName()))) // nolint:errcheck
+			last = controlled
+		}
+		if controlled == want {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("timed out waiting for revision %s to control its CustomResourceDefinitions", target.GetName())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// reowned returns the number of the supplied CRDs that should be controlled by
+// the target owner, and the number that are. A CRD should be controlled by the
+// target if the target owns it, or if another of the supplied owners controls
+// it. CRDs that are merely owned by other owners are no longer part of the
+// package.
+func reowned(crds []apiextensions.CustomResourceDefinition, owners map[types.UID]bool, target types.UID) (want, controlled int) {
+	for i := range crds {
+		ref := metav1.GetControllerOf(&crds[i])
+		if ref != nil && ref.UID == target {
+			want++
+			controlled++
+			continue
+		}
+		if ref != nil && owners[ref.UID] {
+			want++
+			continue
+		}
+		for _, o := range crds[i].GetOwnerReferences() {
+			if o.UID == target {
+				want++
+				break
+			}
+		}
+	}
+	return want, controlled
+}
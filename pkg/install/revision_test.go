@@ -0,0 +1,273 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package install
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+)
+
+func revision(name string, n int64, state v1alpha1.PackageRevisionDesiredState) *v1alpha1.ProviderRevision {
+	r := &v1alpha1.ProviderRevision{ObjectMeta: metav1.ObjectMeta{
+		Name:   name,
+		UID:    types.UID(name),
+		Labels: map[string]string{LabelPackage: "provider-gcp"},
+	}}
+	r.SetRevision(n)
+	r.SetDesiredState(state)
+	r.SetSource("crossplane/provider-gcp:" + name)
+	return r
+}
+
+func revs(r ...*v1alpha1.ProviderRevision) []v1alpha1.PackageRevision {
+	out := make([]v1alpha1.PackageRevision, len(r))
+	for i := range r {
+		out[i] = r[i]
+	}
+	return out
+}
+
+func TestFindRevision(t *testing.T) {
+	rs := revs(
+		revision("v1", 1, v1alpha1.PackageRevisionInactive),
+		revision("v2", 2, v1alpha1.PackageRevisionActive),
+	)
+	cases := map[string]struct {
+		id   string
+		want string
+		err  bool
+	}{
+		"Name":    {id: "v1", want: "v1"},
+		"Number":  {id: "2", want: "v2"},
+		"Missing": {id: "3", err: true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r, err := FindRevision(rs, tc.id)
+			if (err != nil) != tc.err {
+				t.Fatalf("FindRevision(...): want error %t, got %v", tc.err, err)
+			}
+			if err == nil && r.GetName() != tc.want {
+				t.Errorf("FindRevision(...): want %s, got %s", tc.want, r.GetName())
+			}
+		})
+	}
+}
+
+func TestPrevious(t *testing.T) {
+	cases := map[string]struct {
+		revs []v1alpha1.PackageRevision
+		want string
+		err  string
+	}{
+		"Latest": {
+			revs: revs(
+				revision("v1", 1, v1alpha1.PackageRevisionInactive),
+				revision("v2", 2, v1alpha1.PackageRevisionInactive),
+				revision("v3", 3, v1alpha1.PackageRevisionActive),
+			),
+			want: "v2",
+		},
+		"AlreadyRolledBack": {
+			revs: revs(
+				revision("v1", 1, v1alpha1.PackageRevisionInactive),
+				revision("v2", 2, v1alpha1.PackageRevisionActive),
+				revision("v3", 3, v1alpha1.PackageRevisionInactive),
+			),
+			want: "v1",
+		},
+		"Oldest": {
+			revs: revs(revision("v1", 1, v1alpha1.PackageRevisionActive), revision("v2", 2, v1alpha1.PackageRevisionInactive)),
+			err:  "revision 1 is the oldest revision",
+		},
+		"NoneActive": {
+			revs: revs(revision("v1", 1, v1alpha1.PackageRevisionInactive)),
+			err:  "no revision is active",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r, err := Previous(tc.revs)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tc.err {
+				t.Fatalf("Previous(...): want error %q, got %q", tc.err, got)
+			}
+			if err == nil && r.GetName() != tc.want {
+				t.Errorf("Previous(...): want %s, got %s", tc.want, r.GetName())
+			}
+		})
+	}
+}
+
+func TestActivate(t *testing.T) {
+	p := NewProvider(Options{Name: "provider-gcp", Package: "crossplane/provider-gcp:v3"})
+	c := fake.NewFakeClientWithScheme(scheme(t), p,
+		revision("v1", 1, v1alpha1.PackageRevisionInactive),
+		revision("v2", 2, v1alpha1.PackageRevisionActive),
+		revision("v3", 3, v1alpha1.PackageRevisionInactive),
+	)
+	ctx := context.Background()
+	if err := c.Get(ctx, types.NamespacedName{Name: p.GetName()}, p); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := Revisions(ctx, c, p, &v1alpha1.ProviderRevisionList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Activate(ctx, c, p, rs, rs[0]); err != nil {
+		t.Fatalf("Activate(...): %s", err)
+	}
+
+	rs, err = Revisions(ctx, c, p, &v1alpha1.ProviderRevisionList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]v1alpha1.PackageRevisionDesiredState{}
+	for _, r := range rs {
+		got[r.GetName()] = r.GetDesiredState()
+	}
+	want := map[string]v1alpha1.PackageRevisionDesiredState{
+		"v1": v1alpha1.PackageRevisionActive,
+		"v2": v1alpha1.PackageRevisionInactive,
+		"v3": v1alpha1.PackageRevisionInactive,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Activate(...): -want, +got:\n%s", diff)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: p.GetName()}, p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Spec.Package != "crossplane/provider-gcp:v1" {
+		t.Errorf("Activate(...): want package source crossplane/provider-gcp:v1, got %s", p.Spec.Package)
+	}
+}
+
+// failPackageUpdate is a client that cannot update packages.
+type failPackageUpdate struct {
+	client.Client
+}
+
+func (c failPackageUpdate) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if _, ok := obj.(v1alpha1.Package); ok {
+		return errors.New("boom")
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func TestActivateRestore(t *testing.T) {
+	p := NewProvider(Options{Name: "provider-gcp", Package: "crossplane/provider-gcp:v2"})
+	c := failPackageUpdate{fake.NewFakeClientWithScheme(scheme(t), p,
+		revision("v1", 1, v1alpha1.PackageRevisionInactive),
+		revision("v2", 2, v1alpha1.PackageRevisionActive),
+	)}
+	ctx := context.Background()
+	if err := c.Get(ctx, types.NamespacedName{Name: p.GetName()}, p); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := Revisions(ctx, c, p, &v1alpha1.ProviderRevisionList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Activate(ctx, c, p, rs, rs[0]); err == nil {
+		t.Fatal("Activate(...): want error when the package cannot be updated")
+	}
+
+	rs, err = Revisions(ctx, c, p, &v1alpha1.ProviderRevisionList{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]v1alpha1.PackageRevisionDesiredState{}
+	for _, r := range rs {
+		got[r.GetName()] = r.GetDesiredState()
+	}
+	want := map[string]v1alpha1.PackageRevisionDesiredState{
+		"v1": v1alpha1.PackageRevisionInactive,
+		"v2": v1alpha1.PackageRevisionActive,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Activate(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestWaitForOwnership(t *testing.T) {
+	ref := func(uid string, controller bool) metav1.OwnerReference {
+		return metav1.OwnerReference{APIVersion: "crank.crossplane.io/v1alpha1", Kind: "ProviderRevision", Name: uid, UID: types.UID(uid), Controller: &controller}
+	}
+	crd := func(name string, refs ...metav1.OwnerReference) *apiextensions.CustomResourceDefinition {
+		return &apiextensions.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: name, OwnerReferences: refs}}
+	}
+	rs := revs(revision("v1", 1, v1alpha1.PackageRevisionInactive), revision("v2", 2, v1alpha1.PackageRevisionActive))
+
+	cases := map[string]struct {
+		crds []runtime.Object
+		want string
+		err  string
+	}{
+		"Reowned": {
+			crds: []runtime.Object{
+				crd("buckets", ref("v1", false), ref("v2", true)),
+				// Dropped from the target revision.
+				crd("networks", ref("v1", false)),
+				crd("unrelated", ref("other", true)),
+			},
+			want: "1 of 1 CustomResourceDefinitions controlled by revision v2.\n",
+		},
+		"NotReowned": {
+			crds: []runtime.Object{
+				crd("buckets", ref("v1", true), ref("v2", false)),
+				crd("databases", ref("v2", true)),
+			},
+			want: "1 of 2 CustomResourceDefinitions controlled by revision v2.\n",
+			err:  "timed out waiting for revision v2 to control its CustomResourceDefinitions",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := fake.NewFakeClientWithScheme(scheme(t), tc.crds...)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			b := &bytes.Buffer{}
+			err := WaitForOwnership(ctx, c, b, rs, rs[1], time.Millisecond)
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tc.err {
+				t.Errorf("WaitForOwnership(...): want error %q, got %q", tc.err, got)
+			}
+			if diff := cmp.Diff(tc.want, b.String()); diff != "" {
+				t.Errorf("WaitForOwnership(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// CompositionDefinitionRule reports Compositions that satisfy an
+// InfrastructureDefinition that does not exist.
+var CompositionDefinitionRule = Rule{
+	ID:          "composition-definition",
+	Description: "Compositions must satisfy an InfrastructureDefinition that exists.",
+	Severity:    SeverityError,
+	Check:       checkCompositionDefinition,
+}
+
+// DefinitionSatisfiedRule reports InfrastructureDefinitions that are not
+// satisfied by any Composition.
+var DefinitionSatisfiedRule = Rule{
+	ID:          "definition-satisfied",
+	Description: "InfrastructureDefinitions should be satisfied by at least one Composition.",
+	Severity:    SeverityWarning,
+	Check:       checkDefinitionSatisfied,
+}
+
+// DefinitionUniqueRule reports InfrastructureDefinitions that define the same
+// type as another InfrastructureDefinition in the package.
+var DefinitionUniqueRule = Rule{
+	ID:          "definition-unique",
+	Description: "Each InfrastructureDefinition must define a type that no other InfrastructureDefinition defines.",
+	Severity:    SeverityError,
+	Check:       checkDefinitionUnique,
+}
+
+// CompositionResourceKindRule reports Composition resources whose kind is not
+// provided by the package or any of its dependencies.
+var CompositionResourceKindRule = Rule{
+	ID:          "composition-resource-kind",
+	Description: "Composed resource kinds must be provided by the package or one of its dependencies.",
+	Severity:    SeverityError,
+	Check:       checkCompositionResourceKind,
+}
+
+// CompositionBaseSchemaRule reports Composition base templates that are not
+// valid instances of the CustomResourceDefinition they compose.
+var CompositionBaseSchemaRule = Rule{
+	ID:          "composition-base-schema",
+	Description: "Composition base templates must be valid instances of the resource they compose.",
+	Severity:    SeverityError,
+	Check:       checkCompositionBaseSchema,
+}
+
+func checkCompositionDefinition(c *Context) []Finding {
+	findings := []Finding{}
+	for _, path := range compositionPaths(c) {
+		comp := c.Package.Compositions[path]
+		if _, ok := c.Resolver().Resolve(comp.Spec.From); ok {
+			continue
+		}
+		key, val := lookup(c.Node(path), "spec", "from")
+		f := Finding{
+			Path:    path,
+			Range:   rangeOfKey(key, val),
+			Message: fmt.Sprintf("Composition %s satisfies a Definition that does not exist: %s.%s", comp.Name, comp.Spec.From.Kind, comp.Spec.From.APIVersion),
+		}
+		// If exactly one definition defines the kind the Composition most
+		// likely intended to satisfy it.
+		if defs := c.Resolver().Kind(comp.Spec.From.Kind); len(defs) == 1 {
+			if n := value(val, "apiVersion"); n != nil && n.Kind == yaml.ScalarNode {
+				gv := defs[0].GetDefinedGroupVersionKind().GroupVersion().String()
+				f.Fix = &Fix{
+					Description: fmt.Sprintf("Set apiVersion to %s", gv),
+					Edits:       []Edit{replaceScalar(n, gv)},
+				}
+			}
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+func checkDefinitionSatisfied(c *Context) []Finding {
+	findings := []Finding{}
+	for _, path := range c.Resolver().Unsatisfied(c.Package.Compositions) {
+		d := c.Package.InfrastructureDefinitions[path]
+		key, val := lookup(c.Node(path), "metadata", "name")
+		findings = append(findings, Finding{
+			Path:    path,
+			Range:   rangeOfKey(key, val),
+			Message: fmt.Sprintf("InfrastructureDefinition %s is not satisfied by any Composition", d.Name),
+		})
+	}
+	return findings
+}
+
+func checkDefinitionUnique(c *Context) []Finding {
+	dups := c.Resolver().Duplicates()
+	paths := make([]string, 0, len(dups))
+	for path := range dups {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	findings := []Finding{}
+	for _, path := range paths {
+		d := c.Package.InfrastructureDefinitions[path]
+		key, val := lookup(c.Node(path), "metadata", "name")
+		gvk := d.GetDefinedGroupVersionKind()
+		findings = append(findings, Finding{
+			Path:    path,
+			Range:   rangeOfKey(key, val),
+			Message: fmt.Sprintf("InfrastructureDefinition %s defines %s (%s), which is already defined by InfrastructureDefinition %s", d.Name, gvk.Kind, gvk.GroupVersion().String(), dups[path].Name),
+		})
+	}
+	return findings
+}
+
+func checkCompositionResourceKind(c *Context) []Finding {
+	if !c.DependenciesResolved {
+		return nil
+	}
+	crds := c.CustomResourceDefinitions()
+	findings := []Finding{}
+	for _, path := range compositionPaths(c) {
+		to := value(c.Node(path), "spec", "to")
+		for i := range c.Package.Compositions[path].Spec.To {
+			base := value(index(to, i), "base")
+			gv, err := schema.ParseGroupVersion(scalar(base, "apiVersion"))
+			kind := scalar(base, "kind")
+			if base == nil || err != nil || gv.Version == "" || kind == "" {
+				continue
+			}
+			gvk := gv.WithKind(kind)
+			if _, ok := schemaFor(crds, gvk); ok || c.Resolver().Defines(gvk) {
+				continue
+			}
+			key, val := lookup(base, "kind")
+			findings = append(findings, Finding{
+				Path:    path,
+				Range:   rangeOfKey(key, val),
+				Message: fmt.Sprintf("kind %s (%s) not provided by any dependency", kind, gv.String()),
+			})
+		}
+	}
+	return findings
+}
+
+func checkCompositionBaseSchema(c *Context) []Finding {
+	crds := c.CustomResourceDefinitions()
+	findings := []Finding{}
+	for _, path := range compositionPaths(c) {
+		to := value(c.Node(path), "spec", "to")
+		if to == nil {
+			continue
+		}
+		for i, t := range c.Package.Compositions[path].Spec.To {
+			base := value(index(to, i), "base")
+			if base == nil {
+				continue
+			}
+			gv, err := schema.ParseGroupVersion(scalar(base, "apiVersion"))
+			kind := scalar(base, "kind")
+			if err != nil || gv.Version == "" || kind == "" {
+				findings = append(findings, Finding{
+					Path:    path,
+					Range:   rangeOf(base),
+					Message: fmt.Sprintf("Composed resource %d must specify a valid apiVersion and kind", i),
+				})
+				continue
+			}
+			s, ok := schemaFor(crds, gv.WithKind(kind))
+			if !ok {
+				continue
+			}
+			patched := []string{}
+			for _, p := range t.Patches {
+				patched = append(patched, p.ToFieldPath)
+			}
+			for _, v := range validate(s, base, "", patched) {
+				findings = append(findings, Finding{
+					Path:    path,
+					Range:   rangeOfKey(v.key, v.node),
+					Message: fmt.Sprintf("%s base: %s", kind, v.message),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+func compositionPaths(c *Context) []string {
+	paths := make([]string, 0, len(c.Package.Compositions))
+	for path := range c.Package.Compositions {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// isPatched returns true if a patch writes to the field path or to any field
+// beneath it.
+func isPatched(path string, patched []string) bool {
+	for _, p := range patched {
+		if p == path || strings.HasPrefix(p, path+".") || strings.HasPrefix(p, path+"[") {
+			return true
+		}
+	}
+	return false
+}
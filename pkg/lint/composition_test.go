@@ -0,0 +1,224 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/pkg/parser"
+)
+
+const bucketCRD = `apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: buckets.storage.example.org
+spec:
+  group: storage.example.org
+  names:
+    kind: Bucket
+    plural: buckets
+  version: v1alpha1
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          required:
+          - forProvider
+          properties:
+            forProvider:
+              type: object
+              required:
+              - location
+              properties:
+                location:
+                  type: string
+                storageClass:
+                  type: string
+                  enum:
+                  - STANDARD
+                  - NEARLINE
+                versioning:
+                  type: boolean
+                labels:
+                  type: object
+                  additionalProperties:
+                    type: string
+`
+
+const bucketXRD = `apiVersion: apiextensions.crossplane.io/v1alpha1
+kind: InfrastructureDefinition
+metadata:
+  name: buckets.example.org
+spec:
+  connectionSecretKeys:
+  - endpoint
+  crdSpecTemplate:
+    group: example.org
+    version: v1alpha1
+    names:
+      kind: Bucket
+      plural: buckets
+    validation:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              parameters:
+                type: object
+                properties:
+                  location:
+                    type: string
+                  retain:
+                    type: boolean
+`
+
+const bucketComposition = `apiVersion: apiextensions.crossplane.io/v1alpha1
+kind: Composition
+metadata:
+  name: buckets
+spec:
+  from:
+    apiVersion: example.org/v1alpha1
+    kind: Bucket
+  to:
+  - base:
+      apiVersion: storage.example.org/v1alpha1
+      kind: Bucket
+      spec:
+        forProvider:
+          storageClas: STANDARD
+          versioning: "yes"
+          labels:
+            team: storage
+    patches:
+    - fromFieldPath: spec.parameters.location
+      toFieldPath: spec.forProvider.location
+`
+
+func parse(t *testing.T, files map[string]string) *parser.Package {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	for path, content := range files {
+		if err := afero.WriteFile(fs, path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pkg, err := parser.NewParser(fs).ParsePackage("/pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+func TestCompositionBaseSchema(t *testing.T) {
+	pkg := parse(t, map[string]string{
+		"/pkg/definition.yaml":  bucketXRD,
+		"/pkg/composition.yaml": bucketComposition,
+	})
+	dep := parse(t, map[string]string{
+		"/pkg/crd.yaml": bucketCRD,
+	})
+
+	got := NewLinter(pkg, WithRules(CompositionBaseSchemaRule), WithDependencies(dep)).Lint()
+	want := []Finding{
+		{
+			Rule:     CompositionBaseSchemaRule.ID,
+			Severity: SeverityError,
+			Path:     "/pkg/composition.yaml",
+			Range:    Range{Start: Position{Line: 15, Column: 11}, End: Position{Line: 15, Column: 32}},
+			Message:  "Bucket base: spec.forProvider.storageClas: unknown field",
+		},
+		{
+			Rule:     CompositionBaseSchemaRule.ID,
+			Severity: SeverityError,
+			Path:     "/pkg/composition.yaml",
+			Range:    Range{Start: Position{Line: 16, Column: 11}, End: Position{Line: 16, Column: 28}},
+			Message:  "Bucket base: spec.forProvider.versioning: must be a boolean",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Lint(): -want, +got:\n%s", diff)
+	}
+}
+
+func TestCompositionResourceKind(t *testing.T) {
+	pkg := parse(t, map[string]string{
+		"/pkg/definition.yaml":  bucketXRD,
+		"/pkg/composition.yaml": bucketComposition,
+	})
+	dep := parse(t, map[string]string{
+		"/pkg/crd.yaml": bucketCRD,
+	})
+
+	cases := map[string]struct {
+		opts []LinterOption
+		want []Finding
+	}{
+		"ProvidedByDependency": {
+			opts: []LinterOption{WithDependencies(dep)},
+			want: []Finding{},
+		},
+		"DependenciesNotResolved": {
+			want: []Finding{},
+		},
+		"NotProvided": {
+			opts: []LinterOption{WithDependencies()},
+			want: []Finding{{
+				Rule:     CompositionResourceKindRule.ID,
+				Severity: SeverityError,
+				Path:     "/pkg/composition.yaml",
+				Range:    Range{Start: Position{Line: 12, Column: 7}, End: Position{Line: 12, Column: 19}},
+				Message:  "kind Bucket (storage.example.org/v1alpha1) not provided by any dependency",
+			}},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			opts := append([]LinterOption{WithRules(CompositionResourceKindRule)}, tc.opts...)
+			got := NewLinter(pkg, opts...).Lint()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Lint(): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDefinitionUnique(t *testing.T) {
+	pkg := parse(t, map[string]string{
+		"/pkg/definition.yaml":      bucketXRD,
+		"/pkg/definition-copy.yaml": strings.Replace(bucketXRD, "name: buckets.example.org", "name: buckets-copy.example.org", 1),
+	})
+	got := NewLinter(pkg, WithRules(DefinitionUniqueRule)).Lint()
+	want := []Finding{{
+		Rule:     DefinitionUniqueRule.ID,
+		Severity: SeverityError,
+		Path:     "/pkg/definition.yaml",
+		Range:    Range{Start: Position{Line: 4, Column: 3}, End: Position{Line: 4, Column: 28}},
+		Message:  "InfrastructureDefinition buckets.example.org defines Bucket (example.org/v1alpha1), which is already defined by InfrastructureDefinition buckets-copy.example.org",
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Lint(): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"fmt"
+
+	apiv1alpha1 "github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+)
+
+// CompositionConnectionMissingRule reports connection secret keys that an
+// InfrastructureDefinition exposes but a Composition that satisfies it does
+// not produce.
+var CompositionConnectionMissingRule = Rule{
+	ID:          "composition-connection-missing",
+	Description: "Compositions must produce every connection secret key their InfrastructureDefinition exposes.",
+	Severity:    SeverityError,
+	Check:       checkCompositionConnectionMissing,
+}
+
+// CompositionConnectionExtraRule reports Composition connection details whose
+// key is not exposed by the InfrastructureDefinition the Composition
+// satisfies. Crossplane does not propagate such keys.
+var CompositionConnectionExtraRule = Rule{
+	ID:          "composition-connection-extra",
+	Description: "Composition connection details should only produce keys their InfrastructureDefinition exposes.",
+	Severity:    SeverityWarning,
+	Check:       checkCompositionConnectionExtra,
+}
+
+// connectionKey returns the key a connection detail is propagated to, or an
+// empty string if it has none.
+func connectionKey(d apiv1alpha1.ConnectionDetail) string {
+	switch {
+	case d.Name != nil:
+		return *d.Name
+	case d.FromConnectionSecretKey != nil:
+		return *d.FromConnectionSecretKey
+	}
+	return ""
+}
+
+func checkCompositionConnectionMissing(c *Context) []Finding {
+	findings := []Finding{}
+	for _, path := range compositionPaths(c) {
+		comp := c.Package.Compositions[path]
+		d, ok := c.Resolver().Resolve(comp.Spec.From)
+		if !ok {
+			continue
+		}
+		produced := map[string]bool{}
+		for _, t := range comp.Spec.To {
+			for _, cd := range t.ConnectionDetails {
+				produced[connectionKey(cd)] = true
+			}
+		}
+		key, _ := lookup(c.Node(path), "spec", "to")
+		for _, k := range d.Spec.ConnectionSecretKeys {
+			if produced[k] {
+				continue
+			}
+			findings = append(findings, Finding{
+				Path:    path,
+				Range:   rangeOf(key),
+				Message: fmt.Sprintf("connection secret key %q exposed by InfrastructureDefinition %s is not produced by any resource", k, d.GetName()),
+			})
+		}
+	}
+	return findings
+}
+
+func checkCompositionConnectionExtra(c *Context) []Finding {
+	findings := []Finding{}
+	for _, path := range compositionPaths(c) {
+		comp := c.Package.Compositions[path]
+		d, ok := c.Resolver().Resolve(comp.Spec.From)
+		if !ok {
+			continue
+		}
+		exposed := map[string]bool{}
+		for _, k := range d.Spec.ConnectionSecretKeys {
+			exposed[k] = true
+		}
+		to := value(c.Node(path), "spec", "to")
+		for i, t := range comp.Spec.To {
+			details := value(index(to, i), "connectionDetails")
+			for j, cd := range t.ConnectionDetails {
+				k := connectionKey(cd)
+				if k == "" || exposed[k] {
+					continue
+				}
+				findings = append(findings, Finding{
+					Path:    path,
+					Range:   rangeOf(index(details, j)),
+					Message: fmt.Sprintf("%s resource produces connection secret key %q that is not exposed by InfrastructureDefinition %s", scalar(index(to, i), "base", "kind"), k, d.GetName()),
+				})
+			}
+		}
+	}
+	return findings
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const bucketConnectionComposition = `apiVersion: apiextensions.crossplane.io/v1alpha1
+kind: Composition
+metadata:
+  name: buckets
+spec:
+  from:
+    apiVersion: example.org/v1alpha1
+    kind: Bucket
+  to:
+  - base:
+      apiVersion: storage.example.org/v1alpha1
+      kind: Bucket
+    connectionDetails:
+    - name: url
+      fromConnectionSecretKey: endpoint
+    - fromConnectionSecretKey: password
+`
+
+func TestCompositionConnection(t *testing.T) {
+	cases := map[string]struct {
+		composition string
+		want        []Finding
+	}{
+		"Missing": {
+			composition: bucketComposition,
+			want: []Finding{{
+				Rule:     CompositionConnectionMissingRule.ID,
+				Severity: SeverityError,
+				Path:     "/pkg/composition.yaml",
+				Range:    Range{Start: Position{Line: 9, Column: 3}, End: Position{Line: 9, Column: 5}},
+				Message:  `connection secret key "endpoint" exposed by InfrastructureDefinition buckets.example.org is not produced by any resource`,
+			}},
+		},
+		"MissingAndExtra": {
+			composition: bucketConnectionComposition,
+			want: []Finding{
+				{
+					Rule:     CompositionConnectionMissingRule.ID,
+					Severity: SeverityError,
+					Path:     "/pkg/composition.yaml",
+					Range:    Range{Start: Position{Line: 9, Column: 3}, End: Position{Line: 9, Column: 5}},
+					Message:  `connection secret key "endpoint" exposed by InfrastructureDefinition buckets.example.org is not produced by any resource`,
+				},
+				{
+					Rule:     CompositionConnectionExtraRule.ID,
+					Severity: SeverityWarning,
+					Path:     "/pkg/composition.yaml",
+					Range:    Range{Start: Position{Line: 14, Column: 7}, End: Position{Line: 15, Column: 40}},
+					Message:  `Bucket resource produces connection secret key "url" that is not exposed by InfrastructureDefinition buckets.example.org`,
+				},
+				{
+					Rule:     CompositionConnectionExtraRule.ID,
+					Severity: SeverityWarning,
+					Path:     "/pkg/composition.yaml",
+					Range:    Range{Start: Position{Line: 16, Column: 7}, End: Position{Line: 16, Column: 40}},
+					Message:  `Bucket resource produces connection secret key "password" that is not exposed by InfrastructureDefinition buckets.example.org`,
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			pkg := parse(t, map[string]string{
+				"/pkg/definition.yaml":  bucketXRD,
+				"/pkg/composition.yaml": tc.composition,
+			})
+			got := NewLinter(pkg, WithRules(CompositionConnectionMissingRule, CompositionConnectionExtraRule)).Lint()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Lint(): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CRDNameRule reports CustomResourceDefinitions that are not named
+// <plural>.<group>, which the API server requires.
+var CRDNameRule = Rule{
+	ID:          "crd-name",
+	Description: "CustomResourceDefinitions must be named <plural>.<group>.",
+	Severity:    SeverityError,
+	Check:       checkCRDName,
+}
+
+func checkCRDName(c *Context) []Finding {
+	paths := make([]string, 0, len(c.Package.CustomResourceDefinitions))
+	for path := range c.Package.CustomResourceDefinitions {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	findings := []Finding{}
+	for _, path := range paths {
+		crd := c.Package.CustomResourceDefinitions[path]
+		if crd.Spec.Names.Plural == "" || crd.Spec.Group == "" {
+			continue
+		}
+		want := crd.Spec.Names.Plural + "." + crd.Spec.Group
+		if crd.GetName() == want {
+			continue
+		}
+		key, val := lookup(c.Node(path), "metadata", "name")
+		if key == nil {
+			key, val = lookup(c.Node(path), "kind")
+		}
+		f := Finding{
+			Path:    path,
+			Range:   rangeOfKey(key, val),
+			Message: fmt.Sprintf("CustomResourceDefinition %q must be named %q", crd.GetName(), want),
+		}
+		if n := value(c.Node(path), "metadata", "name"); n != nil && n.Kind == yaml.ScalarNode {
+			f.Fix = &Fix{
+				Description: fmt.Sprintf("Rename to %s", want),
+				Edits:       []Edit{replaceScalar(n, want)},
+			}
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
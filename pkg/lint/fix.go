@@ -0,0 +1,176 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"bytes"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// A Fix is a set of text edits that resolve a finding.
+type Fix struct {
+	// Description briefly describes what the fix does.
+	Description string `json:"description"`
+
+	// Edits are applied to the file of the finding. They must not overlap.
+	Edits []Edit `json:"edits"`
+}
+
+// An Edit replaces the text in a range of a file. Text is inserted if the
+// range is empty.
+type Edit struct {
+	Range Range  `json:"range"`
+	Text  string `json:"text"`
+}
+
+// replaceScalar returns an edit that replaces the value of the supplied scalar
+// node, preserving its quoting style.
+func replaceScalar(n *yaml.Node, v string) Edit {
+	switch {
+	case n.Style&yaml.DoubleQuotedStyle != 0:
+		v = `"` + v + `"`
+	case n.Style&yaml.SingleQuotedStyle != 0:
+		v = "'" + v + "'"
+	}
+	return Edit{Range: rangeOf(n), Text: v}
+}
+
+// insert returns an edit that inserts text at the start of the supplied line.
+func insert(line int, text string) Edit {
+	p := Position{Line: line, Column: 1}
+	return Edit{Range: Range{Start: p, End: p}, Text: text}
+}
+
+// Fixes applies the fixes of the supplied findings to the supplied files, which
+// are keyed by path. It returns the content of each file that changed, and the
+// number of findings that were fixed. A fix is skipped if it overlaps a fix
+// that has already been applied to the same file.
+func Fixes(files map[string][]byte, findings []Finding) (map[string][]byte, int, error) {
+	byPath := map[string][]Edit{}
+	fixed := 0
+	for _, f := range findings {
+		if f.Fix == nil || len(f.Fix.Edits) == 0 {
+			continue
+		}
+		if _, ok := files[f.Path]; !ok {
+			continue
+		}
+		if overlaps(byPath[f.Path], f.Fix.Edits) {
+			continue
+		}
+		byPath[f.Path] = append(byPath[f.Path], f.Fix.Edits...)
+		fixed++
+	}
+	out := map[string][]byte{}
+	for path, edits := range byPath {
+		b, err := ApplyEdits(files[path], edits)
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "cannot fix %s", path)
+		}
+		if !bytes.Equal(b, files[path]) {
+			out[path] = b
+		}
+	}
+	return out, fixed, nil
+}
+
+// ApplyEdits applies the supplied non-overlapping edits to b.
+func ApplyEdits(b []byte, edits []Edit) ([]byte, error) {
+	type span struct {
+		start, end int
+		text       string
+	}
+	spans := make([]span, len(edits))
+	for i, e := range edits {
+		start, err := offset(b, e.Range.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := offset(b, e.Range.End)
+		if err != nil {
+			return nil, err
+		}
+		if end < start {
+			return nil, errors.Errorf("edit ends before it starts at %d:%d", e.Range.Start.Line, e.Range.Start.Column)
+		}
+		spans[i] = span{start: start, end: end, text: e.Text}
+	}
+	sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	out := &bytes.Buffer{}
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			return nil, errors.New("edits overlap")
+		}
+		out.Write(b[last:s.start])
+		out.WriteString(s.text)
+		last = s.end
+	}
+	out.Write(b[last:])
+	return out.Bytes(), nil
+}
+
+// offset returns the byte offset of a position in b. Columns count runes. A
+// position one line past the last line is the end of b.
+func offset(b []byte, p Position) (int, error) {
+	line, off := 1, 0
+	for line < p.Line {
+		i := bytes.IndexByte(b[off:], '\n')
+		if i < 0 {
+			if line+1 == p.Line && p.Column == 1 {
+				return len(b), nil
+			}
+			return 0, errors.Errorf("line %d is out of range", p.Line)
+		}
+		off += i + 1
+		line++
+	}
+	for col := 1; col < p.Column; col++ {
+		if off >= len(b) || b[off] == '\n' {
+			return 0, errors.Errorf("column %d of line %d is out of range", p.Column, p.Line)
+		}
+		_, size := utf8.DecodeRune(b[off:])
+		off += size
+	}
+	return off, nil
+}
+
+// overlaps returns true if any of the edits in b overlap any in a.
+func overlaps(a, b []Edit) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if before(x.Range.Start, y.Range.End) && before(y.Range.Start, x.Range.End) {
+				return true
+			}
+			if x.Range.Start == y.Range.Start {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func before(a, b Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Column < b.Column
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const claim = `apiVersion: example.org/v1alpha1
+kind: Bucket
+metadata:
+  name: example
+`
+
+func TestFixes(t *testing.T) {
+	cases := map[string]struct {
+		files map[string]string
+		rules []Rule
+		want  map[string]string
+	}{
+		"CompositionAPIVersion": {
+			files: map[string]string{
+				"/pkg/definition.yaml": bucketXRD,
+				"/pkg/composition.yaml": strings.Replace(bucketComposition,
+					"    apiVersion: example.org/v1alpha1\n",
+					"    apiVersion: \"example.org/v1beta1\" # the claim version\n", 1),
+			},
+			rules: []Rule{CompositionDefinitionRule},
+			want: map[string]string{
+				"/pkg/composition.yaml": strings.Replace(bucketComposition,
+					"    apiVersion: example.org/v1alpha1\n",
+					"    apiVersion: \"example.org/v1alpha1\" # the claim version\n", 1),
+			},
+		},
+		"CRDName": {
+			files: map[string]string{
+				"/pkg/crd.yaml": strings.Replace(bucketCRD, "name: buckets.storage.example.org", "name: bucket.storage.example.org", 1),
+			},
+			rules: []Rule{CRDNameRule},
+			want: map[string]string{
+				"/pkg/crd.yaml": bucketCRD,
+			},
+		},
+		"IgnoreEntry": {
+			files: map[string]string{
+				"/pkg/crossplane.yaml":     "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Configuration\nmetadata:\n  name: bucket\nspec:\n  ignore:\n  # Not part of the package.\n  - path: docs/\n",
+				"/pkg/examples/claim.yaml": claim,
+			},
+			rules: []Rule{MetaIgnoreRule},
+			want: map[string]string{
+				"/pkg/crossplane.yaml": "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Configuration\nmetadata:\n  name: bucket\nspec:\n  ignore:\n  # Not part of the package.\n  - path: docs/\n  - path: examples/\n",
+			},
+		},
+		"IgnoreKey": {
+			files: map[string]string{
+				"/pkg/crossplane.yaml":     "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Configuration\nmetadata:\n  name: bucket\nspec:\n    dependsOn:\n    - package: example/dep\n# Trailing comment.\n",
+				"/pkg/examples/claim.yaml": claim,
+			},
+			rules: []Rule{MetaIgnoreRule},
+			want: map[string]string{
+				"/pkg/crossplane.yaml": "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Configuration\nmetadata:\n  name: bucket\nspec:\n    dependsOn:\n    - package: example/dep\n    ignore:\n    - path: examples/\n# Trailing comment.\n",
+			},
+		},
+		"IgnoreKeyAfterBlockScalar": {
+			files: map[string]string{
+				"/pkg/crossplane.yaml":     "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Configuration\nmetadata:\n  name: bucket\nspec:\n  notes: |\n    First line.\n\n    Last line.\n# Trailing comment.\n",
+				"/pkg/examples/claim.yaml": claim,
+			},
+			rules: []Rule{MetaIgnoreRule},
+			want: map[string]string{
+				"/pkg/crossplane.yaml": "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Configuration\nmetadata:\n  name: bucket\nspec:\n  notes: |\n    First line.\n\n    Last line.\n  ignore:\n  - path: examples/\n# Trailing comment.\n",
+			},
+		},
+		"IgnoreSpec": {
+			files: map[string]string{
+				"/pkg/crossplane.yaml":     "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Configuration\nmetadata:\n  name: bucket",
+				"/pkg/examples/claim.yaml": claim,
+			},
+			rules: []Rule{MetaIgnoreRule},
+			want: map[string]string{
+				"/pkg/crossplane.yaml": "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Configuration\nmetadata:\n  name: bucket\nspec:\n  ignore:\n  - path: examples/\n",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			pkg := parse(t, tc.files)
+			fixed, _, err := Fixes(pkg.Files, NewLinter(pkg, WithRules(tc.rules...)).Lint())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for path, b := range fixed {
+				got[path] = string(b)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Fixes(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
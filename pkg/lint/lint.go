@@ -17,34 +17,216 @@ limitations under the License.
 package lint
 
 import (
-	"fmt"
-	"strings"
+	"sort"
 
 	"github.com/hasheddan/crank/pkg/parser"
+	"gopkg.in/yaml.v3"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 )
 
+// Severity is the severity of a finding.
+type Severity string
+
+// Finding severities.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// A Position is a 1-based line and column in a file.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// A Range is a span of a file.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// A Finding is a problem discovered by a Rule.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Path     string   `json:"file"`
+	Range    Range    `json:"range"`
+	Message  string   `json:"message"`
+
+	// Fix mechanically resolves the finding. It is nil if the finding has no
+	// such resolution.
+	Fix *Fix `json:"fix,omitempty"`
+}
+
+// A Rule checks a package for a single class of problem.
+type Rule struct {
+	// ID uniquely identifies the rule.
+	ID string
+
+	// Description briefly describes what the rule checks.
+	Description string
+
+	// Severity is applied to every finding reported by the rule.
+	Severity Severity
+
+	// Check reports findings for the package being linted.
+	Check func(c *Context) []Finding
+}
+
+// DefaultRules returns the rules that are run when none are supplied.
+func DefaultRules() []Rule {
+	return []Rule{
+		CompositionDefinitionRule,
+		DefinitionSatisfiedRule,
+		DefinitionUniqueRule,
+		CompositionResourceKindRule,
+		CompositionBaseSchemaRule,
+		CompositionPatchPathRule,
+		CompositionPatchTypeRule,
+		CompositionConnectionMissingRule,
+		CompositionConnectionExtraRule,
+		CRDNameRule,
+		MetaIgnoreRule,
+		ConfigurationContentsRule,
+		ProviderContentsRule,
+		PublicationDefinitionRule,
+	}
+}
+
+// Context is the information available to a Rule.
+type Context struct {
+	// Package is the package being linted.
+	Package *parser.Package
+
+	// Dependencies are the packages the linted package depends on.
+	Dependencies []*parser.Package
+
+	// DependenciesResolved is true if Dependencies were fetched. Rules that
+	// require knowledge of every dependency should not run if it is false.
+	DependenciesResolved bool
+
+	resolver *Resolver
+	nodes    map[string]*yaml.Node
+}
+
+// Resolver returns a resolver for the InfrastructureDefinitions in the
+// package and its dependencies.
+func (c *Context) Resolver() *Resolver {
+	if c.resolver == nil {
+		c.resolver = NewResolver(c.Package.InfrastructureDefinitions)
+		for _, d := range c.Dependencies {
+			c.resolver.AddDependency(d.InfrastructureDefinitions)
+		}
+	}
+	return c.resolver
+}
+
+// Node returns the parsed YAML node for the file at path, or nil if the file
+// cannot be parsed.
+func (c *Context) Node(path string) *yaml.Node {
+	if n, ok := c.nodes[path]; ok {
+		return n
+	}
+	n, err := parseNode(c.Package.Files[path])
+	if err != nil {
+		n = nil
+	}
+	c.nodes[path] = n
+	return n
+}
+
+// CustomResourceDefinitions returns the CustomResourceDefinitions in the
+// package and all of its dependencies.
+func (c *Context) CustomResourceDefinitions() []apiextensions.CustomResourceDefinition {
+	crds := []apiextensions.CustomResourceDefinition{}
+	for _, p := range append([]*parser.Package{c.Package}, c.Dependencies...) {
+		paths := make([]string, 0, len(p.CustomResourceDefinitions))
+		for path := range p.CustomResourceDefinitions {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			crds = append(crds, p.CustomResourceDefinitions[path])
+		}
+	}
+	return crds
+}
+
+// LinterOption is used to configure the Linter.
+type LinterOption func(*Linter)
+
+// WithRules specifies the rules the Linter should run.
+func WithRules(r ...Rule) LinterOption {
+	return func(l *Linter) {
+		l.rules = r
+	}
+}
+
+// WithDependencies specifies the packages the linted package depends on. It
+// should be supplied every dependency of the package.
+func WithDependencies(d ...*parser.Package) LinterOption {
+	return func(l *Linter) {
+		l.deps = d
+		l.resolved = true
+	}
+}
+
 // Linter lints Crossplane packages.
 type Linter struct {
-	pkg     *parser.Package
-	verbose bool
+	pkg      *parser.Package
+	deps     []*parser.Package
+	resolved bool
+	rules    []Rule
 }
 
-// NewLinter creates a new linter for the file system.
-func NewLinter(p *parser.Package) *Linter {
-	return &Linter{
-		pkg:     p,
-		verbose: false,
+// NewLinter creates a new linter for the package.
+func NewLinter(p *parser.Package, opts ...LinterOption) *Linter {
+	l := &Linter{
+		pkg:   p,
+		rules: DefaultRules(),
+	}
+
+	for _, f := range opts {
+		f(l)
 	}
+
+	return l
+}
+
+// Rules returns the rules run by the Linter.
+func (l *Linter) Rules() []Rule {
+	return l.rules
 }
 
-// Lint executes the linters.
-// TODO(hasheddan): should be able to supply pluggable linters.
-func (l *Linter) Lint() []string {
-	errors := []string{}
-	for _, c := range l.pkg.Compositions {
-		if _, ok := l.pkg.InfrastructureDefinitions[strings.ToLower(c.Spec.From.Kind)]; !ok {
-			errors = append(errors, fmt.Sprintf("Composition %s satisfies a Definition that does not exist: %s", c.Name, c.Spec.From.Kind))
+// Lint executes the linters and returns their findings ordered by path and
+// position.
+func (l *Linter) Lint() []Finding {
+	c := &Context{
+		Package:              l.pkg,
+		Dependencies:         l.deps,
+		DependenciesResolved: l.resolved,
+		nodes:                map[string]*yaml.Node{},
+	}
+	findings := []Finding{}
+	for _, r := range l.rules {
+		for _, f := range r.Check(c) {
+			if l.pkg.Ignored(f.Path) {
+				continue
+			}
+			f.Rule = r.ID
+			f.Severity = r.Severity
+			findings = append(findings, f)
 		}
 	}
-	return errors
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Range.Start.Line != b.Range.Start.Line {
+			return a.Range.Start.Line < b.Range.Start.Line
+		}
+		return a.Range.Start.Column < b.Range.Start.Column
+	})
+	return findings
 }
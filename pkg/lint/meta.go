@@ -0,0 +1,176 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/hasheddan/crank/pkg/parser"
+)
+
+// MetaIgnoreRule reports directories of example manifests that the package
+// metadata does not ignore, and that would therefore be built into the
+// package.
+var MetaIgnoreRule = Rule{
+	ID:          "meta-ignore",
+	Description: "Package metadata should ignore directories of example manifests.",
+	Severity:    SeverityWarning,
+	Check:       checkMetaIgnore,
+}
+
+// exampleDirs are directories, relative to the package root, that contain
+// example manifests by convention.
+var exampleDirs = []string{"examples"}
+
+// metaPath returns the path of the package metadata file, or an empty string
+// if the package has none.
+func (c *Context) metaPath() string {
+	if c.Package.Meta == nil {
+		return ""
+	}
+	paths := make([]string, 0, len(c.Package.Files))
+	for path := range c.Package.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if gv, err := schema.ParseGroupVersion(scalar(c.Node(path), "apiVersion")); err == nil && gv.Group == parser.MetaGroup {
+			return path
+		}
+	}
+	return ""
+}
+
+func checkMetaIgnore(c *Context) []Finding {
+	path := c.metaPath()
+	if path == "" {
+		return nil
+	}
+	findings := []Finding{}
+	for _, dir := range exampleDirs {
+		if !c.containsUnignored(dir) {
+			continue
+		}
+		key, val := lookup(c.Node(path), "spec", "ignore")
+		if key == nil {
+			key, val = lookup(c.Node(path), "kind")
+		}
+		f := Finding{
+			Path:    path,
+			Range:   rangeOfKey(key, val),
+			Message: fmt.Sprintf("directory %s/ is not ignored and will be built into the package", dir),
+		}
+		if e, ok := ignoreEdit(c.Node(path), c.Package.Files[path], dir+"/"); ok {
+			f.Fix = &Fix{
+				Description: fmt.Sprintf("Ignore %s/", dir),
+				Edits:       []Edit{e},
+			}
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// containsUnignored returns true if any parsed file beneath dir, relative to
+// the package root, is not ignored.
+func (c *Context) containsUnignored(dir string) bool {
+	for path := range c.Package.Files {
+		rel, err := filepath.Rel(c.Package.Root, path)
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(filepath.ToSlash(rel), dir+"/") && !c.Package.Ignored(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreEdit returns an edit that adds an ignore entry for path to the package
+// metadata in n, whose raw content is b. It returns false if the entry cannot
+// be added without reformatting the metadata.
+func ignoreEdit(n *yaml.Node, b []byte, path string) (Edit, bool) {
+	entry := "- path: " + path + "\n"
+	specKey, spec := lookup(n, "spec")
+	switch {
+	case specKey == nil:
+		return appendText(b, "spec:\n  ignore:\n  "+entry), true
+	case spec.Kind != yaml.MappingNode || spec.Style&yaml.FlowStyle != 0 || len(spec.Content) == 0:
+		return Edit{}, false
+	}
+	key, ignore := lookup(spec, "ignore")
+	indent := strings.Repeat(" ", spec.Content[0].Column-1)
+	switch {
+	case key == nil:
+		return insertAfter(b, lastLine(b, spec.Content[len(spec.Content)-1], len(indent)), indent+"ignore:\n"+indent+entry), true
+	case ignore.Kind == yaml.ScalarNode && ignore.Tag == "!!null":
+		return insertAfter(b, key.Line, indent+entry), true
+	case ignore.Kind == yaml.SequenceNode && ignore.Style&yaml.FlowStyle == 0 && len(ignore.Content) > 0:
+		// Block sequence entries begin two columns before their content.
+		indent = strings.Repeat(" ", ignore.Content[0].Column-3)
+		return insertAfter(b, lastLine(b, ignore.Content[len(ignore.Content)-1], len(indent)), indent+entry), true
+	}
+	return Edit{}, false
+}
+
+// lastLine returns the last line of b spanned by n, the value of the last
+// entry of a block collection whose entries are indented by indent columns.
+// Unlike endOf it accounts for multi-line scalars, which continue on every
+// following line that is blank or indented beyond the entries.
+func lastLine(b []byte, n *yaml.Node, indent int) int {
+	last := endOf(n).Line
+	lines := strings.Split(string(b), "\n")
+	for i := last; i < len(lines); i++ {
+		l := lines[i]
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		if len(l)-len(strings.TrimLeft(l, " ")) <= indent {
+			break
+		}
+		last = i + 1
+	}
+	return last
+}
+
+// insertAfter returns an edit that inserts text on a new line after the
+// supplied line of b.
+func insertAfter(b []byte, line int, text string) Edit {
+	if line < bytes.Count(b, []byte("\n")) {
+		return insert(line+1, text)
+	}
+	return appendText(b, text)
+}
+
+// appendText returns an edit that appends text on a new line at the end of b.
+func appendText(b []byte, text string) Edit {
+	lines := bytes.Count(b, []byte("\n"))
+	if len(b) == 0 || bytes.HasSuffix(b, []byte("\n")) {
+		return insert(lines+1, text)
+	}
+	last := b[bytes.LastIndexByte(b, '\n')+1:]
+	p := Position{Line: lines + 1, Column: utf8.RuneCount(last) + 1}
+	return Edit{Range: Range{Start: p, End: p}, Text: "\n" + text}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hasheddan/crank/pkg/parser"
+)
+
+// ConfigurationContentsRule reports CustomResourceDefinitions and controller
+// Deployments in Configuration packages.
+var ConfigurationContentsRule = Rule{
+	ID:          "configuration-contents",
+	Description: "Configurations must not contain CustomResourceDefinitions or controller Deployments.",
+	Severity:    SeverityError,
+	Check:       checkConfigurationContents,
+}
+
+// ProviderContentsRule reports objects other than CustomResourceDefinitions
+// and controller or permission specs in Provider packages.
+var ProviderContentsRule = Rule{
+	ID:          "provider-contents",
+	Description: "Providers must contain only CustomResourceDefinitions and controller or permission specs.",
+	Severity:    SeverityError,
+	Check:       checkProviderContents,
+}
+
+// PublicationDefinitionRule reports InfrastructurePublications that do not
+// reference an InfrastructureDefinition in the same package.
+var PublicationDefinitionRule = Rule{
+	ID:          "publication-definition",
+	Description: "InfrastructurePublications must reference an InfrastructureDefinition in the same package.",
+	Severity:    SeverityError,
+	Check:       checkPublicationDefinition,
+}
+
+// providerKinds are the kinds of object, other than CustomResourceDefinitions,
+// that a Provider may contain.
+var providerKinds = map[string]bool{
+	"Deployment":         true,
+	"ServiceAccount":     true,
+	"ClusterRole":        true,
+	"ClusterRoleBinding": true,
+	"Role":               true,
+	"RoleBinding":        true,
+}
+
+// packageKind returns the kind declared by the package metadata, or an empty
+// string if the package has none.
+func packageKind(c *Context) string {
+	if c.Package.Meta == nil {
+		return ""
+	}
+	return c.Package.Meta.Kind
+}
+
+func checkConfigurationContents(c *Context) []Finding {
+	if packageKind(c) != parser.ConfigurationKind {
+		return nil
+	}
+	findings := []Finding{}
+	for path := range c.Package.CustomResourceDefinitions {
+		findings = append(findings, c.kindFinding(path, "Configurations must not contain CustomResourceDefinitions"))
+	}
+	for path, o := range c.Package.Objects {
+		if o.Kind == "Deployment" {
+			findings = append(findings, c.kindFinding(path, "Configurations must not contain controller Deployments"))
+		}
+	}
+	return findings
+}
+
+func checkProviderContents(c *Context) []Finding {
+	if packageKind(c) != parser.ProviderKind {
+		return nil
+	}
+	kinds := map[string]string{}
+	for path := range c.Package.InfrastructureDefinitions {
+		kinds[path] = "InfrastructureDefinition"
+	}
+	for path := range c.Package.InfrastructurePublications {
+		kinds[path] = "InfrastructurePublication"
+	}
+	for path := range c.Package.Compositions {
+		kinds[path] = "Composition"
+	}
+	for path, o := range c.Package.Objects {
+		if !providerKinds[o.Kind] {
+			kinds[path] = o.Kind
+		}
+	}
+	findings := make([]Finding, 0, len(kinds))
+	for path, kind := range kinds {
+		findings = append(findings, c.kindFinding(path, fmt.Sprintf("Providers must not contain a %s: only CustomResourceDefinitions and controller or permission specs are allowed", kind)))
+	}
+	return findings
+}
+
+func checkPublicationDefinition(c *Context) []Finding {
+	defined := map[string]bool{}
+	for _, d := range c.Package.InfrastructureDefinitions {
+		defined[d.GetName()] = true
+	}
+	paths := make([]string, 0, len(c.Package.InfrastructurePublications))
+	for path := range c.Package.InfrastructurePublications {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	findings := []Finding{}
+	for _, path := range paths {
+		name := c.Package.InfrastructurePublications[path].Spec.InfrastructureDefinitionReference.Name
+		if defined[name] {
+			continue
+		}
+		key, val := lookup(c.Node(path), "spec", "infrastructureDefinitionRef")
+		if key == nil {
+			key, val = lookup(c.Node(path), "kind")
+		}
+		findings = append(findings, Finding{
+			Path:    path,
+			Range:   rangeOfKey(key, val),
+			Message: fmt.Sprintf("InfrastructureDefinition %q is not defined in this package", name),
+		})
+	}
+	return findings
+}
+
+// kindFinding returns a finding for the object at path that is positioned at
+// its kind.
+func (c *Context) kindFinding(path, message string) Finding {
+	key, val := lookup(c.Node(path), "kind")
+	return Finding{Path: path, Range: rangeOfKey(key, val), Message: message}
+}
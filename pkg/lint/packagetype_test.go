@@ -0,0 +1,109 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func meta(kind string) string {
+	return `apiVersion: pkg.crossplane.io/v1alpha1
+kind: ` + kind + `
+metadata:
+  name: bucket
+spec:
+  ignore:
+  - path: examples/
+`
+}
+
+const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller
+`
+
+const publication = `apiVersion: apiextensions.crossplane.io/v1alpha1
+kind: InfrastructurePublication
+metadata:
+  name: buckets.example.org
+spec:
+  infrastructureDefinitionRef:
+    name: buckets.example.com
+`
+
+func TestPackageType(t *testing.T) {
+	kind := Range{Start: Position{Line: 2, Column: 1}}
+	cases := map[string]struct {
+		files map[string]string
+		want  []Finding
+	}{
+		"Configuration": {
+			files: map[string]string{
+				"/pkg/crossplane.yaml":          meta("Configuration"),
+				"/pkg/crd.yaml":                 bucketCRD,
+				"/pkg/deployment.yaml":          deployment,
+				"/pkg/examples/deployment.yaml": deployment,
+				"/pkg/definition.yaml":          bucketXRD,
+				"/pkg/composition.yaml":         bucketComposition,
+			},
+			want: []Finding{
+				{Rule: ConfigurationContentsRule.ID, Path: "/pkg/crd.yaml", Message: "Configurations must not contain CustomResourceDefinitions"},
+				{Rule: ConfigurationContentsRule.ID, Path: "/pkg/deployment.yaml", Message: "Configurations must not contain controller Deployments"},
+			},
+		},
+		"Provider": {
+			files: map[string]string{
+				"/pkg/crossplane.yaml":  meta("Provider"),
+				"/pkg/crd.yaml":         bucketCRD,
+				"/pkg/deployment.yaml":  deployment,
+				"/pkg/composition.yaml": bucketComposition,
+			},
+			want: []Finding{
+				{Rule: ProviderContentsRule.ID, Path: "/pkg/composition.yaml", Message: "Providers must not contain a Composition: only CustomResourceDefinitions and controller or permission specs are allowed"},
+			},
+		},
+		"Publication": {
+			files: map[string]string{
+				"/pkg/definition.yaml":  bucketXRD,
+				"/pkg/publication.yaml": publication,
+			},
+			want: []Finding{
+				{Rule: PublicationDefinitionRule.ID, Path: "/pkg/publication.yaml", Range: Range{Start: Position{Line: 6, Column: 3}}, Message: `InfrastructureDefinition "buckets.example.com" is not defined in this package`},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := NewLinter(parse(t, tc.files), WithRules(ConfigurationContentsRule, ProviderContentsRule, PublicationDefinitionRule)).Lint()
+			for i := range got {
+				got[i].Range.End = Position{}
+			}
+			for i := range tc.want {
+				tc.want[i].Severity = SeverityError
+				if tc.want[i].Range == (Range{}) {
+					tc.want[i].Range = kind
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Lint(): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
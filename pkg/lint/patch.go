@@ -0,0 +1,269 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"fmt"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	apiv1alpha1 "github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1/ccrd"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// CompositionPatchPathRule reports Composition patches whose field paths do
+// not exist in the schema of the InfrastructureDefinition they patch from or
+// the resource they patch to.
+var CompositionPatchPathRule = Rule{
+	ID:          "composition-patch-path",
+	Description: "Composition patch field paths must exist in the schemas they patch between.",
+	Severity:    SeverityError,
+	Check:       checkCompositionPatchPath,
+}
+
+// CompositionPatchTypeRule reports Composition patches that copy a value into
+// a field of a different type.
+var CompositionPatchTypeRule = Rule{
+	ID:          "composition-patch-type",
+	Description: "Composition patches should not copy values into fields of a different type.",
+	Severity:    SeverityWarning,
+	Check:       checkCompositionPatchType,
+}
+
+// A patch is a Composition patch along with the schemas it patches between.
+// Either schema is nil if it could not be determined.
+type patch struct {
+	apiv1alpha1.Patch
+
+	path string
+	node *yaml.Node
+
+	from     *apiextensions.JSONSchemaProps
+	fromName string
+	to       *apiextensions.JSONSchemaProps
+	toName   string
+}
+
+// patches returns every patch in every Composition in the package.
+func patches(c *Context) []patch {
+	crds := c.CustomResourceDefinitions()
+	ps := []patch{}
+	for _, path := range compositionPaths(c) {
+		comp := c.Package.Compositions[path]
+		var from *apiextensions.JSONSchemaProps
+		var fromName string
+		if d, ok := c.Resolver().Resolve(comp.Spec.From); ok {
+			from, fromName = definitionSchema(d), fmt.Sprintf("InfrastructureDefinition %s", d.Name)
+		}
+		to := value(c.Node(path), "spec", "to")
+		for i, t := range comp.Spec.To {
+			base := value(index(to, i), "base")
+			kind := scalar(base, "kind")
+			var s *apiextensions.JSONSchemaProps
+			if gv, err := schema.ParseGroupVersion(scalar(base, "apiVersion")); err == nil {
+				s, _ = schemaFor(crds, gv.WithKind(kind))
+			}
+			pn := value(index(to, i), "patches")
+			for j, p := range t.Patches {
+				ps = append(ps, patch{
+					Patch:    p,
+					path:     path,
+					node:     index(pn, j),
+					from:     from,
+					fromName: fromName,
+					to:       s,
+					toName:   kind,
+				})
+			}
+		}
+	}
+	return ps
+}
+
+// definitionSchema returns the schema of the CustomResourceDefinition that
+// Crossplane generates for an InfrastructureDefinition, or nil if the
+// definition does not specify a schema.
+func definitionSchema(d apiv1alpha1.InfrastructureDefinition) *apiextensions.JSONSchemaProps {
+	if d.Spec.CRDSpecTemplate.Validation == nil {
+		return nil
+	}
+	crd, err := ccrd.New(ccrd.ForInfrastructureDefinition(&d))
+	if err != nil {
+		return nil
+	}
+	return topLevelSchema(*crd)
+}
+
+func checkCompositionPatchPath(c *Context) []Finding {
+	findings := []Finding{}
+	for _, p := range patches(c) {
+		for _, f := range []struct {
+			field  string
+			path   string
+			schema *apiextensions.JSONSchemaProps
+			name   string
+		}{
+			{field: "fromFieldPath", path: p.FromFieldPath, schema: p.from, name: p.fromName},
+			{field: "toFieldPath", path: p.ToFieldPath, schema: p.to, name: p.toName},
+		} {
+			if f.path == "" {
+				continue
+			}
+			key, val := lookup(p.node, f.field)
+			segments, err := fieldpath.Parse(f.path)
+			if err != nil {
+				findings = append(findings, Finding{
+					Path:    p.path,
+					Range:   rangeOfKey(key, val),
+					Message: fmt.Sprintf("%s %q is invalid: %s", f.field, f.path, err),
+				})
+				continue
+			}
+			if f.schema == nil {
+				continue
+			}
+			if _, err := schemaAt(f.schema, segments); err != nil {
+				findings = append(findings, Finding{
+					Path:    p.path,
+					Range:   rangeOfKey(key, val),
+					Message: fmt.Sprintf("%s %q is not defined by %s: %s", f.field, f.path, f.name, err),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+func checkCompositionPatchType(c *Context) []Finding {
+	findings := []Finding{}
+	for _, p := range patches(c) {
+		if p.from == nil || p.to == nil || p.ToFieldPath == "" {
+			continue
+		}
+		fs, ferr := fieldpath.Parse(p.FromFieldPath)
+		ts, terr := fieldpath.Parse(p.ToFieldPath)
+		if ferr != nil || terr != nil {
+			continue
+		}
+		from, ferr := schemaAt(p.from, fs)
+		to, terr := schemaAt(p.to, ts)
+		if ferr != nil || terr != nil || from == nil || to == nil {
+			continue
+		}
+		in := transformedType(typeOf(from), p.Transforms)
+		if in == "" || assignable(in, to) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Path:    p.path,
+			Range:   rangeOf(p.node),
+			Message: fmt.Sprintf("patch writes %s value from %q to %s field %q", in, p.FromFieldPath, typeOf(to), p.ToFieldPath),
+		})
+	}
+	return findings
+}
+
+// schemaAt returns the schema of the field at the supplied path. It returns a
+// nil schema if the field exists but its schema cannot be determined, for
+// example because it is beneath an object that preserves unknown fields.
+func schemaAt(s *apiextensions.JSONSchemaProps, segments fieldpath.Segments) (*apiextensions.JSONSchemaProps, error) {
+	for i, seg := range segments {
+		if s == nil || preservesUnknownFields(s) {
+			return nil, nil
+		}
+		switch seg.Type {
+		case fieldpath.SegmentField:
+			if p, ok := s.Properties[seg.Field]; ok {
+				s = &p
+				continue
+			}
+			if i == 0 && (seg.Field == "apiVersion" || seg.Field == "kind" || seg.Field == "metadata") {
+				// The API server validates the metadata of all objects.
+				return nil, nil
+			}
+			if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+				s = s.AdditionalProperties.Schema
+				continue
+			}
+			if (s.AdditionalProperties != nil && s.AdditionalProperties.Allows) || (s.Type == "object" && len(s.Properties) == 0 && s.AdditionalProperties == nil) {
+				return nil, nil
+			}
+			return nil, errors.Errorf("%s has no field %q", describe(segments[:i]), seg.Field)
+		case fieldpath.SegmentIndex:
+			if s.Type != "array" {
+				return nil, errors.Errorf("%s is not an array", describe(segments[:i]))
+			}
+			if s.Items == nil {
+				return nil, nil
+			}
+			s = s.Items.Schema
+		}
+	}
+	return s, nil
+}
+
+func describe(segments fieldpath.Segments) string {
+	if len(segments) == 0 {
+		return "object"
+	}
+	return fmt.Sprintf("%q", segments.String())
+}
+
+func typeOf(s *apiextensions.JSONSchemaProps) string {
+	switch {
+	case s.XIntOrString:
+		return "int-or-string"
+	case s.Type == "" && len(s.Properties) > 0:
+		return "object"
+	}
+	return s.Type
+}
+
+// transformedType returns the type of a value of type t after the supplied
+// transforms have been applied, or an empty string if it cannot be
+// determined.
+func transformedType(t string, transforms []apiv1alpha1.Transform) string {
+	for _, tr := range transforms {
+		switch tr.Type {
+		case apiv1alpha1.TransformTypeString, apiv1alpha1.TransformTypeMap:
+			t = "string"
+		case apiv1alpha1.TransformTypeMath:
+			if t != "integer" && t != "number" {
+				return ""
+			}
+		default:
+			return ""
+		}
+	}
+	return t
+}
+
+func assignable(from string, to *apiextensions.JSONSchemaProps) bool {
+	t := typeOf(to)
+	switch {
+	case t == "" || from == t || from == "int-or-string":
+		return true
+	case t == "number" && from == "integer":
+		return true
+	case t == "int-or-string" && (from == "integer" || from == "string"):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const bucketPatchComposition = `apiVersion: apiextensions.crossplane.io/v1alpha1
+kind: Composition
+metadata:
+  name: buckets
+spec:
+  from:
+    apiVersion: example.org/v1alpha1
+    kind: Bucket
+  to:
+  - base:
+      apiVersion: storage.example.org/v1alpha1
+      kind: Bucket
+    patches:
+    - fromFieldPath: spec.parameters.location
+      toFieldPath: spec.forProvider.location
+    - fromFieldPath: spec.parameters.locaton
+      toFieldPath: spec.forProvider.location
+    - fromFieldPath: metadata.labels[example.org/team]
+      toFieldPath: spec.forProvider.labels[team]
+    - fromFieldPath: spec.parameters.location
+      toFieldPath: spec.forProvider.location[0]
+    - fromFieldPath: spec.parameters.retain
+      toFieldPath: spec.forProvider.location
+    - fromFieldPath: spec.parameters.retain
+      toFieldPath: spec.forProvider.location
+      transforms:
+      - type: string
+        string:
+          fmt: "%t"
+    - fromFieldPath: spec.parameters.location
+      toFieldPath: spec.forProvider.versioning
+`
+
+func TestCompositionPatch(t *testing.T) {
+	pkg := parse(t, map[string]string{
+		"/pkg/definition.yaml":  bucketXRD,
+		"/pkg/composition.yaml": bucketPatchComposition,
+		"/pkg/crd.yaml":         bucketCRD,
+	})
+
+	got := []string{}
+	for _, f := range NewLinter(pkg, WithRules(CompositionPatchPathRule, CompositionPatchTypeRule)).Lint() {
+		got = append(got, f.Message)
+	}
+	want := []string{
+		`fromFieldPath "spec.parameters.locaton" is not defined by InfrastructureDefinition buckets.example.org: "spec.parameters" has no field "locaton"`,
+		`toFieldPath "spec.forProvider.location[0]" is not defined by Bucket: "spec.forProvider.location" is not an array`,
+		`patch writes boolean value from "spec.parameters.retain" to string field "spec.forProvider.location"`,
+		`patch writes string value from "spec.parameters.location" to boolean field "spec.forProvider.versioning"`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Lint(): -want, +got:\n%s", diff)
+	}
+}
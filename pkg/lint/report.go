@@ -0,0 +1,240 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/hasheddan/crank/pkg/prompt"
+)
+
+// A Reporter writes findings produced by the supplied rules.
+type Reporter func(w io.Writer, rules []Rule, findings []Finding) error
+
+// Reporters are the supported output formats.
+var Reporters = map[string]Reporter{
+	"text":  ReportText,
+	"json":  ReportJSON,
+	"sarif": ReportSARIF,
+	"junit": ReportJUnit,
+}
+
+// ReporterFor returns the Reporter for the named output format.
+func ReporterFor(format string) (Reporter, error) {
+	r, ok := Reporters[format]
+	if !ok {
+		formats := make([]string, 0, len(Reporters))
+		for f := range Reporters {
+			formats = append(formats, f)
+		}
+		sort.Strings(formats)
+		return nil, errors.Errorf("unknown output format %q: must be one of %s", format, strings.Join(formats, ", "))
+	}
+	return r, nil
+}
+
+// ReportText writes findings as human readable text.
+func ReportText(w io.Writer, _ []Rule, findings []Finding) error {
+	n := len(findings)
+	if n != 0 {
+		if _, err := fmt.Fprintln(w, prompt.FmtWarning(fmt.Sprintf("Found %d problems in package.", n))); err != nil {
+			return err
+		}
+	}
+	for i, f := range findings {
+		format := prompt.FmtError
+		if f.Severity == SeverityWarning {
+			format = prompt.FmtWarning
+		}
+		if _, err := fmt.Fprintln(w, format(fmt.Sprintf("[%d/%d] %s:%d:%d: %s (%s)", i+1, n, f.Path, f.Range.Start.Line, f.Range.Start.Column, f.Message, f.Rule))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReportJSON writes findings as a JSON document.
+func ReportJSON(w io.Writer, _ []Rule, findings []Finding) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Findings []Finding `json:"findings"`
+	}{Findings: findings})
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+// ReportSARIF writes findings as a SARIF 2.1.0 log.
+func ReportSARIF(w io.Writer, rules []Rule, findings []Finding) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "crank",
+			InformationURI: "https://github.com/hasheddan/crank",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	for _, r := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: r.ID, ShortDescription: sarifMessage{Text: r.Description}})
+	}
+	for _, f := range findings {
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  f.Rule,
+			Level:   string(f.Severity),
+			Message: sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: f.Path, URIBaseID: "%SRCROOT%"},
+				Region: sarifRegion{
+					StartLine:   f.Range.Start.Line,
+					StartColumn: f.Range.Start.Column,
+					EndLine:     f.Range.End.Line,
+					EndColumn:   f.Range.End.Column,
+				},
+			}}},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// ReportJUnit writes findings as a JUnit XML report with a test case for each
+// rule. A rule's test case fails if the rule reported any findings.
+func ReportJUnit(w io.Writer, rules []Rule, findings []Finding) error {
+	byRule := map[string][]Finding{}
+	for _, f := range findings {
+		byRule[f.Rule] = append(byRule[f.Rule], f)
+	}
+	suite := junitTestSuite{Name: "crank.lint", Tests: len(rules)}
+	for _, r := range rules {
+		tc := junitTestCase{Name: r.ID, ClassName: "crank.lint"}
+		if fs := byRule[r.ID]; len(fs) > 0 {
+			lines := make([]string, len(fs))
+			for i, f := range fs {
+				lines[i] = fmt.Sprintf("%s:%d:%d: %s", f.Path, f.Range.Start.Line, f.Range.Start.Column, f.Message)
+			}
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s reported %d findings", r.ID, len(fs)),
+				Type:    string(r.Severity),
+				Body:    strings.Join(lines, "\n"),
+			}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
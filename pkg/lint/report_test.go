@@ -0,0 +1,184 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "Update golden files.")
+
+var (
+	reportRules = []Rule{
+		{ID: "composition-definition", Description: "Compositions must compose an InfrastructureDefinition.", Severity: SeverityError},
+		{ID: "meta-ignore-examples", Description: "Examples should be ignored.", Severity: SeverityWarning},
+		{ID: "crd-name", Description: "CRD names must match their group and plural.", Severity: SeverityError},
+	}
+	reportFindings = []Finding{
+		{
+			Rule:     "composition-definition",
+			Severity: SeverityError,
+			Path:     "composition.yaml",
+			Range:    Range{Start: Position{Line: 7, Column: 5}, End: Position{Line: 7, Column: 24}},
+			Message:  "no InfrastructureDefinition defines example.org/v1alpha1, Kind=Bucket",
+		},
+		{
+			Rule:     "meta-ignore-examples",
+			Severity: SeverityWarning,
+			Path:     "crossplane.yaml",
+			Range:    Range{Start: Position{Line: 1, Column: 1}, End: Position{Line: 1, Column: 1}},
+			Message:  "examples/ is not ignored",
+			Fix: &Fix{
+				Description: "Ignore examples/",
+				Edits:       []Edit{{Range: Range{Start: Position{Line: 5, Column: 1}, End: Position{Line: 5, Column: 1}}, Text: "spec:\n  ignore:\n  - path: examples/\n"}},
+			},
+		},
+		{
+			Rule:     "composition-definition",
+			Severity: SeverityError,
+			Path:     "other/composition.yaml",
+			Range:    Range{Start: Position{Line: 9, Column: 5}, End: Position{Line: 9, Column: 22}},
+			Message:  "no InfrastructureDefinition defines example.org/v1alpha1, Kind=Queue",
+		},
+	}
+)
+
+func TestReporters(t *testing.T) {
+	cases := map[string]struct {
+		report   Reporter
+		golden   string
+		findings []Finding
+	}{
+		"JSON":       {report: ReportJSON, golden: "report.json", findings: reportFindings},
+		"JSONClean":  {report: ReportJSON, golden: "report-clean.json", findings: []Finding{}},
+		"SARIF":      {report: ReportSARIF, golden: "report.sarif", findings: reportFindings},
+		"SARIFClean": {report: ReportSARIF, golden: "report-clean.sarif", findings: []Finding{}},
+		"JUnit":      {report: ReportJUnit, golden: "report.xml", findings: reportFindings},
+		"JUnitClean": {report: ReportJUnit, golden: "report-clean.xml", findings: []Finding{}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := &bytes.Buffer{}
+			if err := tc.report(got, reportRules, tc.findings); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join("testdata", tc.golden)
+			if *update {
+				if err := ioutil.WriteFile(path, got.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(string(want), got.String()); diff != "" {
+				t.Errorf("%s: -want, +got:\n%s", tc.golden, diff)
+			}
+		})
+	}
+}
+
+// TestReportSARIFSchema checks the SARIF log against the properties that the
+// SARIF 2.1.0 schema requires, and that code scanning tools rely upon.
+func TestReportSARIFSchema(t *testing.T) {
+	b := &bytes.Buffer{}
+	if err := ReportSARIF(b, reportRules, reportFindings); err != nil {
+		t.Fatal(err)
+	}
+	log := map[string]interface{}{}
+	if err := json.Unmarshal(b.Bytes(), &log); err != nil {
+		t.Fatalf("ReportSARIF(...): invalid JSON: %v", err)
+	}
+	if log["version"] != "2.1.0" {
+		t.Errorf("ReportSARIF(...): want version 2.1.0, got %v", log["version"])
+	}
+	if _, ok := log["$schema"].(string); !ok {
+		t.Errorf("ReportSARIF(...): want $schema")
+	}
+	runs, ok := log["runs"].([]interface{})
+	if !ok || len(runs) != 1 {
+		t.Fatalf("ReportSARIF(...): want 1 run, got %v", log["runs"])
+	}
+	run := object(t, runs[0], "runs[0]")
+	driver := object(t, object(t, run["tool"], "tool")["driver"], "tool.driver")
+	if name, _ := driver["name"].(string); name == "" {
+		t.Errorf("ReportSARIF(...): want tool.driver.name")
+	}
+	ids := map[string]bool{}
+	for i, r := range array(t, driver["rules"], "tool.driver.rules") {
+		id, _ := object(t, r, "rule")["id"].(string)
+		if id == "" {
+			t.Errorf("ReportSARIF(...): rule %d has no id", i)
+		}
+		ids[id] = true
+	}
+	levels := map[string]bool{"none": true, "note": true, "warning": true, "error": true}
+	results := array(t, run["results"], "results")
+	if len(results) != len(reportFindings) {
+		t.Fatalf("ReportSARIF(...): want %d results, got %d", len(reportFindings), len(results))
+	}
+	for i, r := range results {
+		res := object(t, r, "result")
+		if id, _ := res["ruleId"].(string); !ids[id] {
+			t.Errorf("ReportSARIF(...): result %d has ruleId %q that is not a rule of the driver", i, id)
+		}
+		if l, _ := res["level"].(string); !levels[l] {
+			t.Errorf("ReportSARIF(...): result %d has invalid level %q", i, l)
+		}
+		if text, _ := object(t, res["message"], "message")["text"].(string); text == "" {
+			t.Errorf("ReportSARIF(...): result %d has no message text", i)
+		}
+		for _, l := range array(t, res["locations"], "locations") {
+			pl := object(t, object(t, l, "location")["physicalLocation"], "physicalLocation")
+			if uri, _ := object(t, pl["artifactLocation"], "artifactLocation")["uri"].(string); uri == "" {
+				t.Errorf("ReportSARIF(...): result %d has no artifact uri", i)
+			}
+			region := object(t, pl["region"], "region")
+			for _, k := range []string{"startLine", "startColumn", "endLine", "endColumn"} {
+				if n, _ := region[k].(float64); n < 1 {
+					t.Errorf("ReportSARIF(...): result %d has %s %v: must be at least 1", i, k, region[k])
+				}
+			}
+		}
+	}
+}
+
+func object(t *testing.T, v interface{}, name string) map[string]interface{} {
+	t.Helper()
+	o, ok := v.(map[string]interface{})
+	if !ok {
+		t.Fatalf("ReportSARIF(...): want %s to be an object, got %v", name, v)
+	}
+	return o
+}
+
+func array(t *testing.T, v interface{}, name string) []interface{} {
+	t.Helper()
+	a, ok := v.([]interface{})
+	if !ok {
+		t.Fatalf("ReportSARIF(...): want %s to be an array, got %v", name, v)
+	}
+	return a
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"sort"
+
+	apiv1alpha1 "github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type resolved struct {
+	apiv1alpha1.InfrastructureDefinition
+
+	// path is empty for definitions supplied by a dependency.
+	path string
+}
+
+// Resolver matches Compositions to the InfrastructureDefinitions they
+// satisfy.
+type Resolver struct {
+	defs       map[schema.GroupVersionKind]resolved
+	duplicates map[string]resolved
+}
+
+// NewResolver constructs a resolver for the supplied InfrastructureDefinitions,
+// which are keyed by the path of the file they were parsed from. If more than
+// one definition defines the same type the definition with the first path is
+// used, and the others are recorded as duplicates.
+func NewResolver(defs map[string]apiv1alpha1.InfrastructureDefinition) *Resolver {
+	r := &Resolver{defs: map[schema.GroupVersionKind]resolved{}, duplicates: map[string]resolved{}}
+	paths := make([]string, 0, len(defs))
+	for path := range defs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		d := defs[path]
+		if first, ok := r.defs[d.GetDefinedGroupVersionKind()]; ok {
+			r.duplicates[path] = first
+			continue
+		}
+		r.defs[d.GetDefinedGroupVersionKind()] = resolved{InfrastructureDefinition: d, path: path}
+	}
+	return r
+}
+
+// Duplicates returns the paths of the InfrastructureDefinitions that define
+// the same type as a definition with an earlier path, mapped to that
+// definition.
+func (r *Resolver) Duplicates() map[string]apiv1alpha1.InfrastructureDefinition {
+	dups := map[string]apiv1alpha1.InfrastructureDefinition{}
+	for path, d := range r.duplicates {
+		dups[path] = d.InfrastructureDefinition
+	}
+	return dups
+}
+
+// AddDependency adds the InfrastructureDefinitions of a dependency. They may
+// be satisfied by Compositions but are never reported as unsatisfied.
+func (r *Resolver) AddDependency(defs map[string]apiv1alpha1.InfrastructureDefinition) {
+	for _, d := range defs {
+		if _, ok := r.defs[d.GetDefinedGroupVersionKind()]; !ok {
+			r.defs[d.GetDefinedGroupVersionKind()] = resolved{InfrastructureDefinition: d}
+		}
+	}
+}
+
+// Resolve returns the InfrastructureDefinition that defines the referenced
+// type. The reference must match the group, version, and kind of the
+// definition.
+func (r *Resolver) Resolve(ref apiv1alpha1.TypeReference) (apiv1alpha1.InfrastructureDefinition, bool) {
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return apiv1alpha1.InfrastructureDefinition{}, false
+	}
+	d, ok := r.defs[gv.WithKind(ref.Kind)]
+	return d.InfrastructureDefinition, ok
+}
+
+// Defines returns true if an InfrastructureDefinition defines the kind.
+func (r *Resolver) Defines(gvk schema.GroupVersionKind) bool {
+	_, ok := r.defs[gvk]
+	return ok
+}
+
+// Kind returns every InfrastructureDefinition that defines the supplied kind,
+// regardless of group and version.
+func (r *Resolver) Kind(kind string) []apiv1alpha1.InfrastructureDefinition {
+	defs := []apiv1alpha1.InfrastructureDefinition{}
+	for gvk, d := range r.defs {
+		if gvk.Kind == kind {
+			defs = append(defs, d.InfrastructureDefinition)
+		}
+	}
+	return defs
+}
+
+// Unsatisfied returns the paths of all InfrastructureDefinitions that are not
+// satisfied by any of the supplied Compositions.
+func (r *Resolver) Unsatisfied(comps map[string]apiv1alpha1.Composition) []string {
+	satisfied := map[schema.GroupVersionKind]bool{}
+	for _, c := range comps {
+		if d, ok := r.Resolve(c.Spec.From); ok {
+			satisfied[d.GetDefinedGroupVersionKind()] = true
+		}
+	}
+	unsatisfied := []string{}
+	for gvk, d := range r.defs {
+		if d.path != "" && !satisfied[gvk] {
+			unsatisfied = append(unsatisfied, d.path)
+		}
+	}
+	sort.Strings(unsatisfied)
+	return unsatisfied
+}
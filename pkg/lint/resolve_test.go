@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"testing"
+
+	apiv1alpha1 "github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func definition(group, version, kind string) apiv1alpha1.InfrastructureDefinition {
+	d := apiv1alpha1.InfrastructureDefinition{}
+	d.Spec.CRDSpecTemplate.Group = group
+	d.Spec.CRDSpecTemplate.Version = version
+	d.Spec.CRDSpecTemplate.Names.Kind = kind
+	return d
+}
+
+func composition(apiVersion, kind string) apiv1alpha1.Composition {
+	c := apiv1alpha1.Composition{}
+	c.Spec.From = apiv1alpha1.TypeReference{APIVersion: apiVersion, Kind: kind}
+	return c
+}
+
+func TestResolve(t *testing.T) {
+	r := NewResolver(map[string]apiv1alpha1.InfrastructureDefinition{
+		"/pkg/mysql.yaml":    definition("database.example.org", "v1alpha1", "MySQLInstance"),
+		"/pkg/postgres.yaml": definition("database.example.org", "v1alpha1", "PostgreSQLInstance"),
+	})
+	r.AddDependency(map[string]apiv1alpha1.InfrastructureDefinition{
+		".registry/redis.yaml": definition("cache.example.org", "v1alpha1", "RedisCluster"),
+	})
+	cases := map[string]struct {
+		ref  apiv1alpha1.TypeReference
+		kind string
+		ok   bool
+	}{
+		"Match":        {ref: apiv1alpha1.TypeReference{APIVersion: "database.example.org/v1alpha1", Kind: "MySQLInstance"}, kind: "MySQLInstance", ok: true},
+		"Dependency":   {ref: apiv1alpha1.TypeReference{APIVersion: "cache.example.org/v1alpha1", Kind: "RedisCluster"}, kind: "RedisCluster", ok: true},
+		"WrongVersion": {ref: apiv1alpha1.TypeReference{APIVersion: "database.example.org/v1beta1", Kind: "MySQLInstance"}},
+		"WrongGroup":   {ref: apiv1alpha1.TypeReference{APIVersion: "cache.example.org/v1alpha1", Kind: "MySQLInstance"}},
+		"WrongKind":    {ref: apiv1alpha1.TypeReference{APIVersion: "database.example.org/v1alpha1", Kind: "mysqlinstance"}},
+		"BadVersion":   {ref: apiv1alpha1.TypeReference{APIVersion: "a/b/c", Kind: "MySQLInstance"}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			d, ok := r.Resolve(tc.ref)
+			if kind := d.Spec.CRDSpecTemplate.Names.Kind; kind != tc.kind || ok != tc.ok {
+				t.Fatalf("Resolve(...): want (%q, %t), got (%q, %t)", tc.kind, tc.ok, kind, ok)
+			}
+		})
+	}
+
+	got := r.Unsatisfied(map[string]apiv1alpha1.Composition{
+		"/pkg/composition.yaml": composition("database.example.org/v1alpha1", "MySQLInstance"),
+	})
+	if diff := cmp.Diff([]string{"/pkg/postgres.yaml"}, got); diff != "" {
+		t.Fatalf("Unsatisfied(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestResolveDuplicates(t *testing.T) {
+	first, second := definition("database.example.org", "v1alpha1", "MySQLInstance"), definition("database.example.org", "v1alpha1", "MySQLInstance")
+	first.Name, second.Name = "first", "second"
+	r := NewResolver(map[string]apiv1alpha1.InfrastructureDefinition{
+		"/pkg/b.yaml": second,
+		"/pkg/a.yaml": first,
+	})
+	if diff := cmp.Diff(map[string]apiv1alpha1.InfrastructureDefinition{"/pkg/b.yaml": first}, r.Duplicates()); diff != "" {
+		t.Errorf("Duplicates(): -want, +got:\n%s", diff)
+	}
+	got := r.Unsatisfied(map[string]apiv1alpha1.Composition{
+		"/pkg/composition.yaml": composition("database.example.org/v1alpha1", "MySQLInstance"),
+	})
+	if len(got) != 0 {
+		t.Errorf("Unsatisfied(...): want none, got %v", got)
+	}
+}
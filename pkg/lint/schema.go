@@ -0,0 +1,277 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// schemaFor returns the OpenAPI schema of the CustomResourceDefinition that
+// serves the supplied kind. The returned schema is nil if the kind is served
+// but has no schema.
+func schemaFor(crds []apiextensions.CustomResourceDefinition, gvk schema.GroupVersionKind) (*apiextensions.JSONSchemaProps, bool) {
+	for _, crd := range crds {
+		if crd.Spec.Group != gvk.Group || crd.Spec.Names.Kind != gvk.Kind {
+			continue
+		}
+		if len(crd.Spec.Versions) == 0 && crd.Spec.Version == gvk.Version {
+			return topLevelSchema(crd), true
+		}
+		for _, v := range crd.Spec.Versions {
+			if v.Name != gvk.Version {
+				continue
+			}
+			if v.Schema != nil && v.Schema.OpenAPIV3Schema != nil {
+				return v.Schema.OpenAPIV3Schema, true
+			}
+			return topLevelSchema(crd), true
+		}
+	}
+	return nil, false
+}
+
+func topLevelSchema(crd apiextensions.CustomResourceDefinition) *apiextensions.JSONSchemaProps {
+	if crd.Spec.Validation == nil {
+		return nil
+	}
+	return crd.Spec.Validation.OpenAPIV3Schema
+}
+
+// A violation is a node that does not conform to a schema.
+type violation struct {
+	key     *yaml.Node
+	node    *yaml.Node
+	message string
+}
+
+// validate validates the object rooted at n against schema s. Required fields
+// are not reported missing if they are written by one of the patched field
+// paths.
+func validate(s *apiextensions.JSONSchemaProps, n *yaml.Node, path string, patched []string) []violation {
+	return validateNode(s, nil, n, path, patched, true)
+}
+
+// nolint:gocyclo
+func validateNode(s *apiextensions.JSONSchemaProps, key, n *yaml.Node, path string, patched []string, root bool) []violation {
+	if s == nil || n == nil {
+		return nil
+	}
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.ShortTag() == "!!null" {
+		return nil
+	}
+	at := func(msg string, args ...interface{}) []violation {
+		m := fmt.Sprintf(msg, args...)
+		if path != "" {
+			m = fmt.Sprintf("%s: %s", path, m)
+		}
+		return []violation{{key: key, node: n, message: m}}
+	}
+	if s.XIntOrString {
+		if t := n.ShortTag(); t != "!!int" && t != "!!str" {
+			return at("must be an integer or string")
+		}
+		return nil
+	}
+
+	t := s.Type
+	if t == "" && len(s.Properties) > 0 {
+		t = "object"
+	}
+	switch t {
+	case "object":
+		if n.Kind != yaml.MappingNode {
+			return at("must be an object")
+		}
+	case "array":
+		if n.Kind != yaml.SequenceNode {
+			return at("must be an array")
+		}
+	case "string":
+		if n.Kind != yaml.ScalarNode || n.ShortTag() != "!!str" {
+			return at("must be a string")
+		}
+	case "integer":
+		if n.Kind != yaml.ScalarNode || n.ShortTag() != "!!int" {
+			return at("must be an integer")
+		}
+	case "number":
+		if n.Kind != yaml.ScalarNode || (n.ShortTag() != "!!int" && n.ShortTag() != "!!float") {
+			return at("must be a number")
+		}
+	case "boolean":
+		if n.Kind != yaml.ScalarNode || n.ShortTag() != "!!bool" {
+			return at("must be a boolean")
+		}
+	}
+
+	if len(s.Enum) > 0 && n.Kind == yaml.ScalarNode && !inEnum(n.Value, s.Enum) {
+		return at("must be one of %s", enumString(s.Enum))
+	}
+
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return validateScalar(s, n, at)
+	case yaml.SequenceNode:
+		if s.MinItems != nil && int64(len(n.Content)) < *s.MinItems {
+			return at("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && int64(len(n.Content)) > *s.MaxItems {
+			return at("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items == nil || s.Items.Schema == nil {
+			return nil
+		}
+		vs := []violation{}
+		for i, item := range n.Content {
+			vs = append(vs, validateNode(s.Items.Schema, nil, item, fmt.Sprintf("%s[%d]", path, i), patched, false)...)
+		}
+		return vs
+	case yaml.MappingNode:
+		return validateObject(s, key, n, path, patched, root)
+	}
+	return nil
+}
+
+func validateScalar(s *apiextensions.JSONSchemaProps, n *yaml.Node, at func(string, ...interface{}) []violation) []violation {
+	switch n.ShortTag() {
+	case "!!str":
+		if s.MinLength != nil && int64(len(n.Value)) < *s.MinLength {
+			return at("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && int64(len(n.Value)) > *s.MaxLength {
+			return at("must be at most %d characters", *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			if re, err := regexp.Compile(s.Pattern); err == nil && !re.MatchString(n.Value) {
+				return at("must match pattern %q", s.Pattern)
+			}
+		}
+	case "!!int", "!!float":
+		f, err := strconv.ParseFloat(n.Value, 64)
+		if err != nil {
+			return nil
+		}
+		if s.Minimum != nil && (f < *s.Minimum || (s.ExclusiveMinimum && f == *s.Minimum)) {
+			return at("must be %s %v", bound("greater than", s.ExclusiveMinimum), *s.Minimum)
+		}
+		if s.Maximum != nil && (f > *s.Maximum || (s.ExclusiveMaximum && f == *s.Maximum)) {
+			return at("must be %s %v", bound("less than", s.ExclusiveMaximum), *s.Maximum)
+		}
+	}
+	return nil
+}
+
+func validateObject(s *apiextensions.JSONSchemaProps, key, n *yaml.Node, path string, patched []string, root bool) []violation {
+	vs := []violation{}
+	present := map[string]bool{}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		present[k.Value] = true
+		child := fieldPath(path, k.Value)
+		if root && (k.Value == "apiVersion" || k.Value == "kind" || k.Value == "metadata") {
+			continue
+		}
+		if p, ok := s.Properties[k.Value]; ok {
+			vs = append(vs, validateNode(&p, k, v, child, patched, false)...)
+			continue
+		}
+		if s.AdditionalProperties != nil {
+			if s.AdditionalProperties.Schema != nil {
+				vs = append(vs, validateNode(s.AdditionalProperties.Schema, k, v, child, patched, false)...)
+				continue
+			}
+			if s.AdditionalProperties.Allows {
+				continue
+			}
+		}
+		if preservesUnknownFields(s) || (len(s.Properties) == 0 && s.AdditionalProperties == nil) {
+			continue
+		}
+		vs = append(vs, violation{key: k, node: v, message: fmt.Sprintf("%s: unknown field", child)})
+	}
+	for _, r := range s.Required {
+		if present[r] || isPatched(fieldPath(path, r), patched) {
+			continue
+		}
+		m := fmt.Sprintf("missing required field %q", r)
+		if path != "" {
+			m = fmt.Sprintf("%s: %s", path, m)
+		}
+		vs = append(vs, violation{key: key, node: n, message: m})
+	}
+	return vs
+}
+
+func preservesUnknownFields(s *apiextensions.JSONSchemaProps) bool {
+	return (s.XPreserveUnknownFields != nil && *s.XPreserveUnknownFields) || s.XEmbeddedResource
+}
+
+// fieldPath appends a field to a field path, using bracket notation for
+// fields that contain a period.
+func fieldPath(path, field string) string {
+	switch {
+	case strings.Contains(field, "."):
+		return fmt.Sprintf("%s[%s]", path, field)
+	case path == "":
+		return field
+	default:
+		return path + "." + field
+	}
+}
+
+func inEnum(v string, enum []apiextensions.JSON) bool {
+	for _, e := range enum {
+		if enumValue(e) == v {
+			return true
+		}
+	}
+	return false
+}
+
+func enumValue(e apiextensions.JSON) string {
+	var v interface{}
+	if err := json.Unmarshal(e.Raw, &v); err != nil {
+		return string(e.Raw)
+	}
+	return fmt.Sprint(v)
+}
+
+func enumString(enum []apiextensions.JSON) string {
+	vals := make([]string, len(enum))
+	for i, e := range enum {
+		vals[i] = enumValue(e)
+	}
+	return "[" + strings.Join(vals, ", ") + "]"
+}
+
+func bound(comparison string, exclusive bool) string {
+	if exclusive {
+		return comparison
+	}
+	return comparison + " or equal to"
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseNode parses a YAML document and returns its root node.
+func parseNode(b []byte) (*yaml.Node, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(b, doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		return doc.Content[0], nil
+	}
+	return doc, nil
+}
+
+// lookup walks a chain of mapping keys starting at n and returns the key and
+// value nodes of the last key. Both are nil if any key is not found.
+func lookup(n *yaml.Node, keys ...string) (*yaml.Node, *yaml.Node) {
+	var key *yaml.Node
+	for _, k := range keys {
+		if n == nil || n.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == k {
+				key, next = n.Content[i], n.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil, nil
+		}
+		n = next
+	}
+	return key, n
+}
+
+// value returns the value node at the chain of mapping keys, or nil.
+func value(n *yaml.Node, keys ...string) *yaml.Node {
+	_, v := lookup(n, keys...)
+	return v
+}
+
+// index returns the i-th element of a sequence node, or nil.
+func index(n *yaml.Node, i int) *yaml.Node {
+	if n == nil || n.Kind != yaml.SequenceNode || i < 0 || i >= len(n.Content) {
+		return nil
+	}
+	return n.Content[i]
+}
+
+// scalar returns the value of a scalar node at the chain of mapping keys.
+func scalar(n *yaml.Node, keys ...string) string {
+	v := value(n, keys...)
+	if v == nil || v.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return v.Value
+}
+
+// rangeOf returns the source range spanned by a node.
+func rangeOf(n *yaml.Node) Range {
+	if n == nil {
+		return Range{Start: Position{Line: 1, Column: 1}, End: Position{Line: 1, Column: 1}}
+	}
+	return Range{Start: Position{Line: n.Line, Column: n.Column}, End: endOf(n)}
+}
+
+// rangeOfKey returns the source range spanning a mapping key and its value.
+func rangeOfKey(key, val *yaml.Node) Range {
+	if key == nil {
+		return rangeOf(val)
+	}
+	r := rangeOf(key)
+	if val != nil {
+		r.End = endOf(val)
+	}
+	return r
+}
+
+func endOf(n *yaml.Node) Position {
+	if len(n.Content) > 0 {
+		return endOf(n.Content[len(n.Content)-1])
+	}
+	if n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 || strings.Contains(n.Value, "\n") {
+		// Multi-line scalars are only reported on the line they begin on.
+		return Position{Line: n.Line, Column: n.Column + 1}
+	}
+	width := utf8.RuneCountInString(n.Value)
+	if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+		width += 2
+	}
+	return Position{Line: n.Line, Column: n.Column + width}
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lock validates PackageLocks, and plans changes to them, without a
+// cluster.
+package lock
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/dag"
+)
+
+// Read parses a PackageLock from YAML.
+func Read(b []byte) (*v1alpha1.PackageLock, error) {
+	l := &v1alpha1.PackageLock{}
+	if err := yaml.Unmarshal(b, l); err != nil {
+		return nil, errors.Wrap(err, "cannot parse PackageLock")
+	}
+	if l.Kind != v1alpha1.PackageLockKind {
+		return nil, errors.Errorf("cannot parse PackageLock: kind is %q", l.Kind)
+	}
+	return l, nil
+}
+
+// Key returns the node of the package installed from the supplied image in
+// the dependency graph of a PackageLock, which is the image without its tag.
+func Key(image string) string {
+	return strings.Split(image, ":")[0]
+}
+
+// A Problem prevents a PackageLock from being used.
+type Problem struct {
+	// Path of dependencies that leads to the problem.
+	Path []string `json:"path"`
+
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %s", strings.Join(p.Path, " -> "), p.Message)
+}
+
+// Validate returns the problems that prevent the package manager from
+// building a dependency graph of the supplied packages. Missing dependencies
+// are reported with the path from every package that requires them, and
+// cycles with the path around the cycle.
+func Validate(pkgs map[string]v1alpha1.PackageDependencies) []Problem {
+	d, missing := graph(pkgs)
+	problems := []Problem{}
+	for _, k := range duplicates(pkgs) {
+		problems = append(problems, Problem{Path: []string{k}, Message: "package is in the PackageLock more than once"})
+	}
+	for _, m := range missing {
+		for _, p := range d.Paths(m) {
+			problems = append(problems, Problem{Path: p, Message: "missing dependency " + m})
+		}
+	}
+	for _, c := range d.Cycles() {
+		problems = append(problems, Problem{Path: c, Message: "dependency cycle"})
+	}
+	return problems
+}
+
+// Graph returns the dependency graph of the supplied packages. Dependencies
+// that are missing from the packages are nodes without dependencies.
+func Graph(pkgs map[string]v1alpha1.PackageDependencies) *dag.Dag {
+	d, _ := graph(pkgs)
+	return d
+}
+
+// graph returns the dependency graph of the supplied packages, and the
+// dependencies that are missing from it in order. The missing dependencies
+// are added to the graph as nodes without dependencies.
+func graph(pkgs map[string]v1alpha1.PackageDependencies) (*dag.Dag, []string) {
+	d, _ := dag.New(nil)
+	for _, p := range pkgs {
+		_ = d.AddNode(Key(p.Image))
+	}
+	missing := []string{}
+	for _, p := range pkgs {
+		for _, dep := range p.Dependencies {
+			if !d.NodeExists(dep) {
+				_ = d.AddNode(dep)
+				missing = append(missing, dep)
+			}
+		}
+	}
+	for _, p := range pkgs {
+		_ = d.AddEdges(map[string][]string{Key(p.Image): p.Dependencies})
+	}
+	sort.Strings(missing)
+	return d, missing
+}
+
+// duplicates returns the nodes of the supplied packages that more than one
+// package would be installed as, in order.
+func duplicates(pkgs map[string]v1alpha1.PackageDependencies) []string {
+	seen := map[string]int{}
+	for _, p := range pkgs {
+		seen[Key(p.Image)]++
+	}
+	dups := []string{}
+	for k, n := range seen {
+		if n > 1 {
+			dups = append(dups, k)
+		}
+	}
+	sort.Strings(dups)
+	return dups
+}
+
+// A Package is to be added to a PackageLock.
+type Package struct {
+	// Image from which the package is installed.
+	Image string `json:"image"`
+
+	// Dependencies of the package, as they would be recorded in the
+	// PackageLock.
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// A BlockedPackage cannot be added to a PackageLock.
+type BlockedPackage struct {
+	Package `json:",inline"`
+
+	// Problems that block the package.
+	Problems []Problem `json:"problems"`
+}
+
+// A Plan describes how the package manager would add packages to a
+// PackageLock.
+type Plan struct {
+	// Install are the packages that would be installed, in order.
+	Install []Package `json:"install"`
+
+	// Unchanged are the packages that are already in the PackageLock.
+	Unchanged []Package `json:"unchanged"`
+
+	// Blocked are the packages that would never be installed.
+	Blocked []BlockedPackage `json:"blocked"`
+}
+
+// NewPlan plans how the package manager would add the supplied packages to
+// the PackageLock. A package is added once every package it depends on is in
+// the PackageLock, so packages are installed in rounds, and a package that
+// depends on a package that is never added is blocked. It returns an error if
+// the PackageLock is invalid.
+func NewPlan(l *v1alpha1.PackageLock, add []Package) (*Plan, error) {
+	if problems := Validate(l.Spec.Packages); len(problems) > 0 {
+		return nil, errors.Errorf("PackageLock is invalid: %s", problems[0])
+	}
+	pkgs := map[string]v1alpha1.PackageDependencies{}
+	for k, p := range l.Spec.Packages {
+		pkgs[k] = p
+	}
+	d, _ := graph(pkgs)
+
+	plan := &Plan{Install: []Package{}, Unchanged: []Package{}, Blocked: []BlockedPackage{}}
+	pending := []Package{}
+	for _, p := range add {
+		if d.NodeExists(Key(p.Image)) {
+			plan.Unchanged = append(plan.Unchanged, p)
+			continue
+		}
+		pending = append(pending, p)
+	}
+	for progress := true; progress; {
+		progress = false
+		blocked := []Package{}
+		for _, p := range pending {
+			if d.NodeExists(Key(p.Image)) {
+				// Another image of the package was added first.
+				plan.Unchanged = append(plan.Unchanged, p)
+				continue
+			}
+			if !installed(d, p.Dependencies) {
+				blocked = append(blocked, p)
+				continue
+			}
+			_ = d.AddNode(Key(p.Image))
+			_ = d.AddEdges(map[string][]string{Key(p.Image): p.Dependencies})
+			pkgs[Key(p.Image)] = v1alpha1.PackageDependencies{Image: p.Image, Dependencies: p.Dependencies}
+			plan.Install = append(plan.Install, p)
+			progress = true
+		}
+		pending = blocked
+	}
+
+	// The problems of blocked packages are those of the PackageLock that
+	// would include them.
+	for _, p := range pending {
+		if _, ok := pkgs[Key(p.Image)]; !ok {
+			pkgs[Key(p.Image)] = v1alpha1.PackageDependencies{Image: p.Image, Dependencies: p.Dependencies}
+		}
+	}
+	problems := Validate(pkgs)
+	for _, p := range pending {
+		b := BlockedPackage{Package: p, Problems: []Problem{}}
+		for _, pr := range problems {
+			if contains(pr.Path, Key(p.Image)) {
+				b.Problems = append(b.Problems, pr)
+			}
+		}
+		if len(b.Problems) == 0 {
+			for _, dep := range p.Dependencies {
+				if !d.NodeExists(dep) {
+					b.Problems = append(b.Problems, Problem{Path: []string{Key(p.Image), dep}, Message: "dependency " + dep + " is blocked"})
+				}
+			}
+		}
+		plan.Blocked = append(plan.Blocked, b)
+	}
+	return plan, nil
+}
+
+// installed returns true if every supplied dependency is in the graph.
+func installed(d *dag.Dag, deps []string) bool {
+	for _, dep := range deps {
+		if !d.NodeExists(dep) {
+			return false
+		}
+	}
+	return true
+}
+
+// contains returns true if s is one of ss.
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
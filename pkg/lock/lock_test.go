@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lock
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+)
+
+func read(t *testing.T, file string) *v1alpha1.PackageLock {
+	b, err := ioutil.ReadFile(filepath.Join("..", "..", "examples", "manifests", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestValidate(t *testing.T) {
+	cases := map[string]struct {
+		pkgs map[string]v1alpha1.PackageDependencies
+		want []Problem
+	}{
+		"Valid": {
+			pkgs: read(t, "packagelock-valid.yaml").Spec.Packages,
+			want: []Problem{},
+		},
+		"Bare": {
+			pkgs: read(t, "packagelock-bare.yaml").Spec.Packages,
+			want: []Problem{},
+		},
+		"Cycle": {
+			pkgs: read(t, "packagelock-invalid.yaml").Spec.Packages,
+			want: []Problem{
+				{Path: []string{"test-2-image", "test-3-image", "test-2-image"}, Message: "dependency cycle"},
+			},
+		},
+		"Missing": {
+			pkgs: map[string]v1alpha1.PackageDependencies{
+				"acme/platform":           {Image: "acme/platform:v1", Dependencies: []string{"crossplane/provider-gcp", "acme/network"}},
+				"acme/network":            {Image: "acme/network:v1", Dependencies: []string{"crossplane/provider-gcp"}},
+				"crossplane/provider-gcp": {Image: "crossplane/provider-gcp:v0.12.0", Dependencies: []string{"crossplane/provider-helm"}},
+			},
+			want: []Problem{
+				{Path: []string{"acme/platform", "acme/network", "crossplane/provider-gcp", "crossplane/provider-helm"}, Message: "missing dependency crossplane/provider-helm"},
+				{Path: []string{"acme/platform", "crossplane/provider-gcp", "crossplane/provider-helm"}, Message: "missing dependency crossplane/provider-helm"},
+			},
+		},
+		"Duplicate": {
+			pkgs: map[string]v1alpha1.PackageDependencies{
+				"crossplane/provider-gcp":  {Image: "crossplane/provider-gcp:v0.12.0"},
+				"crossplane/provider-gcp2": {Image: "crossplane/provider-gcp:v0.11.0"},
+			},
+			want: []Problem{
+				{Path: []string{"crossplane/provider-gcp"}, Message: "package is in the PackageLock more than once"},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, Validate(tc.pkgs)); diff != "" {
+				t.Errorf("Validate(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewPlan(t *testing.T) {
+	l := read(t, "packagelock-valid.yaml")
+	add := []Package{
+		{Image: "acme/platform:v1", Dependencies: []string{"acme/network", "test-1-image"}},
+		{Image: "acme/network:v1", Dependencies: []string{"test-2-image"}},
+		{Image: "acme/storage:v1", Dependencies: []string{"acme/backup"}},
+		{Image: "acme/database:v1", Dependencies: []string{"acme/storage"}},
+		{Image: "test-3-image:v2"},
+	}
+	got, err := NewPlan(l, add)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Plan{
+		Install:   []Package{add[1], add[0]},
+		Unchanged: []Package{add[4]},
+		Blocked: []BlockedPackage{
+			{Package: add[2], Problems: []Problem{
+				{Path: []string{"acme/database", "acme/storage", "acme/backup"}, Message: "missing dependency acme/backup"},
+			}},
+			{Package: add[3], Problems: []Problem{
+				{Path: []string{"acme/database", "acme/storage", "acme/backup"}, Message: "missing dependency acme/backup"},
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("NewPlan(...): -want, +got:\n%s", diff)
+	}
+
+	if _, err := NewPlan(read(t, "packagelock-invalid.yaml"), add); err == nil {
+		t.Errorf("NewPlan(...): want error for an invalid PackageLock")
+	}
+}
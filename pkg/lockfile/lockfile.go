@@ -0,0 +1,231 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lockfile records the digests that the dependencies of a package
+// under development resolved to, so that they resolve to the same digests
+// until they are explicitly updated.
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/parser"
+	"github.com/hasheddan/crank/pkg/unpack"
+)
+
+// File is the name of the lockfile, which is written next to crossplane.yaml.
+const File = "crank.lock"
+
+// Type metadata of a Lockfile.
+const (
+	APIVersion = "crank.crossplane.io/v1alpha1"
+	Kind       = "Lockfile"
+)
+
+// A Lockfile records the digest of every package in the dependency closure of
+// a package.
+type Lockfile struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	// Packages in the dependency closure, sorted by package.
+	Packages []Package `json:"packages"`
+}
+
+// A Package in the dependency closure.
+type Package struct {
+	// Package is the repository of the package, e.g. crossplane/provider-gcp.
+	Package string `json:"package"`
+
+	// Version of the package required by crossplane.yaml or a dependency.
+	Version string `json:"version,omitempty"`
+
+	// Digest the version resolved to.
+	Digest string `json:"digest"`
+
+	// Dependencies are the images, including versions, of the packages this
+	// package depends on.
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// Image returns the image of the package, pinned to its digest.
+func (p Package) Image() string {
+	return p.Package + "@" + p.Digest
+}
+
+// Read reads the lockfile in the supplied directory. It returns nil if there
+// is no lockfile.
+func Read(fs afero.Fs, dir string) (*Lockfile, error) {
+	b, err := afero.ReadFile(fs, filepath.Join(dir, File))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read %s", File)
+	}
+	l := &Lockfile{}
+	if err := yaml.Unmarshal(b, l); err != nil {
+		return nil, errors.Wrapf(err, "cannot parse %s", File)
+	}
+	if l.APIVersion != APIVersion || l.Kind != Kind {
+		return nil, errors.Errorf("cannot parse %s: want apiVersion %s and kind %s", File, APIVersion, Kind)
+	}
+	return l, nil
+}
+
+// Write writes the lockfile to the supplied directory.
+func Write(fs afero.Fs, dir string, l *Lockfile) error {
+	b, err := yaml.Marshal(l)
+	if err != nil {
+		return errors.Wrapf(err, "cannot marshal %s", File)
+	}
+	return errors.Wrapf(afero.WriteFile(fs, filepath.Join(dir, File), b, 0644), "cannot write %s", File)
+}
+
+// Get returns the locked package with the supplied repository.
+func (l *Lockfile) Get(pkg string) (Package, bool) {
+	for _, p := range l.Packages {
+		if p.Package == pkg {
+			return p, true
+		}
+	}
+	return Package{}, false
+}
+
+// Pin returns the supplied dependencies pinned to their locked digests. It
+// returns an error if the lockfile does not satisfy the dependencies, in which
+// case it must be resolved again.
+func (l *Lockfile) Pin(deps []parser.MetaDependency) ([]v1alpha1.Dependency, error) {
+	pinned := make([]v1alpha1.Dependency, 0, len(deps))
+	for _, d := range deps {
+		p, ok := l.Get(d.Package)
+		if !ok || p.Version != d.Version {
+			return nil, errors.Errorf("%s is out of date: %s is not locked; run crank package resolve", File, d.Image())
+		}
+		pinned = append(pinned, v1alpha1.Dependency{Package: p.Image()})
+	}
+	return pinned, nil
+}
+
+// Closure returns every package in the dependency closure of the supplied
+// dependencies, pinned to its locked digest. It returns an error if the
+// lockfile does not satisfy the dependencies, in which case it must be
+// resolved again.
+func (l *Lockfile) Closure(deps []parser.MetaDependency) ([]v1alpha1.Dependency, error) {
+	if _, err := l.Pin(deps); err != nil {
+		return nil, err
+	}
+	closure := make([]v1alpha1.Dependency, 0, len(l.Packages))
+	for _, p := range l.Packages {
+		closure = append(closure, v1alpha1.Dependency{Package: p.Image()})
+	}
+	return closure, nil
+}
+
+// Resolve walks the dependency closure of the supplied dependencies, resolving
+// each version to a digest, and returns a lockfile of the closure. Packages
+// whose version is locked by previous are not resolved again, so previous may
+// be nil to resolve every package. It returns an error if two versions of a
+// package are required.
+func Resolve(f *unpack.Fetcher, deps []parser.MetaDependency, previous *Lockfile) (*Lockfile, error) {
+	if previous == nil {
+		previous = &Lockfile{}
+	}
+	l := &Lockfile{APIVersion: APIVersion, Kind: Kind, Packages: []Package{}}
+	required := map[string]string{}
+	queue := []requirement{}
+	for _, d := range deps {
+		queue = append(queue, requirement{MetaDependency: d, by: "crossplane.yaml"})
+	}
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+		if by, ok := required[r.Package]; ok {
+			p, _ := l.Get(r.Package)
+			if p.Version != r.Version {
+				return nil, errors.Errorf("conflicting versions of %s: %s required by %s, %s required by %s", r.Package, p.Version, by, r.Version, r.by)
+			}
+			continue
+		}
+		required[r.Package] = r.by
+
+		p, ok := previous.Get(r.Package)
+		if !ok || p.Version != r.Version {
+			var err error
+			if p, err = resolve(f, r.MetaDependency); err != nil {
+				return nil, err
+			}
+		}
+		l.Packages = append(l.Packages, p)
+		for _, dep := range p.Dependencies {
+			queue = append(queue, requirement{MetaDependency: parser.MetaDependency{Package: repository(dep), Version: version(dep)}, by: r.Package})
+		}
+	}
+	sort.Slice(l.Packages, func(i, j int) bool { return l.Packages[i].Package < l.Packages[j].Package })
+	return l, nil
+}
+
+// A requirement of a version of a package.
+type requirement struct {
+	parser.MetaDependency
+
+	// by is what requires the package.
+	by string
+}
+
+// resolve resolves the digest of the supplied dependency, and the dependencies
+// of the package at that digest.
+func resolve(f *unpack.Fetcher, d parser.MetaDependency) (Package, error) {
+	digest, err := f.Digest(d.Image())
+	if err != nil {
+		return Package{}, err
+	}
+	p := Package{Package: d.Package, Version: d.Version, Digest: digest}
+	pkg, err := f.Package(p.Image())
+	if err != nil {
+		return Package{}, err
+	}
+	if pkg.Meta != nil {
+		for _, dep := range pkg.Meta.Spec.DependsOn {
+			p.Dependencies = append(p.Dependencies, dep.Image())
+		}
+	}
+	return p, nil
+}
+
+// repository returns the image without its tag.
+func repository(image string) string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+	return image
+}
+
+// version returns the tag of the image, or an empty string if it has none.
+func version(image string) string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lockfile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/parser"
+	"github.com/hasheddan/crank/pkg/unpack"
+)
+
+// registry is a fake registry of packages, keyed by image. Each package lists
+// the images it depends on.
+type registry struct {
+	digests  map[string]string
+	deps     map[string][]string
+	resolved []string
+}
+
+func (r *registry) fetcher() *unpack.Fetcher {
+	return unpack.NewFetcher(
+		unpack.WithDigestFn(func(image string) (string, error) {
+			if i := strings.LastIndex(image, "@"); i != -1 {
+				return image[i+1:], nil
+			}
+			r.resolved = append(r.resolved, image)
+			return r.digests[image], nil
+		}),
+		unpack.WithPullFn(func(image string) (afero.Fs, string, error) {
+			meta := "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Provider\nmetadata:\n  name: p\nspec:\n  dependsOn:\n"
+			for img, d := range r.digests {
+				if !strings.HasSuffix(image, "@"+d) {
+					continue
+				}
+				for _, dep := range r.deps[img] {
+					meta += "  - package: " + repository(dep) + "\n    version: " + version(dep) + "\n"
+				}
+			}
+			fs := afero.NewMemMapFs()
+			return fs, image[strings.LastIndex(image, "@")+1:], afero.WriteFile(fs, ".registry/crossplane.yaml", []byte(meta), 0644)
+		}),
+	)
+}
+
+func digest(c string) string {
+	return "sha256:" + strings.Repeat(c, 64)
+}
+
+func TestResolve(t *testing.T) {
+	r := &registry{
+		digests: map[string]string{
+			"acme/network:v1":                 digest("a"),
+			"crossplane/provider-gcp:v0.12.0": digest("b"),
+			"crossplane/provider-helm:v0.2.0": digest("c"),
+			"crossplane/provider-helm:v0.3.0": digest("d"),
+		},
+		deps: map[string][]string{
+			"acme/network:v1":                 {"crossplane/provider-gcp:v0.12.0"},
+			"crossplane/provider-gcp:v0.12.0": {"crossplane/provider-helm:v0.2.0"},
+		},
+	}
+	deps := []parser.MetaDependency{
+		{Package: "acme/network", Version: "v1"},
+		{Package: "crossplane/provider-gcp", Version: "v0.12.0"},
+	}
+	got, err := Resolve(r.fetcher(), deps, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Lockfile{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Packages: []Package{
+			{Package: "acme/network", Version: "v1", Digest: digest("a"), Dependencies: []string{"crossplane/provider-gcp:v0.12.0"}},
+			{Package: "crossplane/provider-gcp", Version: "v0.12.0", Digest: digest("b"), Dependencies: []string{"crossplane/provider-helm:v0.2.0"}},
+			{Package: "crossplane/provider-helm", Version: "v0.2.0", Digest: digest("c")},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Resolve(...): -want, +got:\n%s", diff)
+	}
+
+	// Locked versions are not resolved again, even if their tags have moved.
+	r.digests["crossplane/provider-gcp:v0.12.0"] = digest("e")
+	r.resolved = nil
+	again, err := Resolve(r.fetcher(), append(deps, parser.MetaDependency{Package: "crossplane/provider-helm", Version: "v0.2.0"}), got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, again); diff != "" {
+		t.Errorf("Resolve(...): -want, +got:\n%s", diff)
+	}
+	if len(r.resolved) != 0 {
+		t.Errorf("Resolve(...): want no images resolved, got %v", r.resolved)
+	}
+
+	// A version that is required twice cannot be resolved.
+	_, err = Resolve(r.fetcher(), append(deps, parser.MetaDependency{Package: "crossplane/provider-helm", Version: "v0.3.0"}), nil)
+	wantErr := "conflicting versions of crossplane/provider-helm: v0.3.0 required by crossplane.yaml, v0.2.0 required by crossplane/provider-gcp"
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("Resolve(...): want error %q, got %v", wantErr, err)
+	}
+}
+
+func TestPin(t *testing.T) {
+	l := &Lockfile{Packages: []Package{{Package: "crossplane/provider-gcp", Version: "v0.12.0", Digest: digest("b")}}}
+	got, err := l.Pin([]parser.MetaDependency{{Package: "crossplane/provider-gcp", Version: "v0.12.0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []v1alpha1.Dependency{{Package: "crossplane/provider-gcp@" + digest("b")}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Pin(...): -want, +got:\n%s", diff)
+	}
+	if _, err := l.Pin([]parser.MetaDependency{{Package: "crossplane/provider-gcp", Version: "v0.13.0"}}); err == nil {
+		t.Errorf("Pin(...): want error for an unlocked version")
+	}
+}
+
+func TestClosure(t *testing.T) {
+	l := &Lockfile{Packages: []Package{
+		{Package: "acme/network", Version: "v1", Digest: digest("a"), Dependencies: []string{"crossplane/provider-gcp:v0.12.0"}},
+		{Package: "crossplane/provider-gcp", Version: "v0.12.0", Digest: digest("b")},
+	}}
+	got, err := l.Closure([]parser.MetaDependency{{Package: "acme/network", Version: "v1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []v1alpha1.Dependency{
+		{Package: "acme/network@" + digest("a")},
+		{Package: "crossplane/provider-gcp@" + digest("b")},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Closure(...): -want, +got:\n%s", diff)
+	}
+	if _, err := l.Closure([]parser.MetaDependency{{Package: "acme/network", Version: "v2"}}); err == nil {
+		t.Errorf("Closure(...): want error for an unlocked version")
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if l, err := Read(fs, "/pkg"); l != nil || err != nil {
+		t.Fatalf("Read(...): want no lockfile, got %v, %v", l, err)
+	}
+	want := &Lockfile{APIVersion: APIVersion, Kind: Kind, Packages: []Package{{Package: "crossplane/provider-gcp", Version: "v0.12.0", Digest: digest("b")}}}
+	if err := Write(fs, "/pkg", want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(fs, "/pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Read(...): -want, +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package outdated
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// A Constraint is satisfied by a range of semantic versions.
+type Constraint struct {
+	raw         string
+	comparators []comparator
+}
+
+// A comparator compares a version to a bound.
+type comparator struct {
+	op    string
+	bound *version.Version
+}
+
+// ParseConstraint parses a version constraint. A constraint is one or more
+// comparators separated by commas or spaces, all of which must be satisfied.
+// A comparator is a version preceded by one of =, >, >=, <, <=, ^ or ~. As
+// with Cargo, a bare version is a caret constraint: ^v0.12.0 is satisfied by
+// any v0.12 version, and ^v1.2.0 by any v1 version from v1.2.0.
+// ~v1.2.0 is satisfied by any v1.2 version from v1.2.0.
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{raw: s}
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(fields) == 0 {
+		return nil, errors.Errorf("invalid version constraint %q: no versions", s)
+	}
+	for _, f := range fields {
+		op := ""
+		for _, o := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+			if strings.HasPrefix(f, o) {
+				op = o
+				break
+			}
+		}
+		v, err := version.ParseSemantic(f[len(op):])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version constraint %q", s)
+		}
+		switch op {
+		case "", "^":
+			c.comparators = append(c.comparators, comparator{">=", v}, comparator{"<", caret(v)})
+		case "~":
+			c.comparators = append(c.comparators, comparator{">=", v}, comparator{"<", v.WithMinor(v.Minor() + 1).WithPatch(0).WithPreRelease("")})
+		default:
+			c.comparators = append(c.comparators, comparator{op, v})
+		}
+	}
+	return c, nil
+}
+
+// caret returns the lowest version that is not compatible with v: the next
+// major version, or if the major version is zero the next minor version, or
+// if that is also zero the next patch version.
+func caret(v *version.Version) *version.Version {
+	switch {
+	case v.Major() > 0:
+		return v.WithMajor(v.Major() + 1).WithMinor(0).WithPatch(0).WithPreRelease("")
+	case v.Minor() > 0:
+		return v.WithMinor(v.Minor() + 1).WithPatch(0).WithPreRelease("")
+	default:
+		return v.WithPatch(v.Patch() + 1).WithPreRelease("")
+	}
+}
+
+// Check returns true if v satisfies the constraint.
+func (c *Constraint) Check(v *version.Version) bool {
+	for _, cmp := range c.comparators {
+		n := 0
+		switch {
+		case v.LessThan(cmp.bound):
+			n = -1
+		case cmp.bound.LessThan(v):
+			n = 1
+		}
+		ok := false
+		switch cmp.op {
+		case "=":
+			ok = n == 0
+		case ">":
+			ok = n > 0
+		case ">=":
+			ok = n >= 0
+		case "<":
+			ok = n < 0
+		case "<=":
+			ok = n <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Constraint) String() string {
+	return c.raw
+}
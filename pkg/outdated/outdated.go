@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package outdated finds newer versions of packages in their registries.
+package outdated
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// A Package whose versions are checked.
+type Package struct {
+	// Package is the repository of the package, e.g. crossplane/provider-gcp.
+	Package string
+
+	// Current version of the package, which is also the constraint that the
+	// wanted version must satisfy. It may be empty, in which case any version
+	// is wanted.
+	Current string
+}
+
+// A Result of checking the versions of a package.
+type Result struct {
+	Package string `json:"package"`
+	Current string `json:"current"`
+
+	// Wanted is the newest version that satisfies the current version
+	// constraint. It is empty if no version does.
+	Wanted string `json:"wanted"`
+
+	// Latest is the newest version.
+	Latest string `json:"latest"`
+}
+
+// A ListFn lists the tags of a repository.
+type ListFn func(repo string) ([]string, error)
+
+// ListTags lists the tags of a repository in its registry.
+func ListTags(repo string) ([]string, error) {
+	r, err := name.NewRepository(repo)
+	if err != nil {
+		return nil, err
+	}
+	return remote.List(r, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+}
+
+// FromImage returns the package installed from the supplied image. The current
+// version of the package is the tag of the image or, if the image is pinned
+// only by digest, its digest.
+func FromImage(image string) Package {
+	p := Package{Package: image}
+	if i := strings.Index(p.Package, "@"); i >= 0 {
+		p = Package{Package: image[:i], Current: image[i+1:]}
+	}
+	if i := strings.LastIndex(p.Package, ":"); i > strings.LastIndex(p.Package, "/") {
+		p = Package{Package: p.Package[:i], Current: p.Package[i+1:]}
+	}
+	return p
+}
+
+// Check lists the tags of each package and returns the wanted and latest
+// version of each. Tags that are not semantic versions, and pre-releases, are
+// ignored. No version is wanted for a package whose current version is not a
+// version constraint, such as a tag like latest or a digest.
+func Check(pkgs []Package, list ListFn) ([]Result, error) {
+	results := make([]Result, 0, len(pkgs))
+	for _, p := range pkgs {
+		var c *Constraint
+		constrained := true
+		if p.Current != "" {
+			var err error
+			if c, err = ParseConstraint(p.Current); err != nil {
+				constrained = false
+			}
+		}
+		tags, err := list(p.Package)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot list tags of %s", p.Package)
+		}
+		r := Result{Package: p.Package, Current: p.Current}
+		for _, t := range releases(tags) {
+			r.Latest = t.tag
+			if constrained && (c == nil || c.Check(t.v)) {
+				r.Wanted = t.tag
+			}
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// A release is a tag that is a semantic version.
+type release struct {
+	tag string
+	v   *version.Version
+}
+
+// releases returns the supplied tags that are semantic versions and not
+// pre-releases, from oldest to newest.
+func releases(tags []string) []release {
+	out := []release{}
+	for _, t := range tags {
+		v, err := version.ParseSemantic(t)
+		if err != nil || v.PreRelease() != "" {
+			continue
+		}
+		out = append(out, release{tag: t, v: v})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].v.LessThan(out[j].v) })
+	return out
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package outdated
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+var (
+	manifestPath = regexp.MustCompile(`^/v2/(.+)/manifests/([^/]+)$`)
+	tagsPath     = regexp.MustCompile(`^/v2/(.+)/tags/list$`)
+)
+
+// tagRegistry is an in-process registry. The registry of go-containerregistry
+// does not list tags, so tagRegistry records the tags that are pushed to it
+// and lists them.
+type tagRegistry struct {
+	handler http.Handler
+
+	mu   sync.Mutex
+	tags map[string][]string
+}
+
+func (r *tagRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if m := tagsPath.FindStringSubmatch(req.URL.Path); m != nil && req.Method == http.MethodGet {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		tags := `{"name":"` + m[1] + `","tags":[`
+		for i, t := range r.tags[m[1]] {
+			if i > 0 {
+				tags += ","
+			}
+			tags += `"` + t + `"`
+		}
+		w.Write([]byte(tags + "]}")) // nolint:errcheck
+		return
+	}
+	if m := manifestPath.FindStringSubmatch(req.URL.Path); m != nil && req.Method == http.MethodPut {
+		r.mu.Lock()
+		r.tags[m[1]] = append(r.tags[m[1]], m[2])
+		r.mu.Unlock()
+	}
+	r.handler.ServeHTTP(w, req)
+}
+
+// serve starts an in-process registry to which images with the supplied tags
+// have been pushed, and returns its host and a function that stops it.
+func serve(t *testing.T, tags map[string][]string) (string, func()) {
+	s := httptest.NewServer(&tagRegistry{handler: registry.New(registry.Logger(log.New(ioutil.Discard, "", 0))), tags: map[string][]string{}})
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repos := []string{}
+	for repo := range tags {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	for _, repo := range repos {
+		for _, tag := range tags[repo] {
+			ref, err := name.NewTag(u.Host + "/" + repo + ":" + tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := remote.Write(ref, img); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return u.Host, s.Close
+}
+
+func TestCheck(t *testing.T) {
+	host, stop := serve(t, map[string][]string{
+		"crossplane/provider-gcp":  {"v0.11.0", "v0.11.1", "v0.12.0", "v0.13.0-rc.1", "latest"},
+		"crossplane/provider-helm": {"v0.2.0"},
+		"acme/platform":            {"v1.0.0", "v1.2.0", "v2.0.0"},
+	})
+	defer stop()
+	digest := "sha256:" + strings.Repeat("a", 64)
+	pkgs := []Package{
+		{Package: host + "/crossplane/provider-gcp", Current: "v0.11.0"},
+		{Package: host + "/crossplane/provider-helm", Current: "v0.2.0"},
+		{Package: host + "/acme/platform", Current: ">=v1.0.0, <v2.0.0"},
+		{Package: host + "/acme/platform"},
+		FromImage(host + "/crossplane/provider-gcp:latest"),
+		FromImage(host + "/crossplane/provider-gcp@" + digest),
+		FromImage(host + "/crossplane/provider-helm:v0.2.0@" + digest),
+	}
+	got, err := Check(pkgs, ListTags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Result{
+		{Package: host + "/crossplane/provider-gcp", Current: "v0.11.0", Wanted: "v0.11.1", Latest: "v0.12.0"},
+		{Package: host + "/crossplane/provider-helm", Current: "v0.2.0", Wanted: "v0.2.0", Latest: "v0.2.0"},
+		{Package: host + "/acme/platform", Current: ">=v1.0.0, <v2.0.0", Wanted: "v1.2.0", Latest: "v2.0.0"},
+		{Package: host + "/acme/platform", Wanted: "v2.0.0", Latest: "v2.0.0"},
+		{Package: host + "/crossplane/provider-gcp", Current: "latest", Latest: "v0.12.0"},
+		{Package: host + "/crossplane/provider-gcp", Current: digest, Latest: "v0.12.0"},
+		{Package: host + "/crossplane/provider-helm", Current: "v0.2.0", Wanted: "v0.2.0", Latest: "v0.2.0"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Check(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	cases := map[string]struct {
+		constraint string
+		match      []string
+		miss       []string
+	}{
+		"Caret": {
+			constraint: "v1.2.0",
+			match:      []string{"v1.2.0", "v1.9.3"},
+			miss:       []string{"v1.1.9", "v2.0.0"},
+		},
+		"CaretZero": {
+			constraint: "^v0.12.1",
+			match:      []string{"v0.12.1", "v0.12.9"},
+			miss:       []string{"v0.12.0", "v0.13.0"},
+		},
+		"Tilde": {
+			constraint: "~v1.2.0",
+			match:      []string{"v1.2.5"},
+			miss:       []string{"v1.3.0"},
+		},
+		"Exact": {
+			constraint: "=v1.2.0",
+			match:      []string{"v1.2.0"},
+			miss:       []string{"v1.2.1"},
+		},
+		"Range": {
+			constraint: ">v1.0.0 <=v1.5.0",
+			match:      []string{"v1.0.1", "v1.5.0"},
+			miss:       []string{"v1.0.0", "v1.5.1"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c, err := ParseConstraint(tc.constraint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range tc.match {
+				if !c.Check(version.MustParseSemantic(v)) {
+					t.Errorf("Check(%s): want %s satisfied", v, tc.constraint)
+				}
+			}
+			for _, v := range tc.miss {
+				if c.Check(version.MustParseSemantic(v)) {
+					t.Errorf("Check(%s): want %s not satisfied", v, tc.constraint)
+				}
+			}
+		})
+	}
+	if _, err := ParseConstraint(">=latest"); err == nil {
+		t.Errorf("ParseConstraint(...): want error for an invalid version")
+	}
+}
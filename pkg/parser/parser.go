@@ -20,6 +20,7 @@ import (
 	"bufio"
 	"errors"
 	"os"
+	"path/filepath"
 	"strings"
 
 	apiv1alpha1 "github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
@@ -27,16 +28,72 @@ import (
 	"github.com/hasheddan/crank/apis/v1alpha1"
 	"github.com/spf13/afero"
 	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// MetaGroup is the API group of package metadata.
+const MetaGroup = "pkg.crossplane.io"
+
+// Kinds of package metadata.
+const (
+	ConfigurationKind = "Configuration"
+	ProviderKind      = "Provider"
+)
+
+// Meta is the metadata of a package, as declared in its crossplane.yaml.
+type Meta struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MetaSpec `json:"spec,omitempty"`
+}
+
+// MetaSpec specifies the dependencies of a package and the paths that should
+// be ignored when it is built.
+type MetaSpec struct {
+	DependsOn []MetaDependency `json:"dependsOn,omitempty"`
+	Ignore    []MetaIgnore     `json:"ignore,omitempty"`
+}
+
+// MetaDependency is a package dependency.
+type MetaDependency struct {
+	Package string `json:"package"`
+	Version string `json:"version,omitempty"`
+}
+
+// Image returns the image reference of the dependency.
+func (d MetaDependency) Image() string {
+	if d.Version == "" {
+		return d.Package
+	}
+	return d.Package + ":" + d.Version
+}
+
+// MetaIgnore is a path that is ignored when a package is built.
+type MetaIgnore struct {
+	Path string `json:"path"`
+}
+
 // Package is a Crossplane package.
 type Package struct {
 	Name                       string
+	Root                       string
 	CustomResourceDefinitions  map[string]apiextensions.CustomResourceDefinition
 	InfrastructureDefinitions  map[string]apiv1alpha1.InfrastructureDefinition
 	InfrastructurePublications map[string]apiv1alpha1.InfrastructurePublication
 	Compositions               map[string]apiv1alpha1.Composition
 	Dependencies               []v1alpha1.Dependency
+
+	// Objects contains the type of every other Kubernetes object in the
+	// package, keyed by path.
+	Objects map[string]metav1.TypeMeta
+
+	// Meta is the package metadata, or nil if the package has none.
+	Meta *Meta
+
+	// Files contains the raw contents of every parsed object, keyed by path.
+	Files map[string][]byte
 }
 
 // Parser parses a package.
@@ -54,10 +111,13 @@ func NewParser(fs afero.Fs) *Parser {
 // ParsePackage parses a package at the given path and returns it.
 func (p *Parser) ParsePackage(root string) (*Package, error) {
 	pkg := &Package{
+		Root:                       root,
 		CustomResourceDefinitions:  map[string]apiextensions.CustomResourceDefinition{},
 		InfrastructureDefinitions:  map[string]apiv1alpha1.InfrastructureDefinition{},
 		InfrastructurePublications: map[string]apiv1alpha1.InfrastructurePublication{},
 		Compositions:               map[string]apiv1alpha1.Composition{},
+		Objects:                    map[string]metav1.TypeMeta{},
+		Files:                      map[string][]byte{},
 	}
 	if err := afero.Walk(p.fs, root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -70,26 +130,45 @@ func (p *Parser) ParsePackage(root string) (*Package, error) {
 		if err != nil {
 			return err
 		}
+		m := &Meta{}
+		if err := parseMeta(b, m); err == nil {
+			pkg.Name = m.GetName()
+			pkg.Meta = m
+			for _, d := range m.Spec.DependsOn {
+				pkg.Dependencies = append(pkg.Dependencies, v1alpha1.Dependency{Package: d.Image()})
+			}
+			pkg.Files[path] = b
+			return nil
+		}
 		crd := &apiextensions.CustomResourceDefinition{}
 		if err := parseCRD(b, crd); err == nil {
 			pkg.CustomResourceDefinitions[path] = *crd
+			pkg.Files[path] = b
 			return nil
 		}
 		id := &apiv1alpha1.InfrastructureDefinition{}
 		if err := parseID(b, id); err == nil {
 			pkg.InfrastructureDefinitions[path] = *id
+			pkg.Files[path] = b
 			return nil
 		}
 		ip := &apiv1alpha1.InfrastructurePublication{}
 		if err := parseIP(b, ip); err == nil {
 			pkg.InfrastructurePublications[path] = *ip
+			pkg.Files[path] = b
 			return nil
 		}
 		c := &apiv1alpha1.Composition{}
 		if err := parseC(b, c); err == nil {
 			pkg.Compositions[path] = *c
+			pkg.Files[path] = b
 			return nil
 		}
+		o := &metav1.TypeMeta{}
+		if err := parseObject(b, o); err == nil {
+			pkg.Objects[path] = *o
+			pkg.Files[path] = b
+		}
 		return nil
 	}); err != nil {
 		return pkg, err
@@ -97,6 +176,26 @@ func (p *Parser) ParsePackage(root string) (*Package, error) {
 	return pkg, nil
 }
 
+// Ignored returns true if the file at path is beneath a path that the package
+// metadata specifies should be ignored.
+func (p *Package) Ignored(path string) bool {
+	if p.Meta == nil {
+		return false
+	}
+	rel, err := filepath.Rel(p.Root, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, i := range p.Meta.Spec.Ignore {
+		dir := strings.TrimSuffix(filepath.ToSlash(filepath.Clean(i.Path)), "/")
+		if rel == dir || strings.HasPrefix(rel, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 // ParseLines finds the start and end line for the match.
 func (p *Parser) ParseLines(path, startMatch, endMatch string) (int, int, error) {
 	var startLine, endLine int
@@ -123,6 +222,18 @@ func (p *Parser) ParseLines(path, startMatch, endMatch string) (int, int, error)
 	return startLine, endLine, err
 }
 
+func parseMeta(b []byte, m *Meta) error {
+	err := yaml.Unmarshal(b, m)
+	if err != nil {
+		return err
+	}
+	gv, err := schema.ParseGroupVersion(m.APIVersion)
+	if err != nil || gv.Group != MetaGroup {
+		return errors.New("not package metadata")
+	}
+	return nil
+}
+
 func parseCRD(b []byte, crd *apiextensions.CustomResourceDefinition) error {
 	err := yaml.Unmarshal(b, crd)
 	if err == nil && crd.Kind != "CustomResourceDefinition" {
@@ -154,3 +265,11 @@ func parseC(b []byte, c *apiv1alpha1.Composition) error {
 	}
 	return err
 }
+
+func parseObject(b []byte, o *metav1.TypeMeta) error {
+	err := yaml.Unmarshal(b, o)
+	if err == nil && (o.APIVersion == "" || o.Kind == "") {
+		return errors.New("not a Kubernetes object")
+	}
+	return err
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	runtimev1alpha1 "github.com/crossplane/crossplane-runtime/apis/core/v1alpha1"
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// Output formats.
+const (
+	FormatTable = "table"
+	FormatWide  = "wide"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+	FormatName  = "name"
+)
+
+// Formats are the supported output formats, in the order they are documented.
+var Formats = []string{FormatTable, FormatWide, FormatJSON, FormatYAML, FormatName}
+
+// Usage describes the output flag of commands that use a Printer.
+var Usage = "Output format. One of: " + strings.Join(Formats, ", ") + "."
+
+// A Column of a table.
+type Column struct {
+	// Name is the column header.
+	Name string
+
+	// Wide columns are only printed in the wide format.
+	Wide bool
+}
+
+// A Row of a table.
+type Row struct {
+	// Name of the object the row describes. It is printed by the name format.
+	Name string
+
+	// Cells of the row, one per column.
+	Cells []string
+}
+
+// A Table describes how objects are printed in human readable formats.
+type Table struct {
+	Columns []Column
+	Rows    []Row
+}
+
+// A Printer writes obj, which must be serializable as JSON, or the supplied
+// table that describes it.
+type Printer func(w io.Writer, obj interface{}, t Table) error
+
+// Printers are the supported output formats.
+var Printers = map[string]Printer{
+	FormatTable: PrintTable,
+	FormatWide:  PrintWide,
+	FormatJSON:  PrintJSON,
+	FormatYAML:  PrintYAML,
+	FormatName:  PrintName,
+}
+
+// PrinterFor returns the Printer for the named output format.
+func PrinterFor(format string) (Printer, error) {
+	p, ok := Printers[format]
+	if !ok {
+		return nil, errors.Errorf("unknown output format %q: must be one of %s", format, strings.Join(Formats, ", "))
+	}
+	return p, nil
+}
+
+// ValidateFormat returns an error unless format is json, yaml, or the
+// supplied human readable format, e.g. text, of a command that does not use a
+// Printer.
+func ValidateFormat(format, human string) error {
+	switch format {
+	case human, FormatJSON, FormatYAML:
+		return nil
+	}
+	return errors.Errorf("unknown output format %q: must be one of %s, %s, %s", format, human, FormatJSON, FormatYAML)
+}
+
+// PrintTable writes the table, omitting wide columns.
+func PrintTable(w io.Writer, _ interface{}, t Table) error {
+	return printTable(w, t, false)
+}
+
+// PrintWide writes the table, including wide columns.
+func PrintWide(w io.Writer, _ interface{}, t Table) error {
+	return printTable(w, t, true)
+}
+
+// none is printed in empty table cells.
+const none = "<none>"
+
+func printTable(w io.Writer, t Table, wide bool) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	cells := func(c []string) string {
+		s := []string{}
+		for i, col := range t.Columns {
+			if col.Wide && !wide {
+				continue
+			}
+			v := none
+			if i < len(c) && c[i] != "" {
+				v = c[i]
+			}
+			s = append(s, v)
+		}
+		return strings.Join(s, "\t") + "\n"
+	}
+	header := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		header[i] = c.Name
+	}
+	if _, err := io.WriteString(tw, cells(header)); err != nil {
+		return err
+	}
+	for _, r := range t.Rows {
+		if _, err := io.WriteString(tw, cells(r.Cells)); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// PrintJSON writes obj as indented JSON.
+func PrintJSON(w io.Writer, obj interface{}, _ Table) error {
+	b, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal JSON")
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// PrintYAML writes obj as YAML.
+func PrintYAML(w io.Writer, obj interface{}, _ Table) error {
+	b, err := yaml.Marshal(obj)
+	if err != nil {
+		return errors.Wrap(err, "cannot marshal YAML")
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// PrintName writes the name of each row of the table, one per line.
+func PrintName(w io.Writer, _ interface{}, t Table) error {
+	for _, r := range t.Rows {
+		if _, err := fmt.Fprintln(w, r.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Age returns the human readable age of an object created at t.
+func Age(t metav1.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(t.Time))
+}
+
+// Ready returns the status of the Ready condition of an object.
+func Ready(s runtimev1alpha1.ConditionedStatus) string {
+	return string(s.GetCondition(runtimev1alpha1.TypeReady).Status)
+}
+
+// IsTerminal returns true if w is a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// ColorEnabled returns true if colored output should be written to w. Color is
+// disabled if w is not a terminal, or if the NO_COLOR environment variable is
+// set. See https://no-color.org.
+func ColorEnabled(w io.Writer) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return IsTerminal(w)
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package printer
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPrinters(t *testing.T) {
+	type object struct {
+		Name  string `json:"name"`
+		Image string `json:"image,omitempty"`
+	}
+	obj := []object{{Name: "provider-aws", Image: "crossplane/provider-aws:v0.12.0"}, {Name: "gcp"}}
+	table := Table{
+		Columns: []Column{{Name: "NAME"}, {Name: "IMAGE"}, {Name: "DEPENDENCIES", Wide: true}},
+		Rows: []Row{
+			{Name: "provider-aws", Cells: []string{"provider-aws", "crossplane/provider-aws:v0.12.0", "a,b"}},
+			{Name: "gcp", Cells: []string{"gcp", ""}},
+		},
+	}
+
+	cases := map[string]struct {
+		want string
+	}{
+		FormatTable: {
+			want: "NAME           IMAGE\n" +
+				"provider-aws   crossplane/provider-aws:v0.12.0\n" +
+				"gcp            <none>\n",
+		},
+		FormatWide: {
+			want: "NAME           IMAGE                             DEPENDENCIES\n" +
+				"provider-aws   crossplane/provider-aws:v0.12.0   a,b\n" +
+				"gcp            <none>                            <none>\n",
+		},
+		FormatJSON: {
+			want: `[
+  {
+    "name": "provider-aws",
+    "image": "crossplane/provider-aws:v0.12.0"
+  },
+  {
+    "name": "gcp"
+  }
+]
+`,
+		},
+		FormatYAML: {
+			want: `- image: crossplane/provider-aws:v0.12.0
+  name: provider-aws
+- name: gcp
+`,
+		},
+		FormatName: {
+			want: "provider-aws\ngcp\n",
+		},
+	}
+	for format, tc := range cases {
+		t.Run(format, func(t *testing.T) {
+			p, err := PrinterFor(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := &bytes.Buffer{}
+			if err := p(b, obj, table); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, b.String()); diff != "" {
+				t.Errorf("%s: -want, +got:\n%s", format, diff)
+			}
+		})
+	}
+
+	if _, err := PrinterFor("xml"); err == nil {
+		t.Errorf("PrinterFor(\"xml\"): want error, got nil")
+	}
+}
+
+func TestValidateFormat(t *testing.T) {
+	for _, f := range []string{"text", FormatJSON, FormatYAML} {
+		if err := ValidateFormat(f, "text"); err != nil {
+			t.Errorf("ValidateFormat(%q, \"text\"): want nil, got %v", f, err)
+		}
+	}
+	for _, f := range []string{"", FormatTable, "xml"} {
+		if err := ValidateFormat(f, "text"); err == nil {
+			t.Errorf("ValidateFormat(%q, \"text\"): want error, got nil", f)
+		}
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	if ColorEnabled(&bytes.Buffer{}) {
+		t.Errorf("ColorEnabled(buffer): want false, got true")
+	}
+
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	want := IsTerminal(f)
+	if got := ColorEnabled(f); got != want {
+		t.Errorf("ColorEnabled(%s): want %t, got %t", os.DevNull, want, got)
+	}
+
+	os.Setenv("NO_COLOR", "")     // nolint:errcheck
+	defer os.Unsetenv("NO_COLOR") // nolint:errcheck
+	if ColorEnabled(f) {
+		t.Errorf("ColorEnabled(...) with NO_COLOR set: want false, got true")
+	}
+}
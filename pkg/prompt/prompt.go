@@ -19,106 +19,349 @@ package prompt
 import (
 	"bufio"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/hasheddan/crank/pkg/printer"
 )
 
-// Output colors.
+// Styles of messages, as ANSI SGR parameters.
 const (
-	InfoColor    = "\033[1;34m%s\033[0m"
-	NoticeColor  = "\033[1;36m%s\033[0m"
-	WarningColor = "\033[1;33m%s\033[0m"
-	ErrorColor   = "\033[1;31m%s\033[0m"
-	DebugColor   = "\033[0;36m%s\033[0m"
+	styleInfo    = "1;34"
+	styleNotice  = "1;36"
+	styleWarning = "1;33"
+	styleError   = "1;31"
+	styleDebug   = "0;36"
 )
 
+// color is true if messages should be colored. Messages are colored if they
+// are written to a terminal and NO_COLOR is not set.
+var color = printer.ColorEnabled(os.Stdout)
+
+// SetColor enables or disables colored messages.
+func SetColor(enabled bool) {
+	color = enabled
+}
+
+func colorize(style, s string) string {
+	if !color {
+		return s
+	}
+	return sgr(style, s)
+}
+
+// sgr wraps s in the supplied ANSI SGR style.
+func sgr(style, s string) string {
+	return "\033[" + style + "m" + s + "\033[0m"
+}
+
 // FmtInfo formats an info message.
 func FmtInfo(s string) string {
-	return fmt.Sprintf(InfoColor, s)
+	return colorize(styleInfo, s)
 }
 
 // FmtNotice formats an notice message.
 func FmtNotice(s string) string {
-	return fmt.Sprintf(NoticeColor, s)
+	return colorize(styleNotice, s)
 }
 
 // FmtWarning formats a warning message.
 func FmtWarning(s string) string {
-	return fmt.Sprintf(WarningColor, s)
+	return colorize(styleWarning, s)
 }
 
 // FmtError formats an error message.
 func FmtError(s string) string {
-	return fmt.Sprintf(ErrorColor, s)
+	return colorize(styleError, s)
 }
 
 // FmtDebug formats a debug message.
 func FmtDebug(s string) string {
-	return fmt.Sprintf(DebugColor, s)
+	return colorize(styleDebug, s)
 }
 
-// A Step is a step in prompter execution.
-type Step int
+// BackCommand is the answer that returns to the previous step.
+const BackCommand = "back"
 
-// A Fn prompts a user for input.
-type Fn func(p Prompter, s string) (string, bool)
+var (
+	// ErrBack is returned by Ask when the user asks to return to the
+	// previous question.
+	ErrBack = errors.New("back")
 
-// BareFn is a Fn that requires no input.
-func BareFn(p func() (string, bool)) Fn {
-	return func(_ Prompter, _ string) (string, bool) {
-		return p()
+	// ErrEOF is returned when input ends before a question is answered.
+	ErrEOF = errors.New("input ended before the question was answered")
+
+	// ErrInterrupted is returned when the prompter is interrupted.
+	ErrInterrupted = errors.New("interrupted")
+
+	// ErrAborted may be returned by a Step to stop running steps.
+	ErrAborted = errors.New("aborted")
+)
+
+// A Question is asked by a Prompter.
+type Question struct {
+	// Message is the question.
+	Message string
+
+	// Default is the answer if the user enters nothing. Multiple default
+	// options of a multi-select question are separated by commas.
+	Default string
+
+	// Options the user must select from. The user may enter an option or its
+	// number.
+	Options []string
+
+	// Multi allows the user to select multiple, comma separated options.
+	// Options may contain spaces, but not commas.
+	Multi bool
+
+	// Validate returns an error describing why an answer is invalid. The
+	// question is asked again if an answer is invalid.
+	Validate func(answer []string) error
+}
+
+// Confirm returns a yes or no question. Use Yes to interpret the answer.
+func Confirm(message string, def bool) Question {
+	d := "N"
+	if def {
+		d = "Y"
+	}
+	return Question{
+		Message: message + " (Y/N)",
+		Default: d,
+		Validate: func(a []string) error {
+			switch strings.ToLower(a[0]) {
+			case "y", "yes", "n", "no":
+				return nil
+			}
+			return errors.New("please answer Y or N")
+		},
 	}
 }
 
-// BinaryFn is a Fn that expects a yes or no answer.
-func BinaryFn(f func(bool, Prompter, string) (string, bool)) Fn {
-	return func(p Prompter, s string) (string, bool) {
-		if s == "Y" {
-			return f(true, p, s)
-		}
-		return f(false, p, s)
+// Yes returns true if the answer to a Confirm question is yes.
+func Yes(answer []string) bool {
+	return len(answer) > 0 && (strings.EqualFold(answer[0], "y") || strings.EqualFold(answer[0], "yes"))
+}
+
+// A Step is a question in a sequence of questions run by a Prompter.
+type Step struct {
+	// Question returns the question asked by the step. It is called each time
+	// the step is run, so it may depend on the answers to previous steps.
+	Question func() Question
+
+	// Skip returns true if the step should not be asked. It may be nil.
+	Skip func() bool
+
+	// Answer is called with the answer to the question. Returning an error
+	// asks the question again, unless the error is ErrBack, ErrEOF,
+	// ErrInterrupted, or ErrAborted. It may be nil.
+	Answer func(answer []string) error
+}
+
+// An Option configures a Prompter.
+type Option func(*Prompter)
+
+// WithInput specifies the input the Prompter reads answers from. Defaults to
+// stdin.
+func WithInput(r io.Reader) Option {
+	return func(p *Prompter) {
+		p.in = r
 	}
 }
 
-// Prompter is the interface that must be satisfied by a Prompter.
-type Prompter interface {
-	Prompt() error
-	SetStep(Step)
+// WithOutput specifies the output the Prompter writes questions to. Defaults
+// to stdout.
+func WithOutput(w io.Writer) Option {
+	return func(p *Prompter) {
+		p.out = w
+	}
 }
 
-// prompter is an interactive command prompt.
-type prompter struct {
-	initialPrompt string
-	scanner       *bufio.Scanner
-	steps         map[Step]Fn
-	step          Step
+// WithInterrupt specifies a channel that interrupts the Prompter, typically
+// one that is notified of os.Interrupt.
+func WithInterrupt(c <-chan os.Signal) Option {
+	return func(p *Prompter) {
+		p.interrupt = c
+	}
 }
 
-// NewPrompter returns a new prompter.
-func NewPrompter(initial string, scanner *bufio.Scanner, steps map[Step]Fn, firstStep Step) Prompter {
-	return &prompter{
-		initialPrompt: initial,
-		scanner:       scanner,
-		steps:         steps,
-		step:          firstStep,
+// A Prompter asks questions and reads their answers.
+type Prompter struct {
+	in        io.Reader
+	out       io.Writer
+	interrupt <-chan os.Signal
+
+	// color is true if messages written to out should be colored.
+	color bool
+
+	lines chan string
+}
+
+// NewPrompter returns a new Prompter.
+func NewPrompter(opts ...Option) *Prompter {
+	p := &Prompter{in: os.Stdin, out: os.Stdout}
+	for _, o := range opts {
+		o(p)
 	}
+	p.color = printer.ColorEnabled(p.out)
+	return p
 }
 
-// SetStep sets the current prompter step.
-func (p *prompter) SetStep(s Step) {
-	p.step = s
+// Info writes an info message to the output of the Prompter.
+func (p *Prompter) Info(s string) {
+	p.printf("%s\n", p.colorize(styleInfo, s))
 }
 
-// Prompt runs a Prompter.
-func (p *prompter) Prompt() error {
-	fmt.Printf("%s\n--> ", p.initialPrompt)
-	for {
-		p.scanner.Scan()
-		t := p.scanner.Text()
-		out, done := p.steps[p.step](p, t)
-		if done {
-			fmt.Println(out)
-			break
+// Run asks the question of each step in order. Entering BackCommand returns to
+// the previous step that was not skipped.
+func (p *Prompter) Run(steps ...Step) error {
+	asked := []int{}
+	for i := 0; i < len(steps); {
+		s := steps[i]
+		if s.Skip != nil && s.Skip() {
+			i++
+			continue
+		}
+		a, err := p.Ask(s.Question())
+		if err == nil && s.Answer != nil {
+			err = s.Answer(a)
+		}
+		switch {
+		case err == ErrBack && len(asked) == 0:
+			p.printf("%s\n", p.colorize(styleWarning, "Already at the first question."))
+		case err == ErrBack:
+			i, asked = asked[len(asked)-1], asked[:len(asked)-1]
+		case err == ErrEOF || err == ErrInterrupted || err == ErrAborted:
+			return err
+		case err != nil:
+			p.printf("%s\n", p.colorize(styleError, err.Error()))
+		default:
+			asked = append(asked, i)
+			i++
 		}
-		fmt.Printf("%s\n--> ", out)
 	}
 	return nil
 }
+
+// Ask asks a question until it is answered validly. It returns the selected
+// options of a select question, or the entered text otherwise.
+func (p *Prompter) Ask(q Question) ([]string, error) {
+	for {
+		p.printf("%s\n", q.Message)
+		for i, o := range q.Options {
+			p.printf("  %d) %s\n", i+1, o)
+		}
+		if q.Default != "" {
+			p.printf("[%s] ", q.Default)
+		}
+		p.printf("--> ")
+
+		line, err := p.readLine()
+		if err != nil {
+			p.printf("\n")
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == BackCommand {
+			return nil, ErrBack
+		}
+		if line == "" {
+			line = q.Default
+		}
+		a, err := q.parse(line)
+		if err == nil && q.Validate != nil {
+			err = q.Validate(a)
+		}
+		if err != nil {
+			p.printf("%s\n", p.colorize(styleError, err.Error()))
+			continue
+		}
+		return a, nil
+	}
+}
+
+// parse parses an answer to the question.
+func (q Question) parse(line string) ([]string, error) {
+	if line == "" {
+		return nil, errors.New("an answer is required")
+	}
+	if len(q.Options) == 0 {
+		return []string{line}, nil
+	}
+	fields := []string{line}
+	if q.Multi {
+		fields = strings.Split(line, ",")
+	}
+	selected := []string{}
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		o, ok := q.option(f)
+		if !ok {
+			return nil, errors.Errorf("%q is not an option", f)
+		}
+		selected = append(selected, o)
+	}
+	if len(selected) == 0 {
+		return nil, errors.New("select at least one option")
+	}
+	return selected, nil
+}
+
+// option returns the option matching an answer, which may be the option or
+// its number.
+func (q Question) option(answer string) (string, bool) {
+	if n, err := strconv.Atoi(answer); err == nil && n > 0 && n <= len(q.Options) {
+		return q.Options[n-1], true
+	}
+	for _, o := range q.Options {
+		if strings.EqualFold(o, answer) {
+			return o, true
+		}
+	}
+	return "", false
+}
+
+// readLine reads a line of input. Lines are read in a separate goroutine so
+// that reading may be interrupted.
+func (p *Prompter) readLine() (string, error) {
+	if p.lines == nil {
+		p.lines = make(chan string)
+		go func() {
+			s := bufio.NewScanner(p.in)
+			for s.Scan() {
+				p.lines <- s.Text()
+			}
+			close(p.lines)
+		}()
+	}
+	select {
+	case l, ok := <-p.lines:
+		if !ok {
+			return "", ErrEOF
+		}
+		return l, nil
+	case <-p.interrupt:
+		return "", ErrInterrupted
+	}
+}
+
+// colorize colors messages written to the output of the Prompter, unless
+// NO_COLOR is set or the output is not a terminal.
+func (p *Prompter) colorize(style, s string) string {
+	if !p.color {
+		return s
+	}
+	return sgr(style, s)
+}
+
+func (p *Prompter) printf(format string, a ...interface{}) {
+	fmt.Fprintf(p.out, format, a...) // nolint:errcheck
+}
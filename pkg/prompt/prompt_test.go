@@ -0,0 +1,168 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun(t *testing.T) {
+	type answers struct {
+		Kind    string
+		Name    string
+		Regions []string
+	}
+	steps := func(a *answers) []Step {
+		return []Step{
+			{
+				Question: func() Question { return Question{Message: "Kind?", Options: []string{"Configuration", "Provider"}} },
+				Answer:   func(s []string) error { a.Kind = s[0]; return nil },
+			},
+			{
+				Question: func() Question {
+					return Question{Message: "Name?", Default: "example", Validate: func(s []string) error {
+						if strings.Contains(s[0], " ") {
+							return errors.New("name must not contain spaces")
+						}
+						return nil
+					}}
+				},
+				Answer: func(s []string) error { a.Name = s[0]; return nil },
+			},
+			{
+				Question: func() Question {
+					return Question{Message: "Regions?", Options: []string{"us", "eu", "asia", "us east"}, Multi: true, Default: "us"}
+				},
+				Answer: func(s []string) error { a.Regions = s; return nil },
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		input string
+		want  answers
+		err   error
+	}{
+		"Defaults": {
+			input: "provider\n\n\n",
+			want:  answers{Kind: "Provider", Name: "example", Regions: []string{"us"}},
+		},
+		"Invalid": {
+			input: "3\n1\nmy bucket\nbucket\nus,mars\neu, asia\n",
+			want:  answers{Kind: "Configuration", Name: "bucket", Regions: []string{"eu", "asia"}},
+		},
+		"OptionWithSpace": {
+			input: "1\n\nus east, 2\n",
+			want:  answers{Kind: "Configuration", Name: "example", Regions: []string{"us east", "eu"}},
+		},
+		"Back": {
+			input: "back\n1\nbucket\nback\nback\n2\nother\n3\n",
+			want:  answers{Kind: "Provider", Name: "other", Regions: []string{"asia"}},
+		},
+		"EOF": {
+			input: "1\n",
+			want:  answers{Kind: "Configuration"},
+			err:   ErrEOF,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := answers{}
+			p := NewPrompter(WithInput(strings.NewReader(tc.input)), WithOutput(ioutil.Discard))
+			if err := p.Run(steps(&got)...); err != tc.err {
+				t.Fatalf("Run(...): want error %v, got %v", tc.err, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Run(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAskOutput(t *testing.T) {
+	out := &bytes.Buffer{}
+	p := NewPrompter(WithInput(strings.NewReader("maybe\ny\n")), WithOutput(out))
+	a, err := p.Ask(Confirm("Continue?", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Yes(a) {
+		t.Errorf("Yes(...): want true, got false")
+	}
+	// Messages are not colored because the output is not a terminal.
+	want := "Continue? (Y/N)\n[N] --> please answer Y or N\nContinue? (Y/N)\n[N] --> "
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Errorf("Ask(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	out := &bytes.Buffer{}
+	NewPrompter(WithOutput(out)).Info("Added 2 dependencies.")
+	if diff := cmp.Diff("Added 2 dependencies.\n", out.String()); diff != "" {
+		t.Errorf("Info(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestColorize(t *testing.T) {
+	defer SetColor(color)
+	SetColor(true)
+	if got, want := FmtError("failed"), "\033[1;31mfailed\033[0m"; got != want {
+		t.Errorf("FmtError(...): want %q, got %q", want, got)
+	}
+	SetColor(false)
+	if got, want := FmtError("failed"), "failed"; got != want {
+		t.Errorf("FmtError(...): want %q, got %q", want, got)
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	interrupt := make(chan os.Signal, 1)
+	interrupt <- os.Interrupt
+	p := NewPrompter(WithInput(r), WithOutput(ioutil.Discard), WithInterrupt(interrupt))
+	if _, err := p.Ask(Question{Message: "Name?"}); err != ErrInterrupted {
+		t.Errorf("Ask(...): want error %v, got %v", ErrInterrupted, err)
+	}
+}
+
+func TestPrompterColor(t *testing.T) {
+	defer SetColor(color)
+	SetColor(false)
+	p := NewPrompter(WithOutput(&bytes.Buffer{}))
+	p.color = true
+	if got, want := p.colorize(styleError, "failed"), "\033[1;31mfailed\033[0m"; got != want {
+		t.Errorf("colorize(...): want %q, got %q", want, got)
+	}
+
+	SetColor(true)
+	if got, want := NewPrompter(WithOutput(&bytes.Buffer{})).colorize(styleError, "failed"), "failed"; got != want {
+		t.Errorf("colorize(...): want %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,453 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package state exports the packages installed in a cluster to a file, and
+// converges a cluster to the state that a file describes.
+package state
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/dag"
+	"github.com/hasheddan/crank/pkg/install"
+	"github.com/hasheddan/crank/pkg/lock"
+)
+
+// DefaultFile is the default file the state of a cluster is written to.
+const DefaultFile = "crank.lock.yaml"
+
+// Type metadata of a State.
+const (
+	APIVersion = "crank.crossplane.io/v1alpha1"
+	Kind       = "State"
+)
+
+// A State describes the packages installed in a cluster.
+type State struct {
+	APIVersion string    `json:"apiVersion"`
+	Kind       string    `json:"kind"`
+	Packages   []Package `json:"packages"`
+}
+
+// A Package installed in a cluster.
+type Package struct {
+	// Kind of the package, either Provider or Configuration.
+	Kind string `json:"kind"`
+
+	// Name of the package object.
+	Name string `json:"name"`
+
+	// Package is the image the package is installed from.
+	Package string `json:"package"`
+
+	// Digest of the image of the active revision, which names the revision.
+	// Any revision of the package may be active if it is empty.
+	Digest string `json:"digest,omitempty"`
+
+	// Dependencies of the package, as recorded in the PackageLock.
+	Dependencies []string `json:"dependencies,omitempty"`
+
+	// PullSecrets are the names of secrets used to pull the package.
+	PullSecrets []string `json:"pullSecrets,omitempty"`
+
+	// PullPolicy is the image pull policy of the package.
+	PullPolicy string `json:"pullPolicy,omitempty"`
+}
+
+func (p Package) String() string {
+	return p.Kind + " " + p.Name
+}
+
+// New returns a State of the supplied packages.
+func New(pkgs ...Package) *State {
+	return &State{APIVersion: APIVersion, Kind: Kind, Packages: append([]Package{}, pkgs...)}
+}
+
+// Read parses a State from YAML.
+func Read(b []byte) (*State, error) {
+	s := &State{}
+	if err := yaml.Unmarshal(b, s); err != nil {
+		return nil, errors.Wrap(err, "cannot parse state")
+	}
+	if s.APIVersion != APIVersion || s.Kind != Kind {
+		return nil, errors.Errorf("cannot parse state: want apiVersion %s and kind %s", APIVersion, Kind)
+	}
+	for _, p := range s.Packages {
+		if p.Kind != v1alpha1.ProviderKind && p.Kind != v1alpha1.ConfigurationKind {
+			return nil, errors.Errorf("cannot parse state: %s has unknown kind %q", p.Name, p.Kind)
+		}
+		if err := install.ValidatePullPolicy(p.PullPolicy); err != nil {
+			return nil, errors.Wrapf(err, "cannot parse state: %s", p)
+		}
+	}
+	return s, nil
+}
+
+// Export returns the State of the packages installed in a cluster, sorted by
+// kind and name.
+func Export(ctx context.Context, c client.Client) (*State, error) {
+	l := &v1alpha1.PackageLock{}
+	if err := c.Get(ctx, types.NamespacedName{Name: install.LockName}, l); client.IgnoreNotFound(err) != nil {
+		return nil, errors.Wrap(err, "cannot get PackageLock")
+	}
+	objs := []v1alpha1.Package{}
+	ps := &v1alpha1.ProviderList{}
+	if err := c.List(ctx, ps); err != nil {
+		return nil, errors.Wrap(err, "cannot list Providers")
+	}
+	for i := range ps.Items {
+		objs = append(objs, &ps.Items[i])
+	}
+	cs := &v1alpha1.ConfigurationList{}
+	if err := c.List(ctx, cs); err != nil {
+		return nil, errors.Wrap(err, "cannot list Configurations")
+	}
+	for i := range cs.Items {
+		objs = append(objs, &cs.Items[i])
+	}
+
+	s := New()
+	for _, o := range objs {
+		p := export(o)
+		revs, err := install.Revisions(ctx, c, o, newRevisionList(p.Kind))
+		if err != nil {
+			return nil, err
+		}
+		if a := install.Active(revs); a != nil {
+			p.Digest = a.GetName()
+		}
+		if e, ok := l.Spec.Packages[lock.Key(p.Package)]; ok && e.Name == p.Name {
+			p.Dependencies = e.Dependencies
+		}
+		s.Packages = append(s.Packages, p)
+	}
+	sort.SliceStable(s.Packages, func(i, j int) bool {
+		if s.Packages[i].Kind != s.Packages[j].Kind {
+			return s.Packages[i].Kind < s.Packages[j].Kind
+		}
+		return s.Packages[i].Name < s.Packages[j].Name
+	})
+	return s, nil
+}
+
+// An Action changes a package.
+type Action string
+
+// Actions that converge a cluster to a State.
+const (
+	// ActionCreate installs a package.
+	ActionCreate Action = "create"
+
+	// ActionUpdate changes the image or pull settings of a package. The
+	// revision of a new image is activated once it has been created.
+	ActionUpdate Action = "update"
+
+	// ActionActivate activates another revision of a package.
+	ActionActivate Action = "activate"
+
+	// ActionDelete uninstalls a package.
+	ActionDelete Action = "delete"
+)
+
+// A Change converges a package to its desired state.
+type Change struct {
+	Action Action
+
+	// Current state of the package. Empty when it is created.
+	Current Package
+
+	// Desired state of the package. Empty when it is deleted.
+	Desired Package
+}
+
+func (c Change) String() string {
+	switch c.Action {
+	case ActionCreate:
+		return fmt.Sprintf("+ %s: %s", c.Desired, image(c.Desired))
+	case ActionDelete:
+		return fmt.Sprintf("- %s: %s", c.Current, image(c.Current))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", c.Desired, image(c.Current), image(c.Desired))
+	}
+}
+
+// image returns the image of a package, including its digest if it is known.
+func image(p Package) string {
+	if p.Digest == "" {
+		return p.Package
+	}
+	return p.Package + " (" + p.Digest + ")"
+}
+
+// Diff returns the changes that converge the current State to the desired
+// State. Packages that are not in the desired State are only deleted if prune
+// is true. Packages are deleted first, dependents before their dependencies,
+// then created and updated in the order computed by dag.Sort, dependencies
+// before their dependents. It returns an error if the packages of the desired
+// State cannot be installed together.
+func Diff(current, desired *State, prune bool) ([]Change, error) {
+	want := map[string]Package{}
+	deps := map[string]v1alpha1.PackageDependencies{}
+	for _, p := range desired.Packages {
+		if _, ok := want[p.String()]; ok {
+			return nil, errors.Errorf("%s is in the desired state more than once", p)
+		}
+		want[p.String()] = p
+		deps[p.String()] = v1alpha1.PackageDependencies{Name: p.Name, Image: p.Package, Dependencies: p.Dependencies}
+	}
+	if problems := lock.Validate(deps); len(problems) > 0 {
+		return nil, errors.Errorf("desired state is invalid: %s", problems[0])
+	}
+	have := map[string]Package{}
+	for _, p := range current.Packages {
+		have[p.String()] = p
+	}
+
+	changes := []Change{}
+	if prune {
+		for _, p := range reverse(order(current.Packages)) {
+			if _, ok := want[p.String()]; !ok {
+				changes = append(changes, Change{Action: ActionDelete, Current: p})
+			}
+		}
+	}
+	for _, p := range order(desired.Packages) {
+		h, ok := have[p.String()]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Action: ActionCreate, Desired: p})
+		case h.Package != p.Package || h.PullPolicy != p.PullPolicy || strings.Join(h.PullSecrets, ",") != strings.Join(p.PullSecrets, ","):
+			changes = append(changes, Change{Action: ActionUpdate, Current: h, Desired: p})
+		case p.Digest != "" && h.Digest != p.Digest:
+			changes = append(changes, Change{Action: ActionActivate, Current: h, Desired: p})
+		}
+	}
+	return changes, nil
+}
+
+// order returns the supplied packages in the order computed by dag.Sort, or
+// in the supplied order if they do not form a valid graph.
+func order(pkgs []Package) []Package {
+	deps := map[string]v1alpha1.PackageDependencies{}
+	byKey := map[string][]Package{}
+	for _, p := range pkgs {
+		deps[p.String()] = v1alpha1.PackageDependencies{Image: p.Package, Dependencies: p.Dependencies}
+		byKey[lock.Key(p.Package)] = append(byKey[lock.Key(p.Package)], p)
+	}
+	d, err := dag.New(deps)
+	if err != nil {
+		return pkgs
+	}
+	keys, err := d.Sort()
+	if err != nil {
+		return pkgs
+	}
+	out := make([]Package, 0, len(pkgs))
+	for _, k := range keys {
+		out = append(out, byKey[k]...)
+	}
+	return out
+}
+
+// reverse returns the supplied packages in reverse order.
+func reverse(pkgs []Package) []Package {
+	out := make([]Package, len(pkgs))
+	for i, p := range pkgs {
+		out[len(pkgs)-1-i] = p
+	}
+	return out
+}
+
+// Apply makes the supplied changes in order, writing progress to w. The
+// revision of an updated image is awaited, polling at the supplied interval,
+// until ctx is done.
+func Apply(ctx context.Context, c client.Client, w io.Writer, changes []Change, interval time.Duration) error {
+	for _, ch := range changes {
+		var err error
+		switch ch.Action {
+		case ActionCreate:
+			err = create(ctx, c, ch.Desired)
+		case ActionUpdate:
+			err = update(ctx, c, ch.Desired, interval)
+		case ActionActivate:
+			err = activate(ctx, c, ch.Desired, "")
+		case ActionDelete:
+			err = remove(ctx, c, ch.Current)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, ch) // nolint:errcheck
+	}
+	return nil
+}
+
+func create(ctx context.Context, c client.Client, p Package) error {
+	o, err := newPackage(p)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(c.Create(ctx, o), "cannot create %s", p)
+}
+
+func update(ctx context.Context, c client.Client, p Package, interval time.Duration) error {
+	o, err := get(ctx, c, p)
+	if err != nil {
+		return err
+	}
+	changed := o.GetSource() != p.Package
+	o.SetSource(p.Package)
+	setPullSettings(o, p)
+	if err := c.Update(ctx, o); err != nil {
+		return errors.Wrapf(err, "cannot update %s", p)
+	}
+	if !changed {
+		return activate(ctx, c, p, "")
+	}
+	if _, err := install.WaitForRevision(ctx, c, o, func() v1alpha1.PackageRevisionList { return newRevisionList(p.Kind) }, p.Package, interval); err != nil {
+		return err
+	}
+	return activate(ctx, c, p, p.Package)
+}
+
+// activate activates the revision of the supplied package that is named by
+// its digest, or if it has no digest the latest revision from source. Nothing
+// is activated if neither is set.
+func activate(ctx context.Context, c client.Client, p Package, source string) error {
+	if p.Digest == "" && source == "" {
+		return nil
+	}
+	o, err := get(ctx, c, p)
+	if err != nil {
+		return err
+	}
+	revs, err := install.Revisions(ctx, c, o, newRevisionList(p.Kind))
+	if err != nil {
+		return err
+	}
+	var target v1alpha1.PackageRevision
+	for _, r := range revs {
+		if (p.Digest != "" && r.GetName() == p.Digest) || (p.Digest == "" && r.GetSource() == source) {
+			target = r
+		}
+	}
+	if target == nil {
+		return errors.Errorf("cannot activate %s: no revision from %s", p, image(p))
+	}
+	if err := install.Activate(ctx, c, o, revs, target); err != nil {
+		return errors.Wrapf(err, "cannot activate %s", p)
+	}
+	return nil
+}
+
+// remove deletes the supplied package, and its entry in the PackageLock so
+// that it may be installed again.
+func remove(ctx context.Context, c client.Client, p Package) error {
+	o, err := get(ctx, c, p)
+	if kerrors.IsNotFound(errors.Cause(err)) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.Delete(ctx, o); client.IgnoreNotFound(err) != nil {
+		return errors.Wrapf(err, "cannot delete %s", p)
+	}
+	l := &v1alpha1.PackageLock{}
+	if err := c.Get(ctx, types.NamespacedName{Name: install.LockName}, l); err != nil {
+		return errors.Wrap(client.IgnoreNotFound(err), "cannot get PackageLock")
+	}
+	if e, ok := l.Spec.Packages[lock.Key(p.Package)]; !ok || e.Name != p.Name {
+		return nil
+	}
+	delete(l.Spec.Packages, lock.Key(p.Package))
+	return errors.Wrap(c.Update(ctx, l), "cannot update PackageLock")
+}
+
+// export returns the Package of the supplied object.
+func export(o v1alpha1.Package) Package {
+	p := Package{Name: o.GetName(), Package: o.GetSource()}
+	var secrets []corev1.LocalObjectReference
+	switch o := o.(type) {
+	case *v1alpha1.Provider:
+		p.Kind = v1alpha1.ProviderKind
+		secrets, p.PullPolicy = o.Spec.ImagePullSecrets, string(o.Spec.ImagePullPolicy)
+	case *v1alpha1.Configuration:
+		p.Kind = v1alpha1.ConfigurationKind
+		secrets, p.PullPolicy = o.Spec.ImagePullSecrets, string(o.Spec.ImagePullPolicy)
+	}
+	for _, s := range secrets {
+		p.PullSecrets = append(p.PullSecrets, s.Name)
+	}
+	return p
+}
+
+// newPackage returns a new object of the supplied package.
+func newPackage(p Package) (v1alpha1.Package, error) {
+	o := install.Options{Name: p.Name, Package: p.Package, PullSecrets: p.PullSecrets, PullPolicy: corev1.PullPolicy(p.PullPolicy)}
+	switch p.Kind {
+	case v1alpha1.ProviderKind:
+		return install.NewProvider(o), nil
+	case v1alpha1.ConfigurationKind:
+		return install.NewConfiguration(o), nil
+	}
+	return nil, errors.Errorf("%s has unknown kind %q", p.Name, p.Kind)
+}
+
+// get returns the object of the supplied package.
+func get(ctx context.Context, c client.Client, p Package) (v1alpha1.Package, error) {
+	o, err := newPackage(Package{Kind: p.Kind, Name: p.Name})
+	if err != nil {
+		return nil, err
+	}
+	return o, errors.Wrapf(c.Get(ctx, types.NamespacedName{Name: p.Name}, o), "cannot get %s", p)
+}
+
+// setPullSettings sets the pull secrets and policy of the supplied object to
+// those of the supplied package.
+func setPullSettings(o v1alpha1.Package, p Package) {
+	n, _ := newPackage(p)
+	switch o := o.(type) {
+	case *v1alpha1.Provider:
+		o.Spec.ImagePullSecrets = n.(*v1alpha1.Provider).Spec.ImagePullSecrets
+		o.Spec.ImagePullPolicy = n.(*v1alpha1.Provider).Spec.ImagePullPolicy
+	case *v1alpha1.Configuration:
+		o.Spec.ImagePullSecrets = n.(*v1alpha1.Configuration).Spec.ImagePullSecrets
+		o.Spec.ImagePullPolicy = n.(*v1alpha1.Configuration).Spec.ImagePullPolicy
+	}
+}
+
+// newRevisionList returns a list of the revisions of packages of the
+// supplied kind.
+func newRevisionList(kind string) v1alpha1.PackageRevisionList {
+	if kind == v1alpha1.ConfigurationKind {
+		return &v1alpha1.ConfigurationRevisionList{}
+	}
+	return &v1alpha1.ProviderRevisionList{}
+}
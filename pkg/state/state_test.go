@@ -0,0 +1,215 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/hasheddan/crank/apis"
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/install"
+)
+
+func scheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	if err := apis.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := apiextensions.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func revision(pkg, name, image string, n int64, state v1alpha1.PackageRevisionDesiredState) *v1alpha1.ProviderRevision {
+	r := &v1alpha1.ProviderRevision{ObjectMeta: metav1.ObjectMeta{
+		Name:   name,
+		Labels: map[string]string{install.LabelPackage: pkg},
+	}}
+	r.SetSource(image)
+	r.SetRevision(n)
+	r.SetDesiredState(state)
+	return r
+}
+
+// cluster returns a cluster with provider-gcp, which depends on provider-helm,
+// and provider-aws installed.
+func cluster() []runtime.Object {
+	return []runtime.Object{
+		install.NewProvider(install.Options{Name: "provider-helm", Package: "crossplane/provider-helm:v0.2.0"}),
+		install.NewProvider(install.Options{Name: "provider-gcp", Package: "crossplane/provider-gcp:v0.11.0", PullSecrets: []string{"registry"}}),
+		install.NewProvider(install.Options{Name: "provider-aws", Package: "crossplane/provider-aws:v0.12.0"}),
+		revision("provider-helm", "helm1", "crossplane/provider-helm:v0.2.0", 1, v1alpha1.PackageRevisionActive),
+		revision("provider-gcp", "gcp1", "crossplane/provider-gcp:v0.11.0", 1, v1alpha1.PackageRevisionActive),
+		revision("provider-gcp", "gcp2", "crossplane/provider-gcp:v0.12.0", 2, v1alpha1.PackageRevisionInactive),
+		revision("provider-aws", "aws1", "crossplane/provider-aws:v0.12.0", 1, v1alpha1.PackageRevisionActive),
+		&v1alpha1.PackageLock{
+			ObjectMeta: metav1.ObjectMeta{Name: install.LockName},
+			Spec: v1alpha1.PackageLockSpec{Packages: map[string]v1alpha1.PackageDependencies{
+				"crossplane/provider-helm": {Name: "provider-helm", Image: "crossplane/provider-helm:v0.2.0"},
+				"crossplane/provider-gcp":  {Name: "provider-gcp", Image: "crossplane/provider-gcp:v0.11.0", Dependencies: []string{"crossplane/provider-helm"}},
+				"crossplane/provider-aws":  {Name: "provider-aws", Image: "crossplane/provider-aws:v0.12.0"},
+			}},
+		},
+	}
+}
+
+func provider(name, image, digest string, deps ...string) Package {
+	return Package{Kind: v1alpha1.ProviderKind, Name: name, Package: image, Digest: digest, Dependencies: deps}
+}
+
+func TestExport(t *testing.T) {
+	c := fake.NewFakeClientWithScheme(scheme(t), cluster()...)
+	got, err := Export(context.Background(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcp := provider("provider-gcp", "crossplane/provider-gcp:v0.11.0", "gcp1", "crossplane/provider-helm")
+	gcp.PullSecrets = []string{"registry"}
+	want := New(
+		provider("provider-aws", "crossplane/provider-aws:v0.12.0", "aws1"),
+		gcp,
+		provider("provider-helm", "crossplane/provider-helm:v0.2.0", "helm1"),
+	)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Export(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	current := New(
+		provider("provider-aws", "crossplane/provider-aws:v0.12.0", "aws1"),
+		provider("provider-gcp", "crossplane/provider-gcp:v0.11.0", "gcp1", "crossplane/provider-helm"),
+		provider("provider-helm", "crossplane/provider-helm:v0.2.0", "helm1"),
+	)
+	desired := New(
+		provider("platform", "acme/platform:v1", "", "crossplane/provider-gcp", "crossplane/provider-azure"),
+		provider("provider-azure", "crossplane/provider-azure:v0.12.0", ""),
+		provider("provider-gcp", "crossplane/provider-gcp:v0.11.0", "gcp2", "crossplane/provider-helm"),
+		provider("provider-helm", "crossplane/provider-helm:v0.3.0", ""),
+	)
+	cases := map[string]struct {
+		desired *State
+		prune   bool
+		want    []string
+		err     string
+	}{
+		"NoPrune": {
+			desired: desired,
+			want: []string{
+				"~ Provider provider-helm: crossplane/provider-helm:v0.2.0 (helm1) -> crossplane/provider-helm:v0.3.0",
+				"~ Provider provider-gcp: crossplane/provider-gcp:v0.11.0 (gcp1) -> crossplane/provider-gcp:v0.11.0 (gcp2)",
+				"+ Provider provider-azure: crossplane/provider-azure:v0.12.0",
+				"+ Provider platform: acme/platform:v1",
+			},
+		},
+		"Prune": {
+			desired: desired,
+			prune:   true,
+			want: []string{
+				"- Provider provider-aws: crossplane/provider-aws:v0.12.0 (aws1)",
+				"~ Provider provider-helm: crossplane/provider-helm:v0.2.0 (helm1) -> crossplane/provider-helm:v0.3.0",
+				"~ Provider provider-gcp: crossplane/provider-gcp:v0.11.0 (gcp1) -> crossplane/provider-gcp:v0.11.0 (gcp2)",
+				"+ Provider provider-azure: crossplane/provider-azure:v0.12.0",
+				"+ Provider platform: acme/platform:v1",
+			},
+		},
+		"MissingDependency": {
+			desired: New(provider("platform", "acme/platform:v1", "", "crossplane/provider-gcp")),
+			err:     "desired state is invalid: acme/platform -> crossplane/provider-gcp: missing dependency crossplane/provider-gcp",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			changes, err := Diff(current, tc.desired, tc.prune)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("Diff(...): want error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, ch := range changes {
+				got = append(got, ch.String())
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("Diff(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewFakeClientWithScheme(scheme(t), cluster()...)
+	desired := New(
+		provider("provider-gcp", "crossplane/provider-gcp:v0.12.0", "", "crossplane/provider-helm"),
+		provider("provider-helm", "crossplane/provider-helm:v0.2.0", "helm1"),
+		provider("provider-azure", "crossplane/provider-azure:v0.12.0", ""),
+	)
+	current, err := Export(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, err := Diff(current, desired, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply(ctx, c, &bytes.Buffer{}, changes, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	// The controller creates the revision of provider-azure, so it has none.
+	got, err := Export(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := New(
+		provider("provider-azure", "crossplane/provider-azure:v0.12.0", ""),
+		provider("provider-gcp", "crossplane/provider-gcp:v0.12.0", "gcp2", "crossplane/provider-helm"),
+		provider("provider-helm", "crossplane/provider-helm:v0.2.0", "helm1"),
+	)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Apply(...): -want, +got:\n%s", diff)
+	}
+
+	err = c.Get(ctx, types.NamespacedName{Name: "provider-aws"}, &v1alpha1.Provider{})
+	if !kerrors.IsNotFound(err) {
+		t.Errorf("Apply(...): want provider-aws deleted, got %v", err)
+	}
+	l := &v1alpha1.PackageLock{}
+	if err := c.Get(ctx, types.NamespacedName{Name: install.LockName}, l); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := l.Spec.Packages["crossplane/provider-aws"]; ok {
+		t.Errorf("Apply(...): want provider-aws removed from the PackageLock")
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package suggest suggests the dependencies of a package from the resources
+// its Compositions compose.
+package suggest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/hasheddan/crank/pkg/parser"
+)
+
+// A Table maps API groups to the packages that provide them. A group matches
+// an entry if it equals or is a subdomain of the entry's group, for example
+// storage.gcp.crossplane.io matches gcp.crossplane.io. Packages may include a
+// version, for example crossplane/provider-gcp:v0.11.0.
+type Table struct {
+	Groups map[string]string `json:"groups"`
+}
+
+// DefaultTable maps the API groups of well known providers to their packages.
+var DefaultTable = Table{Groups: map[string]string{
+	"alibaba.crossplane.io": "crossplane/provider-alibaba",
+	"aws.crossplane.io":     "crossplane/provider-aws",
+	"azure.crossplane.io":   "crossplane/provider-azure",
+	"gcp.crossplane.io":     "crossplane/provider-gcp",
+	"helm.crossplane.io":    "crossplane/provider-helm",
+	"rook.crossplane.io":    "crossplane/provider-rook",
+}}
+
+// ParseTable parses a YAML or JSON Table.
+func ParseTable(b []byte) (Table, error) {
+	t := Table{}
+	if err := yaml.Unmarshal(b, &t); err != nil {
+		return Table{}, errors.Wrap(err, "cannot parse dependency table")
+	}
+	return t, nil
+}
+
+// FetchIndex fetches a Table from a registry index at url.
+func FetchIndex(c *http.Client, url string) (Table, error) {
+	resp, err := c.Get(url)
+	if err != nil {
+		return Table{}, errors.Wrap(err, "cannot fetch dependency index")
+	}
+	defer resp.Body.Close() // nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		return Table{}, errors.Errorf("cannot fetch dependency index: %s", resp.Status)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Table{}, errors.Wrap(err, "cannot read dependency index")
+	}
+	return ParseTable(b)
+}
+
+// Merge returns a table containing the entries of t, overridden by those of o.
+func (t Table) Merge(o Table) Table {
+	m := Table{Groups: map[string]string{}}
+	for g, p := range t.Groups {
+		m.Groups[g] = p
+	}
+	for g, p := range o.Groups {
+		m.Groups[g] = p
+	}
+	return m
+}
+
+// Lookup returns the package that provides the supplied group. The most
+// specific matching entry wins.
+func (t Table) Lookup(group string) (string, bool) {
+	best := ""
+	for g := range t.Groups {
+		if (group == g || strings.HasSuffix(group, "."+g)) && len(g) > len(best) {
+			best = g
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	return t.Groups[best], true
+}
+
+// A Suggestion is a suggested dependency.
+type Suggestion struct {
+	// Package and Version of the suggested dependency. Package is empty if
+	// no package provides Groups, and Version is empty if the table did not
+	// specify one.
+	Package string `json:"package"`
+	Version string `json:"version,omitempty"`
+
+	// Groups are the composed API groups the dependency provides.
+	Groups []string `json:"groups"`
+}
+
+// Suggest returns the dependencies that provide the API groups of the
+// resources composed by the package. Groups defined by the package itself are
+// ignored. Groups that no package in the table provides are returned in a
+// suggestion with an empty package.
+func Suggest(pkg *parser.Package, t Table) []Suggestion {
+	own := map[string]bool{}
+	for _, d := range pkg.InfrastructureDefinitions {
+		own[d.Spec.CRDSpecTemplate.Group] = true
+	}
+	for _, crd := range pkg.CustomResourceDefinitions {
+		own[crd.Spec.Group] = true
+	}
+
+	groups := map[string]map[string]bool{}
+	for _, c := range pkg.Compositions {
+		for _, to := range c.Spec.To {
+			meta := struct {
+				APIVersion string `json:"apiVersion"`
+			}{}
+			if err := yaml.Unmarshal(to.Base.Raw, &meta); err != nil {
+				continue
+			}
+			gv, err := schema.ParseGroupVersion(meta.APIVersion)
+			if err != nil || gv.Group == "" || own[gv.Group] {
+				continue
+			}
+			p, _ := t.Lookup(gv.Group)
+			if groups[p] == nil {
+				groups[p] = map[string]bool{}
+			}
+			groups[p][gv.Group] = true
+		}
+	}
+
+	suggestions := []Suggestion{}
+	for p, gs := range groups {
+		s := Suggestion{Package: p}
+		if i := strings.LastIndex(p, ":"); i > strings.LastIndex(p, "/") {
+			s.Package, s.Version = p[:i], p[i+1:]
+		}
+		for g := range gs {
+			s.Groups = append(s.Groups, g)
+		}
+		sort.Strings(s.Groups)
+		suggestions = append(suggestions, s)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		if (a.Package == "") != (b.Package == "") {
+			return b.Package == ""
+		}
+		return a.Package < b.Package
+	})
+	return suggestions
+}
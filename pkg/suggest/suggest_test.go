@@ -0,0 +1,90 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package suggest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apiv1alpha1 "github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/pkg/parser"
+)
+
+const composition = `apiVersion: apiextensions.crossplane.io/v1alpha1
+kind: Composition
+metadata:
+  name: buckets
+spec:
+  from:
+    apiVersion: example.org/v1alpha1
+    kind: Bucket
+  to:
+  - base:
+      apiVersion: storage.gcp.crossplane.io/v1alpha3
+      kind: Bucket
+  - base:
+      apiVersion: compute.gcp.crossplane.io/v1beta1
+      kind: Network
+  - base:
+      apiVersion: example.org/v1alpha1
+      kind: Bucket
+  - base:
+      apiVersion: widgets.example.com/v1
+      kind: Widget
+`
+
+func TestSuggest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("groups:\n  storage.gcp.crossplane.io: example/provider-gcp-storage:v0.1.0\n"))
+	}))
+	defer srv.Close()
+
+	index, err := FetchIndex(srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "/pkg/composition.yaml", []byte(composition), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := parser.NewParser(fs).ParsePackage("/pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg.InfrastructureDefinitions["/pkg/definition.yaml"] = definition("example.org")
+
+	want := []Suggestion{
+		{Package: "crossplane/provider-gcp", Groups: []string{"compute.gcp.crossplane.io"}},
+		{Package: "example/provider-gcp-storage", Version: "v0.1.0", Groups: []string{"storage.gcp.crossplane.io"}},
+		{Groups: []string{"widgets.example.com"}},
+	}
+	got := Suggest(pkg, DefaultTable.Merge(index))
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Suggest(...): -want, +got:\n%s", diff)
+	}
+}
+
+func definition(group string) apiv1alpha1.InfrastructureDefinition {
+	d := apiv1alpha1.InfrastructureDefinition{}
+	d.Spec.CRDSpecTemplate.Group = group
+	return d
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unpack
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/parser"
+)
+
+// RegistryDir is the directory of a package image that contains the package.
+const RegistryDir = ".registry"
+
+// cachedFile marks a cache entry as completely written.
+const cachedFile = ".cached"
+
+// FetcherOption is used to configure a Fetcher.
+type FetcherOption func(*Fetcher)
+
+// WithCache caches the contents of fetched packages in the supplied
+// filesystem.
+func WithCache(fs afero.Fs) FetcherOption {
+	return func(f *Fetcher) {
+		f.cache = fs
+	}
+}
+
+// WithPullFn specifies how a Fetcher pulls package images. The function
+// returns the package layer and the digest of the image that was pulled.
+func WithPullFn(fn func(image string) (afero.Fs, string, error)) FetcherOption {
+	return func(f *Fetcher) {
+		f.pull = fn
+	}
+}
+
+// WithDigestFn specifies how a Fetcher resolves the digest of package images.
+func WithDigestFn(fn func(image string) (string, error)) FetcherOption {
+	return func(f *Fetcher) {
+		f.digest = fn
+	}
+}
+
+// A Fetcher fetches the contents of package images.
+type Fetcher struct {
+	cache  afero.Fs
+	pull   func(image string) (afero.Fs, string, error)
+	digest func(image string) (string, error)
+}
+
+// NewFetcher returns a new Fetcher.
+func NewFetcher(opts ...FetcherOption) *Fetcher {
+	f := &Fetcher{
+		pull: func(image string) (afero.Fs, string, error) {
+			img, fs, err := pull(image)
+			if err != nil {
+				return nil, "", err
+			}
+			d, err := img.Digest()
+			if err != nil {
+				return nil, "", err
+			}
+			return fs, d.String(), nil
+		},
+		digest: digest,
+	}
+
+	for _, o := range opts {
+		o(f)
+	}
+
+	return f
+}
+
+// Fetch returns a read-only filesystem containing the package layer of the
+// image, and the digest of the image. A tagged image is resolved to a digest
+// and pulled by that digest, because the tag may have moved. Contents are
+// cached by digest, and cached contents are returned if they exist.
+func (f *Fetcher) Fetch(image string) (afero.Fs, string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return nil, "", err
+	}
+	d, err := f.Digest(image)
+	if err != nil {
+		return nil, "", err
+	}
+	pinned := ref.Context().Digest(d).String()
+	key, err := cacheKey(pinned)
+	if err != nil {
+		return nil, "", err
+	}
+	if f.cache != nil {
+		if ok, _ := afero.Exists(f.cache, filepath.Join(key, cachedFile)); ok {
+			return afero.NewReadOnlyFs(afero.NewBasePathFs(f.cache, key)), d, nil
+		}
+	}
+	fs, pulled, err := f.pull(pinned)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "cannot pull %s", image)
+	}
+	if pulled != d {
+		return nil, "", errors.Errorf("cannot pull %s: pulled digest %s, want %s", image, pulled, d)
+	}
+	if f.cache != nil {
+		if err := store(fs, f.cache, key); err != nil {
+			return nil, "", errors.Wrapf(err, "cannot cache %s", image)
+		}
+	}
+	return afero.NewReadOnlyFs(fs), d, nil
+}
+
+// Digest returns the digest of the image. The digest of a tagged image is
+// always resolved by the registry, because the tag may have moved.
+func (f *Fetcher) Digest(image string) (string, error) {
+	d, err := f.digest(image)
+	return d, errors.Wrapf(err, "cannot resolve digest of %s", image)
+}
+
+// Package fetches and parses the package in the image.
+func (f *Fetcher) Package(image string) (*parser.Package, error) {
+	pkg, _, err := f.PackageDigest(image)
+	return pkg, err
+}
+
+// PackageDigest fetches and parses the package in the image, and returns the
+// digest of the image from which it was parsed.
+func (f *Fetcher) PackageDigest(image string) (*parser.Package, string, error) {
+	fs, d, err := f.Fetch(image)
+	if err != nil {
+		return nil, "", err
+	}
+	pkg, err := parser.NewParser(fs).ParsePackage(RegistryDir)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "cannot parse %s", image)
+	}
+	if pkg.Name == "" {
+		pkg.Name = image
+	}
+	return pkg, d, nil
+}
+
+// PackageDependencies returns the packages that the image depends on, read as
+// the package manager reads them.
+func (f *Fetcher) PackageDependencies(image string) ([]string, error) {
+	fs, _, err := f.Fetch(image)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := dependencies(fs)
+	return deps, errors.Wrapf(err, "cannot read dependencies of %s", image)
+}
+
+// Dependencies fetches and parses each package in the dependency closure of
+// the supplied dependencies. The dependencies of each package are read from
+// its crossplane.yaml. A package is fetched once, at the first image of it
+// that is required.
+func (f *Fetcher) Dependencies(deps []v1alpha1.Dependency) ([]*parser.Package, error) {
+	pkgs := []*parser.Package{}
+	seen := map[string]bool{}
+	queue := append([]v1alpha1.Dependency{}, deps...)
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+		if d.Package == "" || seen[repository(d.Package)] {
+			continue
+		}
+		seen[repository(d.Package)] = true
+		pkg, err := f.Package(d.Package)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, pkg)
+		queue = append(queue, pkg.Dependencies...)
+	}
+	return pkgs, nil
+}
+
+// repository returns the repository of an image, without its tag or digest.
+func repository(image string) string {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return image
+	}
+	return ref.Context().String()
+}
+
+// digest resolves the digest of an image.
+func digest(image string) (string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if d, ok := ref.(name.Digest); ok {
+		return d.DigestStr(), nil
+	}
+	desc, err := remote.Get(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return "", err
+	}
+	return desc.Digest.String(), nil
+}
+
+// cacheKey returns the directory in which the contents of an image are
+// cached.
+func cacheKey(image string) (string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	id := strings.Replace(ref.Identifier(), ":", "-", 1)
+	return filepath.Join(ref.Context().RegistryStr(), ref.Context().RepositoryStr(), id), nil
+}
+
+// store copies the registry directory of fs to dir in the cache. The entry is
+// marked as cached once every file has been written so that a partially
+// written cache entry is never used.
+func store(fs, cache afero.Fs, dir string) error {
+	if err := cache.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := afero.Walk(fs, RegistryDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return cache.MkdirAll(filepath.Join(dir, path), 0755)
+		}
+		b, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+		return afero.WriteFile(cache, filepath.Join(dir, path), b, 0644)
+	}); err != nil {
+		return err
+	}
+	return afero.WriteFile(cache, filepath.Join(dir, cachedFile), nil, 0644)
+}
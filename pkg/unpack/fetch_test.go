@@ -0,0 +1,212 @@
+/*
+Copyright 2020 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unpack
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/spf13/afero"
+
+	"github.com/hasheddan/crank/apis/v1alpha1"
+)
+
+const definition = `apiVersion: apiextensions.crossplane.io/v1alpha1
+kind: InfrastructureDefinition
+metadata:
+  name: buckets.example.org
+spec:
+  crdSpecTemplate:
+    group: example.org
+    version: v1alpha1
+    names:
+      kind: Bucket
+      listKind: BucketList
+      plural: buckets
+      singular: bucket
+`
+
+func TestFetcherCache(t *testing.T) {
+	d := "sha256:" + strings.Repeat("a", 64)
+	pulls := 0
+	f := NewFetcher(
+		WithCache(afero.NewMemMapFs()),
+		WithDigestFn(func(image string) (string, error) {
+			return d, nil
+		}),
+		WithPullFn(func(image string) (afero.Fs, string, error) {
+			pulls++
+			if image != "example.org/bucket@"+d {
+				t.Errorf("Package(...): want pull by digest, got %s", image)
+			}
+			fs := afero.NewMemMapFs()
+			return fs, d, afero.WriteFile(fs, ".registry/definition.yaml", []byte(definition), 0644)
+		}),
+	)
+
+	for i := 0; i < 2; i++ {
+		pkg, err := f.Package("example.org/bucket:v0.1.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pkg.InfrastructureDefinitions) != 1 {
+			t.Fatalf("Package(...): want 1 InfrastructureDefinition, got %d", len(pkg.InfrastructureDefinitions))
+		}
+	}
+	if pulls != 1 {
+		t.Fatalf("Package(...): want 1 pull, got %d", pulls)
+	}
+}
+
+func TestFetcherDigest(t *testing.T) {
+	d := "sha256:" + strings.Repeat("a", 64)
+	got, err := NewFetcher().Digest("example.org/bucket@" + d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != d {
+		t.Errorf("Digest(...): want %s, got %s", d, got)
+	}
+}
+
+func TestFetcherDependencies(t *testing.T) {
+	deps := map[string][]string{
+		"acme/platform":           {"acme/network:v1", "crossplane/provider-gcp:v0.12.0"},
+		"acme/network":            {"crossplane/provider-gcp:v0.11.0"},
+		"crossplane/provider-gcp": {"crossplane/provider-helm:v0.2.0"},
+	}
+	digests := map[string]string{}
+	for i, repo := range []string{"acme/platform", "acme/network", "crossplane/provider-gcp", "crossplane/provider-helm"} {
+		digests[repo] = "sha256:" + strings.Repeat(string(rune('a'+i)), 64)
+	}
+	pulled := []string{}
+	f := NewFetcher(
+		WithDigestFn(func(image string) (string, error) {
+			ref, err := name.ParseReference(image)
+			if err != nil {
+				return "", err
+			}
+			return digests[ref.Context().RepositoryStr()], nil
+		}),
+		WithPullFn(func(image string) (afero.Fs, string, error) {
+			ref, err := name.ParseReference(image)
+			if err != nil {
+				return nil, "", err
+			}
+			repo := ref.Context().RepositoryStr()
+			pulled = append(pulled, repo)
+			meta := "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Configuration\nmetadata:\n  name: " + repo + "\nspec:\n  dependsOn:\n"
+			for _, d := range deps[repo] {
+				p := strings.Split(d, ":")
+				meta += "  - package: " + p[0] + "\n    version: " + p[1] + "\n"
+			}
+			fs := afero.NewMemMapFs()
+			return fs, digests[repo], afero.WriteFile(fs, RegistryDir+"/crossplane.yaml", []byte(meta), 0644)
+		}),
+	)
+
+	pkgs, err := f.Dependencies([]v1alpha1.Dependency{{Package: "acme/platform:v1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, p := range pkgs {
+		got = append(got, p.Name)
+	}
+	want := []string{"acme/platform", "acme/network", "crossplane/provider-gcp", "crossplane/provider-helm"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Dependencies(...): -want, +got:\n%s", diff)
+	}
+	if diff := cmp.Diff(want, pulled); diff != "" {
+		t.Errorf("Dependencies(...): -want, +got pulled:\n%s", diff)
+	}
+}
+
+// push writes an image whose package layer contains a crossplane.yaml for a
+// package of the supplied name to ref, and returns the digest of the image.
+func push(t *testing.T, ref name.Reference, pkg string) string {
+	t.Helper()
+	meta := "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Provider\nmetadata:\n  name: " + pkg + "\n"
+	b := &bytes.Buffer{}
+	tw := tar.NewWriter(b)
+	if err := tw.WriteHeader(&tar.Header{Name: RegistryDir + "/crossplane.yaml", Mode: 0644, Size: int64(len(meta))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(meta)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	l, err := tarball.LayerFromReader(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := mutate.AppendLayers(empty.Image, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(ref, img); err != nil {
+		t.Fatal(err)
+	}
+	d, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d.String()
+}
+
+func TestFetcherMovedTag(t *testing.T) {
+	s := httptest.NewServer(registry.New(registry.Logger(log.New(ioutil.Discard, "", 0))))
+	defer s.Close()
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.NewTag(u.Host + "/crossplane/provider-gcp:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := NewFetcher(WithCache(afero.NewMemMapFs()))
+
+	for _, want := range []string{"provider-gcp", "provider-gcp-moved"} {
+		wantDigest := push(t, ref, want)
+		pkg, d, err := f.PackageDigest(ref.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pkg.Name != want {
+			t.Errorf("PackageDigest(...): want package %s, got %s", want, pkg.Name)
+		}
+		if d != wantDigest {
+			t.Errorf("PackageDigest(...): want digest %s, got %s", wantDigest, d)
+		}
+	}
+}
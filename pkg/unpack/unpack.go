@@ -24,21 +24,52 @@ import (
 	"github.com/ghodss/yaml"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/hasheddan/crank/apis/v1alpha1"
+	"github.com/hasheddan/crank/pkg/parser"
 	"github.com/hasheddan/veneer"
+	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 	apiextensions "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 )
 
-// Unpack unpacks an image and gets its dependencies.
-func Unpack(image string) (string, []string, error) {
+// AppMetadataFile is the file of a package image that declares the packages
+// it depends on.
+const AppMetadataFile = RegistryDir + "/app.yaml"
+
+// pull fetches an image and extracts its package layer, which is always the
+// top layer of the image, into an in-memory filesystem.
+func pull(image string) (v1.Image, afero.Fs, error) {
 	ref, err := name.ParseReference(image)
 	if err != nil {
-		return "", nil, err
+		return nil, nil, err
 	}
 
 	img, err := remote.Image(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(layers) == 0 {
+		return nil, nil, errors.New("image has no layers")
+	}
+
+	fs := afero.NewMemMapFs()
+	if err := veneer.LayerFs(layers[len(layers)-1], fs); err != nil {
+		return nil, nil, err
+	}
+	return img, fs, nil
+}
+
+// Unpack unpacks an image and gets its dependencies, which are read from its
+// app.yaml or, if it has none, from its crossplane.yaml.
+func Unpack(image string) (string, []string, error) {
+	img, fs, err := pull(image)
 	if err != nil {
 		return "", nil, err
 	}
@@ -48,24 +79,40 @@ func Unpack(image string) (string, []string, error) {
 	}
 	digest := strings.TrimLeft(hash.String(), "sha256:")
 
-	layers, err := img.Layers()
-	if err != nil {
-		return digest, nil, err
-	}
+	deps, err := dependencies(fs)
+	return digest, deps, err
+}
 
-	fs := afero.NewMemMapFs()
-	err = veneer.LayerFs(layers[len(layers)-1], fs)
+// dependencies reads the packages that the package layer depends on from its
+// app.yaml, or from its crossplane.yaml if it has no app.yaml.
+func dependencies(fs afero.Fs) ([]string, error) {
+	if ok, _ := afero.Exists(fs, AppMetadataFile); ok {
+		return appDependencies(fs)
+	}
+	pkg, err := parser.NewParser(fs).ParsePackage(RegistryDir)
 	if err != nil {
-		return digest, nil, err
+		return nil, err
 	}
-	bytes, err := afero.ReadFile(fs, ".registry/app.yaml")
+	if pkg.Meta == nil {
+		return nil, errors.Errorf("package has neither %s nor crossplane.yaml", AppMetadataFile)
+	}
+	deps := []string{}
+	for _, d := range pkg.Meta.Spec.DependsOn {
+		deps = append(deps, d.Image())
+	}
+	return deps, nil
+}
+
+// appDependencies reads the packages that the package layer depends on from
+// its app.yaml.
+func appDependencies(fs afero.Fs) ([]string, error) {
+	bytes, err := afero.ReadFile(fs, AppMetadataFile)
 	if err != nil {
-		return digest, nil, err
+		return nil, err
 	}
 	deps := &AppMetadataSpec{}
-	err = yaml.Unmarshal(bytes, deps)
-	if err != nil {
-		return digest, nil, err
+	if err := yaml.Unmarshal(bytes, deps); err != nil {
+		return nil, err
 	}
 	stringDeps := []string{}
 	for _, d := range deps.DependsOn {
@@ -73,28 +120,12 @@ func Unpack(image string) (string, []string, error) {
 			stringDeps = append(stringDeps, d.Package)
 		}
 	}
-	return digest, stringDeps, nil
+	return stringDeps, nil
 }
 
 // Resources unpacks resources from a package.
 func Resources(image string) ([]apiextensions.CustomResourceDefinition, []apiv1alpha1.InfrastructureDefinition, []apiv1alpha1.Composition, error) {
-	ref, err := name.ParseReference(image)
-	if err != nil {
-		return nil, nil, nil, err
-	}
-
-	img, err := remote.Image(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
-	if err != nil {
-		return nil, nil, nil, err
-	}
-
-	layers, err := img.Layers()
-	if err != nil {
-		return nil, nil, nil, err
-	}
-
-	fs := afero.NewMemMapFs()
-	err = veneer.LayerFs(layers[len(layers)-1], fs)
+	_, fs, err := pull(image)
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -18,6 +18,9 @@ package unpack
 
 import (
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
 )
 
 func TestSort(t *testing.T) {
@@ -30,3 +33,44 @@ func TestSort(t *testing.T) {
 		t.Fatalf("Found %d CRDs but expected %d", len(crds), 19)
 	}
 }
+
+func TestDependencies(t *testing.T) {
+	cases := map[string]struct {
+		files map[string]string
+		want  []string
+	}{
+		"AppYAML": {
+			files: map[string]string{
+				AppMetadataFile:                  "dependsOn:\n- package: crossplane/provider-gcp:v0.11.0\n",
+				RegistryDir + "/crossplane.yaml": "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Configuration\nmetadata:\n  name: demo\n",
+			},
+			want: []string{"crossplane/provider-gcp:v0.11.0"},
+		},
+		"CrossplaneYAML": {
+			files: map[string]string{
+				RegistryDir + "/crossplane.yaml": "apiVersion: pkg.crossplane.io/v1alpha1\nkind: Configuration\nmetadata:\n  name: demo\nspec:\n  dependsOn:\n  - package: crossplane/provider-gcp\n    version: v0.11.0\n  - package: crossplane/provider-helm\n",
+			},
+			want: []string{"crossplane/provider-gcp:v0.11.0", "crossplane/provider-helm"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for p, c := range tc.files {
+				if err := afero.WriteFile(fs, p, []byte(c), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := dependencies(fs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("dependencies(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+	if _, err := dependencies(afero.NewMemMapFs()); err == nil {
+		t.Errorf("dependencies(...): want error for a package without metadata")
+	}
+}